@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/config"
+	"github.com/whauzan/todo-api/internal/handler"
+	"github.com/whauzan/todo-api/internal/mail"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/analytics"
+	"github.com/whauzan/todo-api/internal/pkg/captcha"
+	"github.com/whauzan/todo-api/internal/pkg/httpclient"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/pkg/logrotate"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+	"github.com/whauzan/todo-api/internal/pkg/password"
+	"github.com/whauzan/todo-api/internal/pkg/respcache"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// app is the wired server: the router and the parts main runs in the
+// background or drains on shutdown
+type app struct {
+	router            http.Handler
+	healthHandler     *handler.HealthHandler
+	accountService    *service.AccountService
+	corsOriginService *service.CORSOriginService
+	hookService       *service.HookService
+	detector          *metrics.Detector
+	analyticsTracker  *analytics.Tracker
+	lockMetrics       *metrics.Locks
+	closers           []io.Closer
+}
+
+// newApp builds the repositories, services, handlers and middleware of the
+// server and returns its router. The integration tests boot the server the same way.
+func newApp(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) (*app, error) {
+	a := &app{}
+
+	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.JWTExpiryHours)
+	hasher, err := password.NewHasherWithPeppers(password.DefaultCost, cfg.PasswordPeppers, cfg.PasswordPepperVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup password hasher: %w", err)
+	}
+	idGen, err := idgen.NewGenerator(cfg.UUIDVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup ID generator: %w", err)
+	}
+
+	// Initialize business metrics; the registry stays nil when metrics are disabled
+	var metricsRegistry *metrics.Registry
+	var kpis *metrics.KPIs
+	var detector *metrics.Detector
+	var httpMetrics *metrics.HTTP
+	var outboundMetrics *metrics.Outbound
+	var lockMetrics *metrics.Locks
+	var botMetrics *metrics.Bots
+	if cfg.MetricsEnabled {
+		metricsRegistry = metrics.NewRegistry()
+		kpis = metrics.NewKPIs(metricsRegistry)
+		httpMetrics = metrics.NewHTTP(metricsRegistry)
+		outboundMetrics = metrics.NewOutbound(metricsRegistry)
+		lockMetrics = metrics.NewLocks(metricsRegistry)
+		botMetrics = metrics.NewBots(metricsRegistry)
+		detector = metrics.NewDetector(metricsRegistry, kpis, metrics.DetectorConfig{
+			Interval:          time.Minute,
+			LoginFailureRatio: cfg.AnomalyLoginFailureRatio,
+			MinSamples:        int64(cfg.AnomalyMinSamples),
+		}, logger)
+	}
+
+	// Initialize repositories
+	userRepo := postgres.NewUserRepository(pool)
+	todoRepo := postgres.NewTodoRepository(pool)
+	announcementRepo := postgres.NewAnnouncementRepository(pool)
+	onboardingRepo := postgres.NewOnboardingRepository(pool)
+	notificationRepo := postgres.NewNotificationRepository(pool)
+	reportRepo := postgres.NewReportRepository(pool)
+	corsOriginRepo := postgres.NewCORSOriginRepository(pool)
+	hookRepo := postgres.NewHookRepository(pool)
+	agentActionRepo := postgres.NewAgentActionRepository(pool)
+
+	// Initialize services
+	authService := service.NewAuthService(userRepo, tokenManager, hasher, idGen, kpis, logger)
+	onboardingService := service.NewOnboardingService(onboardingRepo, logger)
+	todoService := service.NewTodoService(todoRepo, userRepo, idGen, onboardingService, kpis, cfg.TodoLimits(), logger)
+	syncService := service.NewSyncService(todoRepo, userRepo, onboardingService, kpis, cfg.TodoLimits(), cfg.LongPollInterval, logger)
+	accountService := service.NewAccountService(userRepo, hasher, cfg.AbuseSuspendThreshold, cfg.AbuseWindow, cfg.AccountDeletionGracePeriod, logger)
+	announcementService := service.NewAnnouncementService(announcementRepo, idGen, logger)
+	notificationService := service.NewNotificationService(notificationRepo, idGen, logger)
+	reportService := service.NewReportService(reportRepo, logger)
+	corsOriginService := service.NewCORSOriginService(corsOriginRepo, logger)
+	// Hook targets are chosen by users, so deliveries may only reach public
+	// addresses, and each target has its own circuit: many users' targets
+	// share hosts such as hooks.zapier.com
+	hookClientConfig := httpclient.DefaultConfig()
+	hookClientConfig.PublicOnly = true
+	hookClientConfig.CircuitPerURL = true
+	hookService := service.NewHookService(hookRepo, todoRepo, httpclient.New(hookClientConfig, outboundMetrics, logger), idGen, cfg.HookMaxFailures, cfg.HookDeliveryRetention, logger)
+	agentService := service.NewAgentService(todoService, agentActionRepo, idGen, cfg.AgentTools, logger)
+	widgetService := service.NewWidgetService(userRepo, todoRepo, tokenManager, cfg.WidgetTokenTTL, logger)
+
+	// Initialize handlers
+	authHandler := handler.NewAuthHandler(authService, logger)
+	todoHandler := handler.NewTodoHandler(todoService, cfg.TodoPreviewLength, logger)
+	syncHandler := handler.NewSyncHandler(syncService, cfg.LongPollMaxWait, logger)
+	healthHandler := handler.NewHealthHandler([]handler.HealthCheck{handler.DatabaseHealthCheck(pool)}, cfg.HealthCacheTTL, logger)
+	errorHandler := handler.NewErrorHandler(logger)
+	adminHandler := handler.NewAdminHandler(accountService, logger)
+	announcementHandler := handler.NewAnnouncementHandler(announcementService, logger)
+	onboardingHandler := handler.NewOnboardingHandler(onboardingService, logger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, logger)
+	accountHandler := handler.NewAccountHandler(accountService, logger)
+	reportHandler := handler.NewReportHandler(reportService, logger)
+	corsHandler := handler.NewCORSHandler(corsOriginService, logger)
+	hookHandler := handler.NewHookHandler(hookService, logger)
+	agentHandler := handler.NewAgentHandler(agentService, logger)
+	widgetHandler := handler.NewWidgetHandler(widgetService, logger)
+
+	// Email previews are only served in development
+	var mailPreviewHandler *handler.MailPreviewHandler
+	if cfg.IsDevelopment() {
+		mailRenderer, err := mail.NewRenderer()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load email templates: %w", err)
+		}
+		mailPreviewHandler = handler.NewMailPreviewHandler(mailRenderer, logger)
+	}
+
+	// Initialize middleware
+	authMiddleware := middleware.NewAuth(tokenManager, userRepo, logger)
+	loggingMiddleware := middleware.NewLogging(logger, httpMetrics)
+	requestIDMiddleware := middleware.NewRequestID()
+	clientIPMiddleware := middleware.NewClientIP(cfg.TrustedProxyPrefixes())
+	tracingMiddleware := middleware.NewTracing()
+	recoverMiddleware := middleware.NewRecover(logger)
+	methodsMiddleware := middleware.NewMethods()
+	rateLimitMiddleware := middleware.NewRateLimit(cfg.RateLimitRequests, cfg.RateLimitWindow, accountService, logger)
+	corsMiddleware := newCORSMiddleware(cfg, corsOriginService)
+	// Widget requests come from visitors of the embedding site, so they do not
+	// count toward the account's abuse signals
+	widgetRateLimitMiddleware := middleware.NewRateLimit(cfg.WidgetRateLimitRequests, cfg.WidgetRateLimitWindow, nil, logger)
+	authRateLimitMiddleware := middleware.NewRateLimit(cfg.AuthRateLimitRequests, cfg.AuthRateLimitWindow, nil, logger)
+	concurrencyMiddleware := middleware.NewConcurrencyLimit(cfg.MaxConcurrentRequests, cfg.MaxConcurrentRequestsPerUser, cfg.MaxLongPollsPerUser, time.Second, logger)
+
+	// Bot checks of sign-up and sign-in
+	var botGuardConfig middleware.BotGuardConfig
+	if cfg.BotGuardEnabled {
+		botGuardConfig.HoneypotField = cfg.BotHoneypotField
+		botGuardConfig.MinFillTime = cfg.BotMinFillTime
+		if cfg.CaptchaProvider != "" {
+			client := httpclient.New(httpclient.DefaultConfig(), outboundMetrics, logger)
+			botGuardConfig.Captcha, err = captcha.NewVerifier(cfg.CaptchaProvider, cfg.CaptchaSecret, client)
+			if err != nil {
+				return nil, fmt.Errorf("failed to setup captcha verification: %w", err)
+			}
+		}
+	}
+	botGuardMiddleware := middleware.NewBotGuard(botGuardConfig, botMetrics, logger)
+
+	// Admin endpoints are only served when an admin token is configured
+	var adminMiddleware *middleware.Admin
+	if cfg.AdminToken != "" {
+		adminMiddleware = middleware.NewAdmin(cfg.AdminToken, logger)
+	}
+
+	// Fault injection for resilience testing (never in production)
+	var chaosMiddleware *middleware.Chaos
+	var chaosHandler *handler.ChaosHandler
+	if cfg.ChaosEnabled {
+		chaosRules, err := middleware.ParseChaosRules(cfg.ChaosRules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CHAOS_RULES: %w", err)
+		}
+		chaosMiddleware = middleware.NewChaos(chaosRules, logger)
+		chaosHandler = handler.NewChaosHandler(chaosMiddleware, logger)
+		logger.Warn("fault injection enabled", "rules", len(chaosRules))
+	}
+
+	var cacheStore *respcache.Store
+	if cfg.ResponseCacheEnabled {
+		cacheStore = respcache.NewStore(cfg.ResponseCacheTTL, cfg.ResponseCacheMaxEntries)
+	}
+	cacheMiddleware := middleware.NewResponseCache(cacheStore, logger)
+
+	// Product analytics are only recorded when a sink is configured
+	var analyticsTracker *analytics.Tracker
+	if cfg.AnalyticsSink != "" {
+		client := httpclient.New(httpclient.DefaultConfig(), outboundMetrics, logger)
+		sink, err := analytics.NewSink(cfg.AnalyticsSink, cfg.AnalyticsHost, cfg.AnalyticsAPIKey, client, os.Stdout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup analytics: %w", err)
+		}
+		// User IDs are anonymized with a key derived from the JWT secret
+		analyticsTracker = analytics.NewTracker(sink, []byte("analytics:"+cfg.JWTSecret), analytics.Config{
+			FlushInterval: cfg.AnalyticsFlushInterval,
+		}, logger)
+	}
+	analyticsMiddleware := middleware.NewAnalytics(analyticsTracker)
+	apiV1Middleware := middleware.NewAPIVersion("v1", cfg.APIV1NoContent)
+	signingMiddleware := middleware.NewRequestSigning(cfg.RequestSigningRequired, cfg.RequestSigningTolerance, logger)
+
+	// Access log goes to its own rotating file when configured
+	var accessLogMiddleware *middleware.AccessLog
+	if cfg.AccessLogFile != "" {
+		accessLogFile, err := logrotate.Open(cfg.AccessLogFile, int64(cfg.AccessLogMaxSizeMB)<<20, cfg.AccessLogMaxAge, cfg.AccessLogMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open access log: %w", err)
+		}
+		a.closers = append(a.closers, accessLogFile)
+		accessLogMiddleware = middleware.NewAccessLog(accessLogFile, cfg.AccessLogFormat, logger)
+	}
+
+	// Setup router
+	a.router = setupRouter(cfg, authHandler, todoHandler, syncHandler, healthHandler, errorHandler, adminHandler, announcementHandler, onboardingHandler, notificationHandler, accountHandler, reportHandler, mailPreviewHandler, chaosHandler, corsHandler, widgetHandler, hookHandler, agentHandler, authMiddleware, adminMiddleware, loggingMiddleware, accessLogMiddleware, requestIDMiddleware, clientIPMiddleware, tracingMiddleware, recoverMiddleware, methodsMiddleware, concurrencyMiddleware, rateLimitMiddleware, widgetRateLimitMiddleware, authRateLimitMiddleware, botGuardMiddleware, cacheMiddleware, analyticsMiddleware, signingMiddleware, apiV1Middleware, chaosMiddleware, corsMiddleware, metricsRegistry)
+
+	a.healthHandler = healthHandler
+	a.accountService = accountService
+	a.corsOriginService = corsOriginService
+	a.hookService = hookService
+	a.detector = detector
+	a.analyticsTracker = analyticsTracker
+	a.lockMetrics = lockMetrics
+
+	return a, nil
+}
+
+// Close releases the files the app opened
+func (a *app) Close() {
+	for _, c := range a.closers {
+		_ = c.Close()
+	}
+}
@@ -0,0 +1,169 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/whauzan/todo-api/internal/testutil"
+)
+
+// benchTodos is the number of todos of the benchmark user, enough for
+// several pages at the default page size
+const benchTodos = 200
+
+// benchServer is the full router with a signed-in user that has benchTodos todos
+type benchServer struct {
+	handler http.Handler
+	email   string
+	token   string
+}
+
+// newBenchServer boots the full router with rate limiting out of the way
+func newBenchServer(b *testing.B, overrides map[string]string) *benchServer {
+	b.Helper()
+
+	db := testutil.NewDatabase(b)
+	env := map[string]string{"RATE_LIMIT_REQUESTS": "1000000000"}
+	for k, v := range overrides {
+		env[k] = v
+	}
+	a, err := newApp(testutil.Config(b, db, env), db.Pool, testutil.Logger())
+	if err != nil {
+		b.Fatalf("newApp: %v", err)
+	}
+	b.Cleanup(a.Close)
+
+	user := testutil.SeedUser(b, db, "bench")
+	titles := make([]string, benchTodos)
+	for i := range titles {
+		titles[i] = fmt.Sprintf("Benchmark todo %d", i)
+	}
+	testutil.SeedTodos(b, db, user.ID, titles...)
+
+	s := &benchServer{handler: a.router, email: user.Email}
+	rec := s.serve(b, http.MethodPost, "/api/v1/auth/login", s.loginBody(b))
+	var resp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data.Token == "" {
+		b.Fatalf("failed to sign in: %s", rec.Body.String())
+	}
+	s.token = resp.Data.Token
+	return s
+}
+
+// serve sends a request straight to the router, so the numbers do not
+// include the network, and fails unless it succeeds
+func (s *benchServer) serve(b *testing.B, method, path string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	if rec.Code >= 300 {
+		b.Fatalf("%s %s: %d %s", method, path, rec.Code, rec.Body.String())
+	}
+	return rec
+}
+
+func (s *benchServer) loginBody(b *testing.B) []byte {
+	body, err := json.Marshal(map[string]string{"email": s.email, "password": testutil.FixturePassword})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return body
+}
+
+// BenchmarkLogin measures sign-in, which is dominated by the bcrypt cost of
+// the password hasher
+func BenchmarkLogin(b *testing.B) {
+	s := newBenchServer(b, nil)
+	body := s.loginBody(b)
+	s.token = ""
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.serve(b, http.MethodPost, "/api/v1/auth/login", body)
+	}
+}
+
+// BenchmarkListTodos measures listing with and without the response cache,
+// as the full list and as cursor pages
+func BenchmarkListTodos(b *testing.B) {
+	for _, cache := range []struct {
+		name    string
+		enabled string
+	}{
+		{"cached", "true"},
+		{"uncached", "false"},
+	} {
+		b.Run(cache.name, func(b *testing.B) {
+			s := newBenchServer(b, map[string]string{"RESPONSE_CACHE_ENABLED": cache.enabled})
+
+			b.Run("full", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					s.serve(b, http.MethodGet, "/api/v1/todos", nil)
+				}
+			})
+
+			b.Run("first_page", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					s.serve(b, http.MethodGet, "/api/v1/todos?limit=20&cursor=", nil)
+				}
+			})
+
+			// Walking every page exercises cursor decoding and the keyset query
+			b.Run("all_pages", func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					cursor := ""
+					for {
+						rec := s.serve(b, http.MethodGet, "/api/v1/todos?limit=20&cursor="+url.QueryEscape(cursor), nil)
+						var resp struct {
+							Meta struct {
+								Cursor struct {
+									NextCursor string `json:"next_cursor"`
+									HasMore    bool   `json:"has_more"`
+								} `json:"cursor"`
+							} `json:"meta"`
+						}
+						if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+							b.Fatalf("failed to decode page: %v", err)
+						}
+						if !resp.Meta.Cursor.HasMore {
+							break
+						}
+						cursor = resp.Meta.Cursor.NextCursor
+					}
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkCreateTodo measures creating todos, which also purges the user's
+// cached lists
+func BenchmarkCreateTodo(b *testing.B) {
+	s := newBenchServer(b, nil)
+	body, err := json.Marshal(map[string]string{"title": "Benchmark todo", "description": "Created by BenchmarkCreateTodo"})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.serve(b, http.MethodPost, "/api/v1/todos", body)
+	}
+}
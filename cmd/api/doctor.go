@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/whauzan/todo-api/internal/config"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+)
+
+// Check statuses reported by the doctor command
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// doctorTimeout bounds each network check so a hung dependency cannot stall the report
+const doctorTimeout = 5 * time.Second
+
+// requiredRelations are the tables and indexes created by db/migrations.
+// Add new entries here when a migration creates something the app depends on.
+var requiredRelations = []string{
+	"users",
+	"todos",
+	"todo_tombstones",
+	"idx_todos_user_id_created_at_id",
+	"idx_todo_tombstones_user_id_deleted_at",
+	"idx_todos_user_id_updated_at",
+	"announcements",
+	"announcement_dismissals",
+	"onboarding_steps",
+	"notifications",
+	"idx_notifications_user_id_created_at_id",
+	"idx_notifications_user_id_unread",
+	"idx_users_email_lower",
+	"idx_users_purge_after",
+	"todo_undo_tokens",
+	"idx_todo_undo_tokens_user_id_expires_at",
+	"cors_origins",
+	"user_change_seqs",
+	"todo_changes",
+	"idx_todo_changes_user_id_todo_id",
+	"hook_subscriptions",
+	"idx_hook_subscriptions_user_id",
+	"agent_actions",
+	"idx_agent_actions_user_id_created_at_id",
+	"hook_deliveries",
+	"idx_hook_deliveries_hook_id_created_at_id",
+	"idx_hook_deliveries_created_at",
+}
+
+// checkResult is a single line of the doctor report
+type checkResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// doctorReport is the full result of a doctor run
+type doctorReport struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []checkResult `json:"checks"`
+}
+
+// runDoctor checks configuration and dependencies without starting the server.
+// It returns the process exit code: 0 when every check passes or only warns, 1 otherwise.
+func runDoctor(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	report := doctorReport{Healthy: true}
+	add := func(result checkResult) {
+		if result.Status == checkFail {
+			report.Healthy = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		add(checkResult{Name: "config", Status: checkFail, Detail: strings.ReplaceAll(err.Error(), "\n", "; ")})
+		for _, name := range []string{"database", "migrations", "jwt"} {
+			add(checkResult{Name: name, Status: checkSkip, Detail: "configuration is invalid"})
+		}
+	} else {
+		add(checkResult{Name: "config", Status: checkOK, Detail: fmt.Sprintf("env=%s", cfg.Env)})
+
+		conn, result := checkDatabase(cfg)
+		add(result)
+		if conn != nil {
+			add(checkMigrations(conn))
+			conn.Close(context.Background())
+		} else {
+			add(checkResult{Name: "migrations", Status: checkSkip, Detail: "database is unreachable"})
+		}
+
+		add(checkJWT(cfg))
+	}
+
+	// Optional integrations are not part of this build yet
+	for _, name := range []string{"smtp", "redis", "s3"} {
+		add(checkResult{Name: name, Status: checkSkip, Detail: "not configured"})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printReport(out, report)
+	}
+
+	if !report.Healthy {
+		return 1
+	}
+	return 0
+}
+
+// checkDatabase opens a single connection to verify the database is reachable
+func checkDatabase(cfg *config.Config) (*pgx.Conn, checkResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return nil, checkResult{Name: "database", Status: checkFail, Detail: err.Error()}
+	}
+
+	var version string
+	if err := conn.QueryRow(ctx, "SHOW server_version").Scan(&version); err != nil {
+		conn.Close(context.Background())
+		return nil, checkResult{Name: "database", Status: checkFail, Detail: err.Error()}
+	}
+
+	return conn, checkResult{Name: "database", Status: checkOK, Detail: "postgres " + version}
+}
+
+// checkMigrations verifies that every relation created by the migrations exists
+func checkMigrations(conn *pgx.Conn) checkResult {
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+
+	var missing []string
+	for _, name := range requiredRelations {
+		var exists bool
+		if err := conn.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", name).Scan(&exists); err != nil {
+			return checkResult{Name: "migrations", Status: checkFail, Detail: err.Error()}
+		}
+		if !exists {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return checkResult{Name: "migrations", Status: checkFail, Detail: "missing " + strings.Join(missing, ", ")}
+	}
+	return checkResult{Name: "migrations", Status: checkOK, Detail: fmt.Sprintf("%d relations present", len(requiredRelations))}
+}
+
+// checkJWT signs and verifies a throwaway token with the configured secret
+func checkJWT(cfg *config.Config) checkResult {
+	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.JWTExpiryHours)
+
+	token, err := tokenManager.GenerateToken(uuid.New(), "doctor@example.com", 0, jwt.ScopesFor(jwt.ClientWeb))
+	if err != nil {
+		return checkResult{Name: "jwt", Status: checkFail, Detail: err.Error()}
+	}
+	if _, err := tokenManager.ValidateToken(token.Token); err != nil {
+		return checkResult{Name: "jwt", Status: checkFail, Detail: err.Error()}
+	}
+
+	// The example secret is long enough to pass validation but is public
+	if strings.HasPrefix(cfg.JWTSecret, "your-super-secret-jwt-key") {
+		status := checkWarn
+		if cfg.IsProduction() {
+			status = checkFail
+		}
+		return checkResult{Name: "jwt", Status: status, Detail: "JWT_SECRET is the example value"}
+	}
+
+	return checkResult{Name: "jwt", Status: checkOK, Detail: "sign and verify succeeded"}
+}
+
+// printReport writes the report as an aligned table
+func printReport(out io.Writer, report doctorReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	for _, c := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, c.Status, c.Detail)
+	}
+	w.Flush()
+
+	if report.Healthy {
+		fmt.Fprintln(out, "\nall checks passed")
+	} else {
+		fmt.Fprintln(out, "\none or more checks failed")
+	}
+}
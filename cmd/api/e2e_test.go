@@ -0,0 +1,249 @@
+//go:build integration
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/testutil"
+	"github.com/whauzan/todo-api/pkg/client"
+)
+
+// newTestServer boots the full router against a fresh test database
+func newTestServer(t *testing.T, db *testutil.Database) *client.Client {
+	t.Helper()
+
+	a, err := newApp(testutil.Config(t, db, nil), db.Pool, testutil.Logger())
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(a.Close)
+	return testutil.Serve(t, a.router)
+}
+
+// wantStatus fails the test unless err is an API error with the status
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want API error with status %d", err, status)
+	}
+	if apiErr.StatusCode != status {
+		t.Fatalf("status = %d (%s), want %d", apiErr.StatusCode, apiErr.Code, status)
+	}
+}
+
+func TestAuthFlow(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	c := newTestServer(t, db)
+	ctx := context.Background()
+
+	const email = "flow@example.com"
+	user, err := c.Register(ctx, &client.RegisterRequest{Email: email, Password: "first-password", Name: "Flow"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if user.Email != email || user.ID == uuid.Nil {
+		t.Fatalf("registered user = %+v", user)
+	}
+
+	_, err = c.Register(ctx, &client.RegisterRequest{Email: email, Password: "other-password", Name: "Again"})
+	wantStatus(t, err, http.StatusConflict)
+
+	_, err = c.Login(ctx, &client.LoginRequest{Email: email, Password: "wrong-password"})
+	wantStatus(t, err, http.StatusUnauthorized)
+
+	login, err := c.Login(ctx, &client.LoginRequest{Email: email, Password: "first-password"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if login.User.ID != user.ID {
+		t.Fatalf("logged in as %s, want %s", login.User.ID, user.ID)
+	}
+
+	if _, err := c.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Changing the password revokes every token issued before it
+	oldToken := c.Token()
+	if _, err := c.ChangePassword(ctx, &client.ChangePasswordRequest{CurrentPassword: "first-password", NewPassword: "second-password"}); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	newToken := c.Token()
+	c.SetToken(oldToken)
+	_, err = c.ListTodos(ctx, nil)
+	wantStatus(t, err, http.StatusUnauthorized)
+
+	c.SetToken(newToken)
+	if _, err := c.ListTodos(ctx, nil); err != nil {
+		t.Fatalf("ListTodos with the replacement token: %v", err)
+	}
+
+	_, err = c.Login(ctx, &client.LoginRequest{Email: email, Password: "first-password"})
+	wantStatus(t, err, http.StatusUnauthorized)
+
+	// A logged out token is rejected
+	if _, err := c.Login(ctx, &client.LoginRequest{Email: email, Password: "second-password"}); err != nil {
+		t.Fatalf("Login with the new password: %v", err)
+	}
+	loggedOut := c.Token()
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	c.SetToken(loggedOut)
+	_, err = c.ListTodos(ctx, nil)
+	wantStatus(t, err, http.StatusUnauthorized)
+}
+
+func TestTodoCRUD(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	c := newTestServer(t, db)
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "crud")
+	seeded := testutil.SeedTodos(t, db, user.ID, "Seeded todo")
+	if _, err := c.Login(ctx, &client.LoginRequest{Email: user.Email, Password: testutil.FixturePassword}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	description := "Milk, eggs"
+	created, err := c.CreateTodo(ctx, &client.CreateTodoRequest{Title: "Buy groceries", Description: &description})
+	if err != nil {
+		t.Fatalf("CreateTodo: %v", err)
+	}
+	if created.Title != "Buy groceries" || created.Description == nil || *created.Description != description || created.Completed {
+		t.Fatalf("created todo = %+v", created)
+	}
+
+	_, err = c.CreateTodo(ctx, &client.CreateTodoRequest{})
+	wantStatus(t, err, http.StatusBadRequest)
+
+	got, err := c.GetTodo(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetTodo: %v", err)
+	}
+	if got.ID != created.ID || got.UserID != user.ID {
+		t.Fatalf("got todo = %+v", got)
+	}
+
+	todos, err := c.ListTodos(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTodos: %v", err)
+	}
+	if !hasTodo(todos, seeded[0].ID) || !hasTodo(todos, created.ID) || len(todos) != 2 {
+		t.Fatalf("listed %d todos, want the seeded and the created todo", len(todos))
+	}
+
+	completed := true
+	updated, err := c.UpdateTodo(ctx, created.ID, &client.UpdateTodoRequest{Completed: &completed})
+	if err != nil {
+		t.Fatalf("UpdateTodo: %v", err)
+	}
+	if !updated.Completed || updated.Title != created.Title {
+		t.Fatalf("updated todo = %+v", updated)
+	}
+
+	if err := c.DeleteTodo(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+	_, err = c.GetTodo(ctx, created.ID)
+	wantStatus(t, err, http.StatusNotFound)
+
+	// The list is not served from the response cache after the delete
+	todos, err = c.ListTodos(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTodos after delete: %v", err)
+	}
+	if hasTodo(todos, created.ID) || len(todos) != 1 {
+		t.Fatalf("listed %d todos after delete, want only the seeded todo", len(todos))
+	}
+}
+
+func TestTodosAreScopedToTheirOwner(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	c := newTestServer(t, db)
+	ctx := context.Background()
+
+	owner := testutil.SeedUser(t, db, "owner")
+	todo := testutil.SeedTodos(t, db, owner.ID, "Private todo")[0]
+	other := testutil.SeedUser(t, db, "other")
+
+	if _, err := c.Login(ctx, &client.LoginRequest{Email: other.Email, Password: testutil.FixturePassword}); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	_, err := c.GetTodo(ctx, todo.ID)
+	wantStatus(t, err, http.StatusForbidden)
+
+	title := "Taken over"
+	_, err = c.UpdateTodo(ctx, todo.ID, &client.UpdateTodoRequest{Title: title})
+	wantStatus(t, err, http.StatusForbidden)
+
+	err = c.DeleteTodo(ctx, todo.ID)
+	wantStatus(t, err, http.StatusForbidden)
+
+	todos, err := c.ListTodos(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTodos: %v", err)
+	}
+	if len(todos) != 0 {
+		t.Fatalf("listed %d todos of another user", len(todos))
+	}
+
+	// Unauthenticated requests are rejected before reaching the todo
+	c.SetToken("")
+	_, err = c.GetTodo(ctx, todo.ID)
+	wantStatus(t, err, http.StatusUnauthorized)
+}
+
+func TestCachedTodosRequireReadScope(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	cfg := testutil.Config(t, db, map[string]string{"RESPONSE_CACHE_ENABLED": "true"})
+	a, err := newApp(cfg, db.Pool, testutil.Logger())
+	if err != nil {
+		t.Fatalf("newApp: %v", err)
+	}
+	t.Cleanup(a.Close)
+	c := testutil.Serve(t, a.router)
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "cached")
+	todo := testutil.SeedTodos(t, db, user.ID, "Cached todo")[0]
+
+	// Warm the cache with a token that may read todos
+	c.SetToken(testutil.Token(t, cfg, user, jwt.ScopesFor(jwt.ClientWeb)...))
+	if _, err := c.ListTodos(ctx, nil); err != nil {
+		t.Fatalf("ListTodos: %v", err)
+	}
+	if _, err := c.GetTodo(ctx, todo.ID); err != nil {
+		t.Fatalf("GetTodo: %v", err)
+	}
+
+	// Tokens of the same user without todos:read get no cached response
+	for _, clientType := range []string{jwt.ClientAgent, jwt.ClientAgentReadOnly} {
+		c.SetToken(testutil.Token(t, cfg, user, jwt.ScopesFor(clientType)...))
+		_, err := c.ListTodos(ctx, nil)
+		wantStatus(t, err, http.StatusForbidden)
+		_, err = c.GetTodo(ctx, todo.ID)
+		wantStatus(t, err, http.StatusForbidden)
+	}
+	c.SetToken(testutil.Token(t, cfg, user, jwt.ScopeWidget))
+	_, err = c.ListTodos(ctx, nil)
+	wantStatus(t, err, http.StatusForbidden)
+}
+
+// hasTodo reports whether the list contains the todo
+func hasTodo(todos []*client.Todo, id uuid.UUID) bool {
+	for _, todo := range todos {
+		if todo.ID == id {
+			return true
+		}
+	}
+	return false
+}
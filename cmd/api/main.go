@@ -11,18 +11,25 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/whauzan/todo-api/internal/config"
 	"github.com/whauzan/todo-api/internal/handler"
 	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/buildinfo"
+	"github.com/whauzan/todo-api/internal/pkg/dlock"
 	"github.com/whauzan/todo-api/internal/pkg/jwt"
-	"github.com/whauzan/todo-api/internal/pkg/password"
-	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+	"github.com/whauzan/todo-api/internal/pkg/tracing"
 	"github.com/whauzan/todo-api/internal/service"
+	"github.com/whauzan/todo-api/internal/web"
 )
 
 func main() {
+	// The doctor command reports on configuration problems instead of failing on them
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		os.Exit(runDoctor(os.Args[2:], os.Stdout))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -32,7 +39,15 @@ func main() {
 
 	// Setup logger
 	logger := setupLogger(cfg)
-	logger.Info("starting todo-api", "env", cfg.Env, "port", cfg.Port)
+	build := buildinfo.Get()
+	logger.Info("starting todo-api",
+		"env", cfg.Env,
+		"port", cfg.Port,
+		"version", build.Version,
+		"commit", build.Commit,
+		"build_time", build.BuildTime,
+		"go_version", build.GoVersion,
+	)
 
 	// Setup database connection
 	pool, err := setupDatabase(cfg, logger)
@@ -42,41 +57,70 @@ func main() {
 	}
 	defer pool.Close()
 
-	// Initialize dependencies
-	tokenManager := jwt.NewTokenManager(cfg.JWTSecret, cfg.JWTExpiryHours)
-	hasher := password.NewHasher()
-
-	// Initialize repositories
-	userRepo := postgres.NewUserRepository(pool)
-	todoRepo := postgres.NewTodoRepository(pool)
-
-	// Initialize services
-	authService := service.NewAuthService(userRepo, tokenManager, hasher, logger)
-	todoService := service.NewTodoService(todoRepo, logger)
-
-	// Initialize handlers
-	authHandler := handler.NewAuthHandler(authService, logger)
-	todoHandler := handler.NewTodoHandler(todoService, logger)
-	healthHandler := handler.NewHealthHandler(pool, logger)
-
-	// Initialize middleware
-	authMiddleware := middleware.NewAuth(tokenManager, logger)
-	loggingMiddleware := middleware.NewLogging(logger)
-	requestIDMiddleware := middleware.NewRequestID()
-	recoverMiddleware := middleware.NewRecover(logger)
+	// Run a one-off command instead of the server when requested
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], cfg, pool, logger); err != nil {
+			logger.Error("command failed", "command", os.Args[1], "error", err)
+			pool.Close()
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Setup router
-	r := setupRouter(cfg, authHandler, todoHandler, healthHandler, authMiddleware, loggingMiddleware, requestIDMiddleware, recoverMiddleware)
+	// Initialize dependencies
+	a, err := newApp(cfg, pool, logger)
+	if err != nil {
+		logger.Error("failed to initialize server", "error", err)
+		os.Exit(1)
+	}
+	defer a.Close()
 
 	// Setup HTTP server
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      r,
+		Handler:      a.router,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Load the CORS origin allowlist of the widget routes and keep it in sync with
+	// other instances; if loading fails, widgets are not allowed until the next refresh
+	if err := a.corsOriginService.Refresh(context.Background()); err != nil {
+		logger.Warn("failed to load CORS origins", "error", err)
+	}
+
+	// Compute metric rates and watch for anomalies until shutdown
+	detectorCtx, stopDetector := context.WithCancel(context.Background())
+	defer stopDetector()
+	if a.detector != nil {
+		go a.detector.Run(detectorCtx)
+	}
+
+	// Purge accounts whose deletion grace period has ended, one instance at a time
+	if cfg.AccountPurgeInterval > 0 {
+		go a.accountService.RunPurge(detectorCtx, cfg.AccountPurgeInterval, dlock.NewLocker(pool, a.lockMetrics))
+	}
+	go a.corsOriginService.Run(detectorCtx, cfg.CORSAllowlistRefresh)
+
+	// Deliver todo changes to REST hooks, one instance at a time
+	if cfg.HookDispatchInterval > 0 {
+		go a.hookService.RunDispatch(detectorCtx, cfg.HookDispatchInterval, dlock.NewLocker(pool, a.lockMetrics))
+	}
+
+	// Send analytics events in the background; they are flushed after the server stops
+	analyticsCtx, stopAnalytics := context.WithCancel(context.Background())
+	defer stopAnalytics()
+	analyticsDone := make(chan struct{})
+	if a.analyticsTracker != nil {
+		go func() {
+			a.analyticsTracker.Run(analyticsCtx)
+			close(analyticsDone)
+		}()
+	} else {
+		close(analyticsDone)
+	}
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("server started", "addr", srv.Addr)
@@ -93,8 +137,19 @@ func main() {
 
 	logger.Info("shutting down server...")
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Fail readiness first so load balancers stop sending new requests,
+	// and keep serving in the meantime
+	a.healthHandler.SetDraining()
+	if cfg.ShutdownDrainDelay > 0 {
+		logger.Info("draining before shutdown", "delay", cfg.ShutdownDrainDelay)
+		time.Sleep(cfg.ShutdownDrainDelay)
+	}
+
+	// Stop reusing connections, then stop accepting new ones and close idle
+	// keep-alives while in-flight requests finish
+	srv.SetKeepAlivesEnabled(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
@@ -102,9 +157,61 @@ func main() {
 		os.Exit(1)
 	}
 
+	stopAnalytics()
+	<-analyticsDone
+
 	logger.Info("server stopped gracefully")
 }
 
+// newCORSMiddleware builds the CORS policy of each route group. Auth, admin
+// and account routes only allow the strict auth origins. Public read-only
+// routes allow the public origins without credentials, and widget routes allow
+// the origins on the runtime allowlist without credentials.
+func newCORSMiddleware(cfg *config.Config, allowlist *service.CORSOriginService) *middleware.CORS {
+	policies := []middleware.CORSPolicy{
+		{
+			PathPrefix:       "/",
+			AllowedOrigins:   cfg.CORSAllowedOrigins,
+			AllowCredentials: true,
+		},
+	}
+	for _, prefix := range []string{"/api/v1/auth", "/api/v1/admin", "/api/v1/users/me"} {
+		policies = append(policies, middleware.CORSPolicy{
+			PathPrefix:       prefix,
+			AllowedOrigins:   cfg.CORSAuthOrigins(),
+			AllowCredentials: true,
+		})
+	}
+	for _, prefix := range []string{"/health", "/ready", "/version", "/api/v1/errors"} {
+		policies = append(policies, middleware.CORSPolicy{
+			PathPrefix:     prefix,
+			AllowedOrigins: cfg.CORSPublicAllowedOrigins,
+		})
+	}
+	// Widgets run on the sites an admin allowed; Auth also rejects tokens
+	// used from another origin than the one they are bound to
+	policies = append(policies, middleware.CORSPolicy{
+		PathPrefix:    "/api/v1/widget",
+		OriginAllowed: allowlist.Allowed,
+	})
+	return middleware.NewCORS(policies...)
+}
+
+// runCommand runs a one-off command against the configured database
+func runCommand(name string, cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	switch name {
+	case "seed":
+		return runSeed(ctx, cfg, pool, logger)
+	case "purge-accounts":
+		return runPurgeAccounts(ctx, cfg, pool, logger)
+	default:
+		return fmt.Errorf("unknown command: %s", name)
+	}
+}
+
 // setupLogger creates and configures the logger
 func setupLogger(cfg *config.Config) *slog.Logger {
 	var level slog.Level
@@ -132,7 +239,8 @@ func setupLogger(cfg *config.Config) *slog.Logger {
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	}
 
-	return slog.New(handler)
+	// Records logged with a request context carry its trace and span IDs
+	return slog.New(tracing.NewLogHandler(handler))
 }
 
 // setupDatabase creates and configures the database connection pool
@@ -173,52 +281,278 @@ func setupRouter(
 	cfg *config.Config,
 	authHandler *handler.AuthHandler,
 	todoHandler *handler.TodoHandler,
+	syncHandler *handler.SyncHandler,
 	healthHandler *handler.HealthHandler,
+	errorHandler *handler.ErrorHandler,
+	adminHandler *handler.AdminHandler,
+	announcementHandler *handler.AnnouncementHandler,
+	onboardingHandler *handler.OnboardingHandler,
+	notificationHandler *handler.NotificationHandler,
+	accountHandler *handler.AccountHandler,
+	reportHandler *handler.ReportHandler,
+	mailPreviewHandler *handler.MailPreviewHandler,
+	chaosHandler *handler.ChaosHandler,
+	corsHandler *handler.CORSHandler,
+	widgetHandler *handler.WidgetHandler,
+	hookHandler *handler.HookHandler,
+	agentHandler *handler.AgentHandler,
 	authMiddleware *middleware.Auth,
+	adminMiddleware *middleware.Admin,
 	loggingMiddleware *middleware.Logging,
+	accessLogMiddleware *middleware.AccessLog,
 	requestIDMiddleware *middleware.RequestID,
+	clientIPMiddleware *middleware.ClientIP,
+	tracingMiddleware *middleware.Tracing,
 	recoverMiddleware *middleware.Recover,
+	methodsMiddleware *middleware.Methods,
+	concurrencyMiddleware *middleware.ConcurrencyLimit,
+	rateLimitMiddleware *middleware.RateLimit,
+	widgetRateLimitMiddleware *middleware.RateLimit,
+	authRateLimitMiddleware *middleware.RateLimit,
+	botGuardMiddleware *middleware.BotGuard,
+	cacheMiddleware *middleware.ResponseCache,
+	analyticsMiddleware *middleware.Analytics,
+	signingMiddleware *middleware.RequestSigning,
+	apiV1Middleware *middleware.APIVersion,
+	chaosMiddleware *middleware.Chaos,
+	corsMiddleware *middleware.CORS,
+	metricsRegistry *metrics.Registry,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Apply global middleware
 	r.Use(recoverMiddleware.Handle)
 	r.Use(requestIDMiddleware.Handle)
+	r.Use(clientIPMiddleware.Handle)
+	r.Use(tracingMiddleware.Handle)
 	r.Use(loggingMiddleware.Log)
+	if accessLogMiddleware != nil {
+		r.Use(accessLogMiddleware.Handle)
+	}
+	r.Use(concurrencyMiddleware.Limit)
+	if chaosMiddleware != nil {
+		r.Use(chaosMiddleware.Handle)
+	}
+
+	// CORS policy of the route group
+	r.Use(corsMiddleware.Handle)
+
+	// HEAD for GET routes and OPTIONS with an Allow header for every route
+	r.Use(methodsMiddleware.Handle)
 
-	// CORS configuration
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   cfg.CORSAllowedOrigins,
-		AllowedMethods:   []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
-		ExposedHeaders:   []string{"X-Request-ID"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	}))
+	// Enveloped errors for unmatched routes and methods
+	r.NotFound(errorHandler.NotFound)
+	r.MethodNotAllowed(errorHandler.MethodNotAllowed)
 
 	// Health check endpoint
 	r.Get("/health", healthHandler.Check)
+	r.Get("/ready", healthHandler.Ready)
+	r.Get("/version", healthHandler.Version)
+
+	// Business metrics in the Prometheus text format
+	if metricsRegistry != nil {
+		r.Method(http.MethodGet, "/metrics", metricsRegistry.Handler())
+	}
+
+	// Embedded web UI for self-hosters
+	if cfg.WebUIEnabled {
+		ui := web.Handler()
+		r.Handle("/", ui)
+		r.Handle("/assets/*", ui)
+	}
+
+	// Email template previews (development only)
+	if mailPreviewHandler != nil {
+		r.Get("/dev/mail", mailPreviewHandler.List)
+		r.Get("/dev/mail/{template}", mailPreviewHandler.Preview)
+	}
 
 	// API v1 routes
 	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(apiV1Middleware.Handle)
+
+		// Error code documentation (public)
+		r.Get("/errors", errorHandler.ListCodes)
+
 		// Auth routes (public)
 		r.Route("/auth", func(r chi.Router) {
-			r.Post("/register", authHandler.Register)
-			r.Post("/login", authHandler.Login)
+			// Limited per client IP, as there is no user to count against yet
+			r.With(authRateLimitMiddleware.HandleClientIP, botGuardMiddleware.Handle).Post("/register", authHandler.Register)
+			r.With(authRateLimitMiddleware.HandleClientIP, botGuardMiddleware.Handle).Post("/login", authHandler.Login)
 			r.Post("/refresh", authHandler.Refresh)
 			r.Post("/logout", authHandler.Logout)
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.Authenticate)
+				r.Use(authMiddleware.RequireScope(jwt.ScopeAccount))
+				r.Use(analyticsMiddleware.Handle)
+
+				r.Put("/encryption", authHandler.SetEncryption)
+				r.With(signingMiddleware.Handle).Put("/password", authHandler.ChangePassword)
+				r.With(signingMiddleware.Handle).Post("/logout-all", authHandler.LogoutAll)
+			})
 		})
 
 		// Todo routes (protected)
 		r.Route("/todos", func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 
-			r.Get("/", todoHandler.List)
-			r.Post("/", todoHandler.Create)
-			r.Get("/{id}", todoHandler.GetByID)
-			r.Patch("/{id}", todoHandler.Update)
-			r.Delete("/{id}", todoHandler.Delete)
+			read := authMiddleware.RequireScope(jwt.ScopeTodosRead)
+			write := authMiddleware.RequireScope(jwt.ScopeTodosWrite)
+
+			// Long polls are never cached, so they always see fresh changes.
+			// They wait while idle, so they have their own concurrency limit
+			// in place of the global and per-user ones.
+			r.With(concurrencyMiddleware.LimitLongPolls, rateLimitMiddleware.Handle, analyticsMiddleware.Handle, read).Get("/changes", syncHandler.Changes)
+
+			r.Group(func(r chi.Router) {
+				r.Use(concurrencyMiddleware.LimitUser)
+				r.Use(rateLimitMiddleware.Handle)
+				r.Use(analyticsMiddleware.Handle)
+
+				// Scopes are checked before the cache, which serves hits
+				// without running the rest of the chain
+				r.Group(func(r chi.Router) {
+					r.Use(read)
+					r.Use(cacheMiddleware.Handle)
+
+					r.Get("/", todoHandler.List)
+					r.Get("/export", todoHandler.Export)
+					r.Get("/{id}", todoHandler.GetByID)
+				})
+				r.Group(func(r chi.Router) {
+					r.Use(write)
+					r.Use(cacheMiddleware.Handle)
+
+					r.Post("/", todoHandler.Create)
+					r.Post("/complete-by-filter", todoHandler.CompleteByFilter)
+					r.Post("/complete-by-filter/undo", todoHandler.UndoComplete)
+					r.Patch("/{id}", todoHandler.Update)
+					r.Post("/{id}/clone", todoHandler.Clone)
+					r.Delete("/{id}", todoHandler.Delete)
+				})
+			})
+		})
+
+		// Sync routes (protected)
+		r.With(authMiddleware.Authenticate, authMiddleware.RequireScope(jwt.ScopeTodosWrite), concurrencyMiddleware.LimitUser, rateLimitMiddleware.Handle, analyticsMiddleware.Handle, cacheMiddleware.Handle).Post("/sync", syncHandler.Sync)
+		r.With(authMiddleware.Authenticate, authMiddleware.RequireScope(jwt.ScopeTodosRead), concurrencyMiddleware.LimitUser, rateLimitMiddleware.Handle, analyticsMiddleware.Handle).Get("/changes", syncHandler.Feed)
+
+		// REST hook routes (protected)
+		r.Route("/hooks", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(jwt.ScopeTodosRead))
+			r.Use(concurrencyMiddleware.LimitUser)
+			r.Use(rateLimitMiddleware.Handle)
+			r.Use(analyticsMiddleware.Handle)
+
+			r.Get("/", hookHandler.List)
+			r.Get("/samples", hookHandler.Samples)
+			r.Get("/{id}/deliveries", hookHandler.Deliveries)
+
+			// Changing or firing subscriptions also needs the write scope
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.RequireScope(jwt.ScopeTodosWrite))
+
+				r.Post("/", hookHandler.Subscribe)
+				r.Delete("/{id}", hookHandler.Unsubscribe)
+				r.Post("/{id}/deliveries/{deliveryId}/redeliver", hookHandler.Redeliver)
+			})
+		})
+
+		// Current user routes (protected)
+		r.Route("/users/me", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(jwt.ScopeAccount))
+			r.Use(concurrencyMiddleware.LimitUser)
+			r.Use(rateLimitMiddleware.Handle)
+			r.Use(analyticsMiddleware.Handle)
+
+			r.Get("/", authHandler.Me)
+			r.With(signingMiddleware.Handle).Delete("/", accountHandler.Delete)
+			r.Put("/telemetry", authHandler.SetTelemetry)
+			r.Get("/onboarding", onboardingHandler.Get)
+			r.Patch("/onboarding", onboardingHandler.Update)
+			r.Post("/widget-tokens", widgetHandler.CreateToken)
+			r.Get("/agent-actions", agentHandler.Actions)
 		})
+
+		// Agent tool routes (agent tokens); each tool checks its own scope
+		if cfg.AgentEnabled {
+			r.Route("/agent", func(r chi.Router) {
+				r.Use(authMiddleware.Authenticate)
+				r.Use(authMiddleware.RequireScope(jwt.ScopeAgentRead))
+				r.Use(concurrencyMiddleware.LimitUser)
+				r.Use(rateLimitMiddleware.Handle)
+				r.Use(analyticsMiddleware.Handle)
+				// Write tools change todos, so calls purge the user's cached todo lists
+				r.Use(cacheMiddleware.Handle)
+
+				r.Get("/tools", agentHandler.Tools)
+				r.Post("/tools/{name}", agentHandler.Call)
+			})
+		}
+
+		// Embedded widget routes (origin-bound widget tokens)
+		r.Route("/widget", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(jwt.ScopeWidget))
+			r.Use(concurrencyMiddleware.LimitUser)
+			r.Use(widgetRateLimitMiddleware.Handle)
+
+			r.Get("/todos", widgetHandler.ListTodos)
+		})
+
+		// Notification inbox routes (protected)
+		r.Route("/notifications", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(jwt.ScopeAccount))
+			r.Use(concurrencyMiddleware.LimitUser)
+			r.Use(rateLimitMiddleware.Handle)
+			r.Use(analyticsMiddleware.Handle)
+
+			r.Get("/", notificationHandler.List)
+			r.Post("/read-all", notificationHandler.MarkAllRead)
+			r.Post("/{id}/read", notificationHandler.MarkRead)
+		})
+
+		// Announcement routes (protected)
+		r.Route("/announcements", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(authMiddleware.RequireScope(jwt.ScopeAccount))
+			r.Use(concurrencyMiddleware.LimitUser)
+			r.Use(rateLimitMiddleware.Handle)
+			r.Use(analyticsMiddleware.Handle)
+
+			r.Get("/", announcementHandler.List)
+			r.Post("/{id}/dismiss", announcementHandler.Dismiss)
+		})
+
+		// Admin routes (admin token)
+		if adminMiddleware != nil {
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(adminMiddleware.Handle)
+
+				r.Get("/users/{id}", adminHandler.GetUser)
+				r.Post("/users/{id}/suspend", adminHandler.Suspend)
+				r.Post("/users/{id}/reactivate", adminHandler.Reactivate)
+
+				r.Get("/announcements", announcementHandler.ListAll)
+				r.Post("/announcements", announcementHandler.Create)
+				r.Delete("/announcements/{id}", announcementHandler.Delete)
+
+				r.Get("/reports/usage", reportHandler.Usage)
+
+				r.Get("/cors-origins", corsHandler.List)
+				r.Post("/cors-origins", corsHandler.Add)
+				r.Delete("/cors-origins", corsHandler.Remove)
+
+				if chaosHandler != nil {
+					r.Get("/chaos", chaosHandler.Get)
+					r.Put("/chaos", chaosHandler.Replace)
+					r.Delete("/chaos", chaosHandler.Clear)
+				}
+			})
+		}
 	})
 
 	return r
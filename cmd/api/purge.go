@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/config"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// runPurgeAccounts permanently deletes accounts whose deletion grace period has
+// ended. It is the one-off form of the server's purge loop, for running from cron
+// with ACCOUNT_PURGE_INTERVAL=0.
+func runPurgeAccounts(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) error {
+	userRepo := postgres.NewUserRepository(pool)
+	accountService := service.NewAccountService(userRepo, nil, 0, 0, cfg.AccountDeletionGracePeriod, logger)
+
+	count, err := accountService.PurgeDeleted(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("purge complete", "accounts", count)
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/config"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/pkg/password"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+const (
+	// demoEmail is the email of the seeded demo user
+	demoEmail = "demo@example.com"
+	// demoPassword is the password of the seeded demo user
+	demoPassword = "demo-password"
+	// seedValue makes the generated demo data deterministic between runs
+	seedValue = 42
+)
+
+var demoTodos = []struct {
+	title       string
+	description string
+}{
+	{"Buy groceries", "Milk, eggs, spinach, coffee beans"},
+	{"Book dentist appointment", ""},
+	{"Finish quarterly report", "Include the revenue breakdown by region"},
+	{"Call mom", ""},
+	{"Renew passport", "Check the photo requirements first"},
+	{"Plan weekend hike", "Look up trail conditions and weather"},
+	{"Pay electricity bill", ""},
+	{"Review pull requests", "Focus on the auth refactor"},
+	{"Water the plants", ""},
+	{"Read chapter 4 of the design book", ""},
+	{"Prepare slides for team sync", "Roadmap, hiring update, open questions"},
+	{"Clean out the garage", ""},
+	{"Schedule car service", "Oil change and tire rotation"},
+	{"Update resume", ""},
+	{"Order birthday gift for Sam", "Something from the wishlist"},
+	{"Back up laptop", ""},
+	{"Cancel unused subscriptions", "Streaming, gym, magazine"},
+	{"Write blog post draft", "Topic: lessons learned from the migration"},
+	{"Fix leaking kitchen tap", ""},
+	{"Sign up for the 10k run", ""},
+}
+
+// runSeed creates a demo user with a deterministic set of todos.
+// An existing demo user is removed first so repeated runs produce the same data.
+func runSeed(ctx context.Context, cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) error {
+	if cfg.IsProduction() {
+		return errors.New("seed is not allowed in production")
+	}
+
+	userRepo := postgres.NewUserRepository(pool)
+	todoRepo := postgres.NewTodoRepository(pool)
+	onboardingRepo := postgres.NewOnboardingRepository(pool)
+
+	idGen, err := idgen.NewGenerator(cfg.UUIDVersion)
+	if err != nil {
+		return err
+	}
+
+	// Bcrypt's minimum cost keeps seeding fast; this is dev-only data
+	authService := service.NewAuthService(userRepo, nil, password.NewHasherWithCost(password.MinCost), idGen, nil, logger)
+	onboardingService := service.NewOnboardingService(onboardingRepo, logger)
+	todoService := service.NewTodoService(todoRepo, userRepo, idGen, onboardingService, nil, cfg.TodoLimits(), logger)
+
+	existing, err := userRepo.GetByEmail(ctx, demoEmail)
+	if err != nil {
+		return fmt.Errorf("failed to look up demo user: %w", err)
+	}
+	if existing != nil {
+		if err := userRepo.Delete(ctx, existing.ID); err != nil {
+			return fmt.Errorf("failed to remove existing demo user: %w", err)
+		}
+		logger.Info("removed existing demo user", "user_id", existing.ID)
+	}
+
+	user, err := authService.Register(ctx, &domain.RegisterRequest{
+		Email:    demoEmail,
+		Password: demoPassword,
+		Name:     "Demo User",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register demo user: %w", err)
+	}
+
+	rng := rand.New(rand.NewSource(seedValue))
+
+	for _, t := range demoTodos {
+		req := &domain.CreateTodoRequest{Title: t.title}
+		if t.description != "" {
+			description := t.description
+			req.Description = &description
+		}
+
+		todo, _, err := todoService.Create(ctx, user.ID, req)
+		if err != nil {
+			return fmt.Errorf("failed to create demo todo %q: %w", t.title, err)
+		}
+
+		// Roughly a third of the demo todos start out completed
+		if rng.Intn(3) == 0 {
+			if _, err := todoService.Update(ctx, user.ID, todo.ID, &domain.UpdateTodoRequest{Completed: domain.Some(true)}); err != nil {
+				return fmt.Errorf("failed to complete demo todo %q: %w", t.title, err)
+			}
+		}
+	}
+
+	logger.Info("demo data seeded",
+		"email", demoEmail,
+		"password", demoPassword,
+		"todos", len(demoTodos),
+	)
+
+	return nil
+}
@@ -0,0 +1,544 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// goIdentifierPattern matches names that can be turned into Go identifiers
+var goIdentifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// goInitialisms are the name parts written in upper case, as in UserID
+var goInitialisms = map[string]bool{
+	"api": true, "e2e": true, "http": true, "id": true, "ip": true, "json": true, "url": true, "uuid": true,
+}
+
+// goRuntimeSchemas are the components pkg/client implements by hand in
+// client.go: APIError for ErrorInfo and the envelope meta
+var goRuntimeSchemas = map[string]bool{"ErrorInfo": true, "Meta": true, "CursorPagination": true}
+
+// goImports are the import paths of the packages the generated code may use
+var goImports = map[string]string{
+	"context": "context",
+	"http":    "net/http",
+	"strconv": "strconv",
+	"time":    "time",
+	"url":     "net/url",
+	"uuid":    "github.com/google/uuid",
+}
+
+// goWriter builds a Go source file, recording the packages it uses
+type goWriter struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// use records that the generated code refers to a package of goImports
+func (w *goWriter) use(pkg string) {
+	w.imports[pkg] = true
+}
+
+// generateGo returns the types and operations of pkg/client for the spec: a
+// struct per component schema and a Client method per operation. The
+// transport, options and errors live in pkg/client/client.go.
+func generateGo(s *spec) ([]byte, error) {
+	w := &goWriter{imports: make(map[string]bool)}
+	w.use("context")
+	w.use("http")
+
+	for _, name := range s.Components.Schemas.keys {
+		if goRuntimeSchemas[name] {
+			continue
+		}
+		if err := w.writeStruct(name, s.Components.Schemas.values[name]); err != nil {
+			return nil, fmt.Errorf("components.schemas.%s: %w", name, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range s.Paths.keys {
+		item := s.Paths.values[path]
+		for _, method := range item.Operations.keys {
+			op := item.Operations.values[method]
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: operationId is required", strings.ToUpper(method), path)
+			}
+			if seen[op.OperationID] {
+				return nil, fmt.Errorf("%s %s: duplicate operationId %q", strings.ToUpper(method), path, op.OperationID)
+			}
+			seen[op.OperationID] = true
+
+			if err := w.writeMethod(s, path, method, item.Parameters, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/clientgen from the %s spec. DO NOT EDIT.\n\n", s.Info.Title)
+	out.WriteString("package client\n\nimport (\n")
+	// Standard library packages come first, then the others
+	var std, others []string
+	for pkg := range w.imports {
+		if path := goImports[pkg]; strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+	for i, group := range [][]string{std, others} {
+		if i > 0 && len(group) > 0 {
+			out.WriteString("\n")
+		}
+		for _, path := range group {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	out.WriteString(")\n\n")
+	out.Write(w.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go: %w", err)
+	}
+	return src, nil
+}
+
+// writeStruct writes an object component schema as an exported struct
+func (w *goWriter) writeStruct(name string, sch *schema) error {
+	if !goIdentifierPattern.MatchString(name) {
+		return fmt.Errorf("name is not a valid identifier")
+	}
+	if sch.Type != "object" || sch.Nullable || sch.Ref != "" {
+		return fmt.Errorf("only object schemas are supported")
+	}
+
+	writeGoDoc(&w.buf, "", name+" is the "+name+" schema of the API", sch.Description)
+	fmt.Fprintf(&w.buf, "type %s struct {\n", name)
+	for _, prop := range sch.Properties.keys {
+		propSchema := sch.Properties.values[prop]
+		required := sch.isRequired(prop)
+		typ, err := w.goType(propSchema, !required)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		field, err := goName(prop)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		tag := prop
+		if !required {
+			tag += ",omitempty"
+		}
+		writeGoDoc(&w.buf, "\t", "", fieldDoc(propSchema))
+		fmt.Fprintf(&w.buf, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+	w.buf.WriteString("}\n\n")
+	return nil
+}
+
+// fieldDoc describes a property, listing the values of an enum
+func fieldDoc(sch *schema) string {
+	doc := strings.TrimSpace(sch.Description)
+	if len(sch.Enum) > 0 {
+		if doc != "" {
+			doc += ". "
+		}
+		doc += "One of " + strings.Join(sch.Enum, ", ")
+	}
+	return doc
+}
+
+// writeMethod writes the Client method of an operation. Path parameters come
+// first, in path order, then the body, then the query parameters as a struct.
+func (w *goWriter) writeMethod(s *spec, path, method string, shared []*parameter, op *operation) error {
+	name, err := goName(op.OperationID)
+	if err != nil {
+		return fmt.Errorf("operationId: %w", err)
+	}
+
+	var pathParams, queryParams []*parameter
+	for _, param := range append(append([]*parameter{}, shared...), op.Parameters...) {
+		switch param.In {
+		case "path":
+			pathParams = append(pathParams, param)
+		case "query":
+			queryParams = append(queryParams, param)
+		default:
+			return fmt.Errorf("parameter %s: unsupported location %q", param.Name, param.In)
+		}
+	}
+
+	args := []string{"ctx context.Context"}
+
+	// The path is a concatenation of its literal parts and parameters
+	var pathParts []string
+	rest := path
+	for _, match := range pathParamPattern.FindAllStringSubmatchIndex(path, -1) {
+		literal := path[len(path)-len(rest) : match[0]]
+		paramName := path[match[2]:match[3]]
+		rest = path[match[1]:]
+
+		param := findParameter(pathParams, paramName)
+		if param == nil {
+			return fmt.Errorf("path parameter %s is not declared", paramName)
+		}
+		arg, err := goArgName(paramName)
+		if err != nil {
+			return fmt.Errorf("path parameter %s: %w", paramName, err)
+		}
+		typ, err := w.goType(param.Schema, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", paramName, err)
+		}
+		value, err := w.goString(param.Schema, arg)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", paramName, err)
+		}
+		args = append(args, arg+" "+typ)
+		if literal != "" {
+			pathParts = append(pathParts, fmt.Sprintf("%q", literal))
+		}
+		w.use("url")
+		pathParts = append(pathParts, "url.PathEscape("+value+")")
+	}
+	if rest != "" || len(pathParts) == 0 {
+		pathParts = append(pathParts, fmt.Sprintf("%q", rest))
+	}
+
+	bodyArg := "nil"
+	if op.RequestBody != nil {
+		media, ok := op.RequestBody.Content["application/json"]
+		if !ok || media.Schema == nil {
+			return fmt.Errorf("request body must be application/json")
+		}
+		if media.Schema.Ref == "" {
+			return fmt.Errorf("request body must refer to a component schema")
+		}
+		typ, err := w.goType(media.Schema, false)
+		if err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		args = append(args, "req "+typ)
+		bodyArg = "req"
+	}
+
+	if len(queryParams) > 0 {
+		paramsType := name + "Params"
+		if err := w.writeParams(paramsType, name, queryParams); err != nil {
+			return err
+		}
+		args = append(args, "params *"+paramsType)
+		pathParts = append(pathParts, "params.encode()")
+	}
+
+	dataType, noContent, err := w.goResponseType(op)
+	if err != nil {
+		return err
+	}
+
+	var tokenLine string
+	switch op.ClientToken {
+	case "":
+	case "store":
+		if dataType == "" || !hasTokenProperty(s, op) {
+			return fmt.Errorf("x-client-token: store needs a response with a token")
+		}
+		tokenLine = "\tc.SetToken(out.Token)\n"
+	case "clear":
+		tokenLine = "\tc.SetToken(\"\")\n"
+	default:
+		return fmt.Errorf("x-client-token: unsupported value %q", op.ClientToken)
+	}
+
+	summary := strings.TrimSpace(op.Summary)
+	if summary != "" && !strings.HasSuffix(summary, ".") {
+		summary += "."
+	}
+	switch op.ClientToken {
+	case "store":
+		summary += " The returned token is stored on the client."
+	case "clear":
+		summary += " The token on the client is cleared."
+	}
+	writeGoDoc(&w.buf, "", fmt.Sprintf("%s calls %s %s", name, strings.ToUpper(method), path), summary)
+
+	call := fmt.Sprintf("c.do(ctx, http.Method%s, %s, %s, %%s)", httpMethodName(method), strings.Join(pathParts, "+"), bodyArg)
+	signature := fmt.Sprintf("func (c *Client) %s(%s)", name, strings.Join(args, ", "))
+	switch {
+	case (noContent || dataType == "") && tokenLine == "":
+		fmt.Fprintf(&w.buf, "%s error {\n\treturn %s\n}\n\n", signature, fmt.Sprintf(call, "nil"))
+	case noContent || dataType == "":
+		fmt.Fprintf(&w.buf, "%s error {\n\tif err := %s; err != nil {\n\t\treturn err\n\t}\n%s\treturn nil\n}\n\n",
+			signature, fmt.Sprintf(call, "nil"), tokenLine)
+	case strings.HasPrefix(dataType, "[]") || strings.HasPrefix(dataType, "map["):
+		fmt.Fprintf(&w.buf, "%s (%s, error) {\n\tvar out %s\n\tif err := %s; err != nil {\n\t\treturn nil, err\n\t}\n%s\treturn out, nil\n}\n\n",
+			signature, dataType, dataType, fmt.Sprintf(call, "&out"), tokenLine)
+	case strings.HasPrefix(dataType, "*"):
+		fmt.Fprintf(&w.buf, "%s (%s, error) {\n\tvar out %s\n\tif err := %s; err != nil {\n\t\treturn nil, err\n\t}\n%s\treturn &out, nil\n}\n\n",
+			signature, dataType, strings.TrimPrefix(dataType, "*"), fmt.Sprintf(call, "&out"), tokenLine)
+	default:
+		fmt.Fprintf(&w.buf, "%s (%s, error) {\n\tvar out %s\n\tif err := %s; err != nil {\n\t\treturn out, err\n\t}\n%s\treturn out, nil\n}\n\n",
+			signature, dataType, dataType, fmt.Sprintf(call, "&out"), tokenLine)
+	}
+	return nil
+}
+
+// writeParams writes the struct of an operation's query parameters and the
+// method encoding it. Optional parameters are pointers, so zero values and
+// empty strings can be sent.
+func (w *goWriter) writeParams(typeName, method string, params []*parameter) error {
+	var fields, encode bytes.Buffer
+	for _, param := range params {
+		field, err := goName(param.Name)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		typ, err := w.goType(param.Schema, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+		value := "p." + field
+		if !param.Required {
+			typ = "*" + strings.TrimPrefix(typ, "*")
+			value = "*" + value
+		}
+		str, err := w.goString(param.Schema, value)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+
+		doc := fieldDoc(param.Schema)
+		if description := strings.TrimSpace(param.Description); description != "" && doc != "" {
+			doc = description + ". " + doc
+		} else if description != "" {
+			doc = description
+		}
+		writeGoDoc(&fields, "\t", "", doc)
+		fmt.Fprintf(&fields, "\t%s %s\n", field, typ)
+		if param.Required {
+			fmt.Fprintf(&encode, "\tquery.Set(%q, %s)\n", param.Name, str)
+		} else {
+			fmt.Fprintf(&encode, "\tif p.%s != nil {\n\t\tquery.Set(%q, %s)\n\t}\n", field, param.Name, str)
+		}
+	}
+
+	w.use("url")
+	fmt.Fprintf(&w.buf, "// %s are the query parameters of %s\ntype %s struct {\n%s}\n\n", typeName, method, typeName, fields.String())
+	fmt.Fprintf(&w.buf, "// encode returns the query string of the parameters, or \"\" for none\n"+
+		"func (p *%s) encode() string {\n\tif p == nil {\n\t\treturn \"\"\n\t}\n\tquery := url.Values{}\n%s"+
+		"\tif len(query) == 0 {\n\t\treturn \"\"\n\t}\n\treturn \"?\" + query.Encode()\n}\n\n", typeName, encode.String())
+	return nil
+}
+
+// goResponseType returns the Go type of the data of an operation's success
+// response, "" if it has none, or noContent if it answers 204 No Content
+func (w *goWriter) goResponseType(op *operation) (dataType string, noContent bool, err error) {
+	for _, status := range op.Responses.keys {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		if status == "204" {
+			return "", true, nil
+		}
+
+		media, ok := op.Responses.values[status].Content["application/json"]
+		if !ok || media.Schema == nil {
+			return "", false, fmt.Errorf("response %s must be application/json", status)
+		}
+		data, ok := media.Schema.Properties.values["data"]
+		if !ok {
+			return "", false, nil
+		}
+		if data.Type == "object" && data.Ref == "" {
+			return "", false, fmt.Errorf("response %s: data must refer to a component schema", status)
+		}
+		typ, err := w.goType(data, false)
+		if err != nil {
+			return "", false, fmt.Errorf("response %s: %w", status, err)
+		}
+		return typ, false, nil
+	}
+	return "", false, fmt.Errorf("no success response")
+}
+
+// hasTokenProperty reports whether the success response data of an
+// operation is a component schema with a token property
+func hasTokenProperty(s *spec, op *operation) bool {
+	for _, status := range op.Responses.keys {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		data := op.Responses.values[status].Content["application/json"].Schema.Properties.values["data"]
+		if data == nil {
+			return false
+		}
+		name, err := data.refName()
+		if err != nil {
+			return false
+		}
+		component, ok := s.Components.Schemas.values[name]
+		if !ok {
+			return false
+		}
+		token, ok := component.Properties.values["token"]
+		return ok && token.Type == "string"
+	}
+	return false
+}
+
+// goType returns the Go type of a schema. Nullable values, and optional ones
+// whose zero value is meaningful, are pointers; strings, slices and
+// references are not, as empty strings and nil are omitted instead.
+func (w *goWriter) goType(sch *schema, optional bool) (string, error) {
+	if sch == nil {
+		return "", fmt.Errorf("schema is missing")
+	}
+
+	var typ string
+	pointer := sch.Nullable || optional
+	switch {
+	case sch.Ref != "":
+		name, err := sch.refName()
+		if err != nil {
+			return "", err
+		}
+		return "*" + name, nil
+	case sch.Type == "string" && sch.Format == "byte":
+		return "[]byte", nil
+	case sch.Type == "string" && sch.Format == "uuid":
+		w.use("uuid")
+		typ = "uuid.UUID"
+	case sch.Type == "string" && sch.Format == "date-time":
+		w.use("time")
+		typ = "time.Time"
+	case sch.Type == "string":
+		typ = "string"
+		pointer = sch.Nullable
+	case sch.Type == "integer" && sch.Format == "int64":
+		typ = "int64"
+	case sch.Type == "integer":
+		typ = "int"
+	case sch.Type == "number":
+		typ = "float64"
+	case sch.Type == "boolean":
+		typ = "bool"
+	case sch.Type == "array":
+		item, err := w.goType(sch.Items, false)
+		if err != nil {
+			return "", fmt.Errorf("items: %w", err)
+		}
+		return "[]" + item, nil
+	case sch.Type == "object" && len(sch.Properties.keys) == 0:
+		return "map[string]any", nil
+	default:
+		return "", fmt.Errorf("unsupported schema type %q", sch.Type)
+	}
+
+	if pointer {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// goString returns the expression formatting value, a parameter of the
+// schema, as it is sent in a path or query string
+func (w *goWriter) goString(sch *schema, value string) (string, error) {
+	if sch == nil {
+		return "", fmt.Errorf("schema is missing")
+	}
+	switch {
+	case sch.Type == "string" && sch.Format == "uuid":
+		return value + ".String()", nil
+	case sch.Type == "string" && sch.Format == "date-time":
+		return value + ".Format(time.RFC3339Nano)", nil
+	case sch.Type == "string" && sch.Format == "byte":
+		return "", fmt.Errorf("byte parameters are not supported")
+	case sch.Type == "string":
+		return value, nil
+	case sch.Type == "integer" && sch.Format == "int64":
+		w.use("strconv")
+		return "strconv.FormatInt(" + value + ", 10)", nil
+	case sch.Type == "integer":
+		w.use("strconv")
+		return "strconv.Itoa(" + value + ")", nil
+	case sch.Type == "number":
+		w.use("strconv")
+		return "strconv.FormatFloat(" + value + ", 'f', -1, 64)", nil
+	case sch.Type == "boolean":
+		w.use("strconv")
+		return "strconv.FormatBool(" + value + ")", nil
+	default:
+		return "", fmt.Errorf("unsupported parameter type %q", sch.Type)
+	}
+}
+
+// goName turns a snake_case, kebab-case or camelCase name into an exported
+// Go identifier, as in user_id -> UserID and listTodos -> ListTodos
+func goName(name string) (string, error) {
+	if !goIdentifierPattern.MatchString(strings.ReplaceAll(name, "-", "_")) {
+		return "", fmt.Errorf("%q is not a valid identifier", name)
+	}
+
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }) {
+		// camelCase parts split further at each upper-case letter
+		start := 0
+		for i := 1; i <= len(part); i++ {
+			if i == len(part) || (part[i] >= 'A' && part[i] <= 'Z') {
+				word := part[start:i]
+				if goInitialisms[strings.ToLower(word)] {
+					b.WriteString(strings.ToUpper(word))
+				} else {
+					b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+				}
+				start = i
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// goArgName turns a parameter name into an unexported Go identifier
+func goArgName(name string) (string, error) {
+	exported, err := goName(name)
+	if err != nil {
+		return "", err
+	}
+	if strings.ToUpper(exported) == exported {
+		return strings.ToLower(exported), nil
+	}
+	return strings.ToLower(exported[:1]) + exported[1:], nil
+}
+
+// httpMethodName returns the suffix of the net/http constant of a method
+func httpMethodName(method string) string {
+	return strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+}
+
+// writeGoDoc writes a doc comment of a summary line and an optional paragraph
+func writeGoDoc(buf *bytes.Buffer, indent, summary, text string) {
+	var lines []string
+	if summary != "" {
+		lines = append(lines, summary)
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		if summary != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(buf, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(buf, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,65 @@
+// Command clientgen generates the TypeScript client in clients/typescript and
+// the types and operations of the Go client in pkg/client from the OpenAPI
+// spec in api/openapi.yaml. Run it with `make clients`.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	specPath := flag.String("spec", "api/openapi.yaml", "OpenAPI spec to read")
+	outPath := flag.String("out", "clients/typescript/client.ts", "TypeScript file to write, or empty to skip it")
+	goOutPath := flag.String("go-out", "pkg/client/api.gen.go", "Go file to write, or empty to skip it")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	s, err := loadSpec(*specPath)
+	if err != nil {
+		logger.Error("failed to load spec", "spec", *specPath, "error", err)
+		os.Exit(1)
+	}
+
+	for _, client := range []struct {
+		path     string
+		generate func(*spec) ([]byte, error)
+	}{
+		{*outPath, generateTypeScript},
+		{*goOutPath, generateGo},
+	} {
+		if client.path == "" {
+			continue
+		}
+
+		src, err := client.generate(s)
+		if err != nil {
+			logger.Error("failed to generate client", "spec", *specPath, "path", client.path, "error", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(client.path, src, 0o644); err != nil {
+			logger.Error("failed to write client", "path", client.path, "error", err)
+			os.Exit(1)
+		}
+		logger.Info("client generated", "spec", *specPath, "path", client.path)
+	}
+}
+
+// loadSpec reads and parses the spec at specPath
+func loadSpec(specPath string) (*spec, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var s spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestClientsUpToDate fails when the committed clients were not regenerated
+// after a change to the spec or the generators
+func TestClientsUpToDate(t *testing.T) {
+	s, err := loadSpec("../../api/openapi.yaml")
+	if err != nil {
+		t.Fatalf("loadSpec: %v", err)
+	}
+
+	for _, client := range []struct {
+		path     string
+		generate func(*spec) ([]byte, error)
+	}{
+		{"../../clients/typescript/client.ts", generateTypeScript},
+		{"../../pkg/client/api.gen.go", generateGo},
+	} {
+		want, err := client.generate(s)
+		if err != nil {
+			t.Fatalf("generate %s: %v", client.path, err)
+		}
+
+		got, err := os.ReadFile(client.path)
+		if err != nil {
+			t.Fatalf("failed to read client: %v", err)
+		}
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date; run `make clients`", client.path)
+		}
+	}
+}
+
+func TestGenerateRejectsInvalidSpecs(t *testing.T) {
+	const components = `
+info:
+  title: Test
+components:
+  schemas:
+    ErrorInfo:
+      type: object
+    Meta:
+      type: object
+`
+	tests := []struct {
+		name    string
+		paths   string
+		wantErr string
+	}{
+		{
+			name: "missing operationId",
+			paths: `
+paths:
+  /things:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+`,
+			wantErr: "operationId is required",
+		},
+		{
+			name: "undeclared path parameter",
+			paths: `
+paths:
+  /things/{id}:
+    get:
+      operationId: getThing
+      responses:
+        "204":
+          description: ok
+`,
+			wantErr: "path parameter id is not declared",
+		},
+		{
+			name: "unsupported schema type",
+			paths: `
+paths:
+  /things:
+    post:
+      operationId: createThing
+      parameters:
+        - name: upload
+          in: query
+          schema:
+            type: file
+      responses:
+        "204":
+          description: ok
+`,
+			wantErr: `unsupported schema type "file"`,
+		},
+		{
+			name: "token stored from a response without one",
+			paths: `
+paths:
+  /things:
+    delete:
+      operationId: deleteThings
+      x-client-token: store
+      responses:
+        "204":
+          description: ok
+`,
+			wantErr: "x-client-token: store needs a response with a token",
+		},
+		{
+			name: "no success response",
+			paths: `
+paths:
+  /things:
+    get:
+      operationId: listThings
+      responses:
+        "404":
+          description: missing
+`,
+			wantErr: "no success response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s spec
+			if err := yaml.Unmarshal([]byte(components+tt.paths), &s); err != nil {
+				t.Fatalf("failed to parse spec: %v", err)
+			}
+
+			for name, generate := range map[string]func(*spec) ([]byte, error){
+				"TypeScript": generateTypeScript,
+				"Go":         generateGo,
+			} {
+				_, err := generate(&s)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%s: error = %v, want one containing %q", name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"id":                "ID",
+		"user_id":           "UserID",
+		"e2e_enabled":       "E2EEnabled",
+		"telemetry_opt_out": "TelemetryOptOut",
+		"listTodos":         "ListTodos",
+		"logout-all":        "LogoutAll",
+		"api_url":           "APIURL",
+	}
+	for name, want := range tests {
+		if got, err := goName(name); err != nil || got != want {
+			t.Errorf("goName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := goName("1st"); err == nil {
+		t.Error("goName accepted a name starting with a digit")
+	}
+}
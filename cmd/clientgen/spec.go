@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the part of an OpenAPI 3.0 document the generator reads
+type spec struct {
+	Info struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Paths      orderedMap[*pathItem] `yaml:"paths"`
+	Components struct {
+		Schemas orderedMap[*schema] `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// pathItem holds the operations of a path, in the order of the document, and
+// the parameters they share
+type pathItem struct {
+	Parameters []*parameter
+	Operations orderedMap[*operation]
+}
+
+// httpMethods are the operation keys of a path item
+var httpMethods = map[string]bool{
+	"get": true, "put": true, "post": true, "delete": true, "patch": true, "head": true, "options": true,
+}
+
+// UnmarshalYAML splits a path item into its parameters and operations
+func (p *pathItem) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: path item must be a mapping", node.Line)
+	}
+	p.Operations.values = make(map[string]*operation)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		switch {
+		case key == "parameters":
+			if err := value.Decode(&p.Parameters); err != nil {
+				return err
+			}
+		case httpMethods[key]:
+			var op operation
+			if err := value.Decode(&op); err != nil {
+				return err
+			}
+			p.Operations.keys = append(p.Operations.keys, key)
+			p.Operations.values[key] = &op
+		default:
+			return fmt.Errorf("line %d: unsupported path item field %q", node.Content[i].Line, key)
+		}
+	}
+	return nil
+}
+
+// operation is one method of a path
+type operation struct {
+	OperationID string                `yaml:"operationId"`
+	Summary     string                `yaml:"summary"`
+	Parameters  []*parameter          `yaml:"parameters"`
+	RequestBody *requestBody          `yaml:"requestBody"`
+	Responses   orderedMap[*response] `yaml:"responses"`
+	// ClientToken is "store" when the clients keep the token of the
+	// response, and "clear" when they forget their token
+	ClientToken string `yaml:"x-client-token"`
+}
+
+// parameter is a path or query parameter
+type parameter struct {
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *schema `yaml:"schema"`
+}
+
+// requestBody is the JSON body of an operation
+type requestBody struct {
+	Required bool                 `yaml:"required"`
+	Content  map[string]mediaType `yaml:"content"`
+}
+
+// response is one status of an operation
+type response struct {
+	Description string               `yaml:"description"`
+	Content     map[string]mediaType `yaml:"content"`
+}
+
+// mediaType is the schema of a body in one content type
+type mediaType struct {
+	Schema *schema `yaml:"schema"`
+}
+
+// schema is the subset of JSON Schema the generator turns into types
+type schema struct {
+	Ref         string              `yaml:"$ref"`
+	Type        string              `yaml:"type"`
+	Format      string              `yaml:"format"`
+	Description string              `yaml:"description"`
+	Nullable    bool                `yaml:"nullable"`
+	Enum        []string            `yaml:"enum"`
+	Items       *schema             `yaml:"items"`
+	Properties  orderedMap[*schema] `yaml:"properties"`
+	Required    []string            `yaml:"required"`
+}
+
+// refName returns the component name a schema reference points at
+func (s *schema) refName() (string, error) {
+	const prefix = "#/components/schemas/"
+	if !strings.HasPrefix(s.Ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q", s.Ref)
+	}
+	return strings.TrimPrefix(s.Ref, prefix), nil
+}
+
+// isRequired reports whether an object schema requires a property
+func (s *schema) isRequired(name string) bool {
+	for _, required := range s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMap is a YAML mapping that keeps the order of its keys, so the
+// generated code follows the order of the document
+type orderedMap[V any] struct {
+	keys   []string
+	values map[string]V
+}
+
+// UnmarshalYAML decodes a mapping in document order
+func (m *orderedMap[V]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	m.values = make(map[string]V, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		var value V
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		m.keys = append(m.keys, key)
+		m.values[key] = value
+	}
+	return nil
+}
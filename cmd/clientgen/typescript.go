@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// identifierPattern matches names usable as TypeScript identifiers
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// pathParamPattern matches the parameters of a path template
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// runtimeSchemas are the components the client runtime refers to
+var runtimeSchemas = []string{"ErrorInfo", "Meta"}
+
+// generateTypeScript returns a TypeScript client for the spec: an interface
+// per component schema and a Client method per operation
+func generateTypeScript(s *spec) ([]byte, error) {
+	for _, name := range runtimeSchemas {
+		if _, ok := s.Components.Schemas.values[name]; !ok {
+			return nil, fmt.Errorf("components.schemas.%s is required by the client runtime", name)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by cmd/clientgen from the %s spec. DO NOT EDIT.\n\n", s.Info.Title)
+
+	for _, name := range s.Components.Schemas.keys {
+		if err := writeInterface(&buf, name, s.Components.Schemas.values[name]); err != nil {
+			return nil, fmt.Errorf("components.schemas.%s: %w", name, err)
+		}
+	}
+
+	buf.WriteString(runtimeHead)
+
+	seen := make(map[string]bool)
+	for _, path := range s.Paths.keys {
+		item := s.Paths.values[path]
+		for _, method := range item.Operations.keys {
+			op := item.Operations.values[method]
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: operationId is required", strings.ToUpper(method), path)
+			}
+			if seen[op.OperationID] {
+				return nil, fmt.Errorf("%s %s: duplicate operationId %q", strings.ToUpper(method), path, op.OperationID)
+			}
+			seen[op.OperationID] = true
+
+			if err := writeMethod(&buf, path, method, item.Parameters, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+		}
+	}
+
+	buf.WriteString(runtimeTail)
+	return buf.Bytes(), nil
+}
+
+// writeInterface writes a component schema as an exported type
+func writeInterface(buf *bytes.Buffer, name string, sch *schema) error {
+	if !identifierPattern.MatchString(name) {
+		return fmt.Errorf("name is not a valid identifier")
+	}
+	writeDoc(buf, "", sch.Description)
+
+	if sch.Type != "object" || sch.Nullable {
+		typ, err := tsType(sch)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "export type %s = %s;\n\n", name, typ)
+		return nil
+	}
+
+	fmt.Fprintf(buf, "export interface %s {\n", name)
+	for _, prop := range sch.Properties.keys {
+		propSchema := sch.Properties.values[prop]
+		typ, err := tsType(propSchema)
+		if err != nil {
+			return fmt.Errorf("property %s: %w", prop, err)
+		}
+		writeDoc(buf, "  ", propSchema.Description)
+		optional := "?"
+		if sch.isRequired(prop) {
+			optional = ""
+		}
+		fmt.Fprintf(buf, "  %s%s: %s;\n", propertyName(prop), optional, typ)
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+// writeMethod writes the Client method of an operation. Path parameters come
+// first, in path order, then the body, then the query parameters as an object.
+func writeMethod(buf *bytes.Buffer, path, method string, shared []*parameter, op *operation) error {
+	var pathParams, queryParams []*parameter
+	for _, param := range append(append([]*parameter{}, shared...), op.Parameters...) {
+		switch param.In {
+		case "path":
+			pathParams = append(pathParams, param)
+		case "query":
+			queryParams = append(queryParams, param)
+		default:
+			return fmt.Errorf("parameter %s: unsupported location %q", param.Name, param.In)
+		}
+	}
+
+	var args []string
+	urlExpr := `"` + path + `"`
+	if matches := pathParamPattern.FindAllStringSubmatch(path, -1); len(matches) > 0 {
+		template := path
+		for _, match := range matches {
+			name := match[1]
+			param := findParameter(pathParams, name)
+			if param == nil {
+				return fmt.Errorf("path parameter %s is not declared", name)
+			}
+			if !identifierPattern.MatchString(name) {
+				return fmt.Errorf("path parameter %s is not a valid identifier", name)
+			}
+			typ, err := tsType(param.Schema)
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", name, err)
+			}
+			args = append(args, fmt.Sprintf("%s: %s", name, typ))
+			template = strings.Replace(template, match[0], "${encodeURIComponent(String("+name+"))}", 1)
+		}
+		urlExpr = "`" + template + "`"
+	}
+
+	bodyArg := "undefined"
+	if op.RequestBody != nil {
+		media, ok := op.RequestBody.Content["application/json"]
+		if !ok || media.Schema == nil {
+			return fmt.Errorf("request body must be application/json")
+		}
+		typ, err := tsType(media.Schema)
+		if err != nil {
+			return fmt.Errorf("request body: %w", err)
+		}
+		optional := "?"
+		if op.RequestBody.Required {
+			optional = ""
+		}
+		args = append(args, fmt.Sprintf("body%s: %s", optional, typ))
+		bodyArg = "body"
+	}
+
+	queryArg := "undefined"
+	if len(queryParams) > 0 {
+		fields := make([]string, 0, len(queryParams))
+		for _, param := range queryParams {
+			typ, err := tsType(param.Schema)
+			if err != nil {
+				return fmt.Errorf("parameter %s: %w", param.Name, err)
+			}
+			optional := "?"
+			if param.Required {
+				optional = ""
+			}
+			fields = append(fields, fmt.Sprintf("%s%s: %s", propertyName(param.Name), optional, typ))
+		}
+		args = append(args, fmt.Sprintf("query: { %s } = {}", strings.Join(fields, "; ")))
+		queryArg = "query"
+	}
+
+	dataType, noContent, err := responseType(op)
+	if err != nil {
+		return err
+	}
+
+	writeDoc(buf, "  ", op.Summary)
+	call := fmt.Sprintf("this.request<%s>(%q, %s, %s, %s)", dataType, strings.ToUpper(method), urlExpr, queryArg, bodyArg)
+	switch {
+	case op.ClientToken == "store":
+		if noContent {
+			return fmt.Errorf("x-client-token: store needs a response with a token")
+		}
+		fmt.Fprintf(buf, "  async %s(%s): Promise<Envelope<%s>> {\n    const env = await %s;\n    this.token = env.data?.token;\n    return env;\n  }\n\n",
+			op.OperationID, strings.Join(args, ", "), dataType, call)
+	case op.ClientToken == "clear":
+		fmt.Fprintf(buf, "  async %s(%s): Promise<void> {\n    await %s;\n    this.token = undefined;\n  }\n\n", op.OperationID, strings.Join(args, ", "), call)
+	case op.ClientToken != "":
+		return fmt.Errorf("x-client-token: unsupported value %q", op.ClientToken)
+	case noContent:
+		fmt.Fprintf(buf, "  async %s(%s): Promise<void> {\n    await %s;\n  }\n\n", op.OperationID, strings.Join(args, ", "), call)
+	default:
+		fmt.Fprintf(buf, "  %s(%s): Promise<Envelope<%s>> {\n    return %s;\n  }\n\n", op.OperationID, strings.Join(args, ", "), dataType, call)
+	}
+	return nil
+}
+
+// responseType returns the type of the data of an operation's success
+// response, or noContent if it answers 204 No Content
+func responseType(op *operation) (dataType string, noContent bool, err error) {
+	for _, status := range op.Responses.keys {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		if status == "204" {
+			return "unknown", true, nil
+		}
+
+		media, ok := op.Responses.values[status].Content["application/json"]
+		if !ok || media.Schema == nil {
+			return "", false, fmt.Errorf("response %s must be application/json", status)
+		}
+		data, ok := media.Schema.Properties.values["data"]
+		if !ok {
+			return "unknown", false, nil
+		}
+		typ, err := tsType(data)
+		if err != nil {
+			return "", false, fmt.Errorf("response %s: %w", status, err)
+		}
+		return typ, false, nil
+	}
+	return "", false, fmt.Errorf("no success response")
+}
+
+// tsType returns the TypeScript type of a schema
+func tsType(sch *schema) (string, error) {
+	if sch == nil {
+		return "", fmt.Errorf("schema is missing")
+	}
+
+	var typ string
+	switch {
+	case sch.Ref != "":
+		name, err := sch.refName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	case len(sch.Enum) > 0:
+		values := make([]string, len(sch.Enum))
+		for i, value := range sch.Enum {
+			values[i] = fmt.Sprintf("%q", value)
+		}
+		typ = strings.Join(values, " | ")
+	case sch.Type == "string":
+		// UUIDs, timestamps and base64 bytes all travel as strings
+		typ = "string"
+	case sch.Type == "integer" || sch.Type == "number":
+		typ = "number"
+	case sch.Type == "boolean":
+		typ = "boolean"
+	case sch.Type == "array":
+		item, err := tsType(sch.Items)
+		if err != nil {
+			return "", fmt.Errorf("items: %w", err)
+		}
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		typ = item + "[]"
+	case sch.Type == "object" && len(sch.Properties.keys) == 0:
+		typ = "Record<string, unknown>"
+	case sch.Type == "object":
+		fields := make([]string, 0, len(sch.Properties.keys))
+		for _, prop := range sch.Properties.keys {
+			propType, err := tsType(sch.Properties.values[prop])
+			if err != nil {
+				return "", fmt.Errorf("property %s: %w", prop, err)
+			}
+			optional := "?"
+			if sch.isRequired(prop) {
+				optional = ""
+			}
+			fields = append(fields, fmt.Sprintf("%s%s: %s", propertyName(prop), optional, propType))
+		}
+		typ = "{ " + strings.Join(fields, "; ") + " }"
+	default:
+		return "", fmt.Errorf("unsupported schema type %q", sch.Type)
+	}
+
+	if sch.Nullable {
+		typ += " | null"
+	}
+	return typ, nil
+}
+
+// findParameter returns the parameter called name, or nil
+func findParameter(params []*parameter, name string) *parameter {
+	for _, param := range params {
+		if param.Name == name {
+			return param
+		}
+	}
+	return nil
+}
+
+// propertyName quotes names that are not valid identifiers
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+// writeDoc writes text as a JSDoc comment at the given indentation
+func writeDoc(buf *bytes.Buffer, indent, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(buf, "%s/** %s */\n", indent, strings.ReplaceAll(text, "*/", "* /"))
+}
+
+// runtimeHead opens the Client class; the generated methods follow it
+const runtimeHead = `/** Envelope wraps every JSON response of the API */
+export interface Envelope<T> {
+  success: boolean;
+  data?: T;
+  error?: ErrorInfo;
+  meta?: Meta;
+}
+
+/** APIError is thrown when the API answers with an error */
+export class APIError extends Error {
+  readonly status: number;
+  readonly code: string;
+  readonly details: string[];
+  readonly requestId?: string;
+
+  constructor(status: number, info: ErrorInfo, requestId?: string) {
+    super(` + "`${info.code} (${status}): ${info.message}`" + `);
+    this.name = "APIError";
+    this.status = status;
+    this.code = info.code;
+    this.details = info.details ?? [];
+    this.requestId = requestId;
+  }
+}
+
+/** Query holds the query parameters of a request */
+export type Query = Record<string, string | number | boolean | undefined>;
+
+export interface ClientOptions {
+  /** Bearer token sent with every request */
+  token?: string;
+  /** fetch implementation, the global fetch by default */
+  fetch?: typeof fetch;
+}
+
+/** Client is a typed client for the API */
+export class Client {
+  /** Bearer token sent with every request; login, refresh and password changes set it */
+  token?: string;
+  private readonly baseURL: string;
+  private readonly fetchImpl: typeof fetch;
+
+  constructor(baseURL: string, options: ClientOptions = {}) {
+    this.baseURL = baseURL.replace(/\/+$/, "");
+    this.token = options.token;
+    this.fetchImpl = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+
+`
+
+// runtimeTail closes the Client class with the request helper
+const runtimeTail = `  private async request<T>(method: string, path: string, query?: Query, body?: unknown): Promise<Envelope<T>> {
+    const url = new URL(this.baseURL + path);
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined) {
+        url.searchParams.set(key, String(value));
+      }
+    }
+
+    const headers: Record<string, string> = { Accept: "application/json" };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    if (this.token) {
+      headers["Authorization"] = ` + "`Bearer ${this.token}`" + `;
+    }
+
+    const res = await this.fetchImpl(url, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    // Actions with nothing to return respond without a body
+    if (res.status === 204) {
+      return { success: true };
+    }
+
+    let env: Envelope<T> | undefined;
+    try {
+      env = (await res.json()) as Envelope<T>;
+    } catch {
+      env = undefined;
+    }
+    if (!res.ok || !env?.success) {
+      const info = env?.error ?? { code: res.statusText || String(res.status), message: "unexpected error response" };
+      throw new APIError(res.status, info, env?.meta?.request_id);
+    }
+    return env;
+  }
+}
+`
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whauzan/todo-api/pkg/client"
+)
+
+// scenarioFunc performs a single request against the API
+type scenarioFunc func(ctx context.Context, c *client.Client) error
+
+func main() {
+	baseURL := flag.String("base-url", "http://localhost:8080", "API base URL")
+	email := flag.String("email", "loadtest@example.com", "email of the load test user (registered if missing)")
+	pass := flag.String("password", "loadtest-password", "password of the load test user")
+	scenarios := flag.String("scenarios", "login,list,create", "comma-separated scenarios to run (login, list, create)")
+	duration := flag.Duration("duration", 10*time.Second, "duration of each scenario")
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers per scenario")
+	output := flag.String("output", "", "write results as JSON to this file (default: stdout)")
+	baselinePath := flag.String("baseline", "", "compare results against this baseline results file")
+	threshold := flag.Float64("threshold", 20, "allowed p99 latency regression against the baseline, in percent")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if *concurrency < 1 {
+		logger.Error("concurrency must be at least 1")
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	c := client.New(*baseURL)
+
+	if err := ensureUser(ctx, c, *email, *pass); err != nil {
+		logger.Error("failed to prepare load test user", "error", err)
+		os.Exit(1)
+	}
+
+	available := map[string]scenarioFunc{
+		"login": func(ctx context.Context, _ *client.Client) error {
+			// Use a fresh client so the shared token is not overwritten
+			_, err := client.New(*baseURL).Login(ctx, &client.LoginRequest{Email: *email, Password: *pass})
+			return err
+		},
+		"list": func(ctx context.Context, c *client.Client) error {
+			_, err := c.ListTodos(ctx, nil)
+			return err
+		},
+		"create": func(ctx context.Context, c *client.Client) error {
+			_, err := c.CreateTodo(ctx, &client.CreateTodoRequest{Title: "load test todo"})
+			return err
+		},
+	}
+
+	results := &Results{
+		BaseURL:     *baseURL,
+		Concurrency: *concurrency,
+		Duration:    duration.String(),
+		StartedAt:   time.Now().UTC(),
+	}
+
+	for _, name := range strings.Split(*scenarios, ",") {
+		name = strings.TrimSpace(name)
+		fn, ok := available[name]
+		if !ok {
+			logger.Error("unknown scenario", "scenario", name)
+			os.Exit(1)
+		}
+
+		logger.Info("running scenario", "scenario", name, "duration", *duration, "concurrency", *concurrency)
+		result := run(ctx, c, name, fn, *duration, *concurrency)
+		logger.Info("scenario finished",
+			"scenario", name,
+			"requests", result.Requests,
+			"errors", result.Errors,
+			"rps", fmt.Sprintf("%.1f", result.RPS),
+			"p99_ms", result.Latency.P99,
+		)
+		results.Scenarios = append(results.Scenarios, result)
+	}
+
+	if err := writeResults(results, *output); err != nil {
+		logger.Error("failed to write results", "error", err)
+		os.Exit(1)
+	}
+
+	if *baselinePath != "" {
+		baseline, err := readResults(*baselinePath)
+		if err != nil {
+			logger.Error("failed to read baseline", "error", err)
+			os.Exit(1)
+		}
+
+		regressions := compare(baseline, results, *threshold)
+		for _, r := range regressions {
+			logger.Error("performance regression", "detail", r)
+		}
+		if len(regressions) > 0 {
+			os.Exit(2)
+		}
+		logger.Info("no regressions against baseline", "baseline", *baselinePath)
+	}
+}
+
+// ensureUser logs in as the load test user, registering it first if needed
+func ensureUser(ctx context.Context, c *client.Client, email, pass string) error {
+	_, err := c.Login(ctx, &client.LoginRequest{Email: email, Password: pass})
+	if err == nil {
+		return nil
+	}
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "INVALID_CREDENTIALS" {
+		return err
+	}
+
+	if _, err := c.Register(ctx, &client.RegisterRequest{Email: email, Password: pass, Name: "Load Test"}); err != nil {
+		return err
+	}
+
+	_, err = c.Login(ctx, &client.LoginRequest{Email: email, Password: pass})
+	return err
+}
+
+// run executes a scenario with the given number of workers until the duration elapses
+func run(ctx context.Context, c *client.Client, name string, fn scenarioFunc, duration time.Duration, concurrency int) ScenarioResult {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errCount  int
+		wg        sync.WaitGroup
+	)
+
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				reqStart := time.Now()
+				err := fn(ctx, c)
+				elapsed := time.Since(reqStart)
+
+				// Requests cut off by the end of the run are not counted
+				if ctx.Err() != nil {
+					return
+				}
+
+				mu.Lock()
+				latencies = append(latencies, elapsed)
+				if err != nil {
+					errCount++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return newScenarioResult(name, latencies, errCount, time.Since(start))
+}
+
+// writeResults writes results as indented JSON to path, or stdout if path is empty
+func writeResults(results *Results, path string) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %w", err)
+	}
+	data = append(data, '\n')
+
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// readResults reads a results file written by a previous run
+func readResults(path string) (*Results, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var results Results
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to decode results: %w", err)
+	}
+	return &results, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Results is the baseline results format written by the load test runner
+type Results struct {
+	BaseURL     string           `json:"base_url"`
+	Concurrency int              `json:"concurrency"`
+	Duration    string           `json:"duration"`
+	StartedAt   time.Time        `json:"started_at"`
+	Scenarios   []ScenarioResult `json:"scenarios"`
+}
+
+// ScenarioResult holds the aggregated measurements of a single scenario
+type ScenarioResult struct {
+	Name     string  `json:"name"`
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	RPS      float64 `json:"rps"`
+	Latency  Latency `json:"latency_ms"`
+}
+
+// Latency holds latency percentiles in milliseconds
+type Latency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// newScenarioResult aggregates raw latencies into a ScenarioResult
+func newScenarioResult(name string, latencies []time.Duration, errCount int, elapsed time.Duration) ScenarioResult {
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	result := ScenarioResult{
+		Name:     name,
+		Requests: len(latencies),
+		Errors:   errCount,
+	}
+	if elapsed > 0 {
+		result.RPS = float64(len(latencies)) / elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		result.Latency = Latency{
+			P50: percentile(latencies, 50),
+			P90: percentile(latencies, 90),
+			P99: percentile(latencies, 99),
+			Max: toMillis(latencies[len(latencies)-1]),
+		}
+	}
+	return result
+}
+
+// percentile returns the p-th percentile of sorted latencies in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return toMillis(sorted[idx])
+}
+
+func toMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// compare returns a description of every scenario whose p99 latency regressed
+// by more than threshold percent, or whose error count increased, against the baseline
+func compare(baseline, current *Results, threshold float64) []string {
+	previous := make(map[string]ScenarioResult, len(baseline.Scenarios))
+	for _, s := range baseline.Scenarios {
+		previous[s.Name] = s
+	}
+
+	var regressions []string
+	for _, s := range current.Scenarios {
+		base, ok := previous[s.Name]
+		if !ok {
+			continue
+		}
+
+		if base.Latency.P99 > 0 {
+			change := (s.Latency.P99 - base.Latency.P99) / base.Latency.P99 * 100
+			if change > threshold {
+				regressions = append(regressions, fmt.Sprintf(
+					"%s: p99 latency %.2fms -> %.2fms (+%.1f%%)", s.Name, base.Latency.P99, s.Latency.P99, change))
+			}
+		}
+
+		if s.Errors > base.Errors {
+			regressions = append(regressions, fmt.Sprintf(
+				"%s: errors %d -> %d", s.Name, base.Errors, s.Errors))
+		}
+	}
+	return regressions
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/pkg/client"
+)
+
+// runLogin logs in and caches the token
+func runLogin(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	email := fs.String("email", "", "account email")
+	pass := fs.String("password", "", "account password (read from TASKJOY_PASSWORD or prompted if empty)")
+	_ = fs.Parse(args)
+
+	if *email == "" {
+		return errors.New("-email is required")
+	}
+
+	if *pass == "" {
+		*pass = os.Getenv("TASKJOY_PASSWORD")
+	}
+	if *pass == "" {
+		fmt.Fprint(os.Stderr, "Password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		*pass = strings.TrimRight(line, "\r\n")
+	}
+
+	c := client.New(apiURL())
+	resp, err := c.Login(ctx, &client.LoginRequest{Email: *email, Password: *pass})
+	if err != nil {
+		return err
+	}
+
+	if err := saveCredentials(&credentials{
+		APIURL:    apiURL(),
+		Email:     resp.User.Email,
+		Token:     resp.Token,
+		ExpiresAt: resp.ExpiresAt,
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in as %s\n", resp.User.Email)
+	return nil
+}
+
+// runLogout logs out and removes the cached token
+func runLogout(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	if c, err := authenticatedClient(); err == nil {
+		// Best effort; the token is discarded locally either way
+		_ = c.Logout(ctx)
+	}
+
+	if err := removeCredentials(); err != nil {
+		return err
+	}
+
+	fmt.Println("Logged out")
+	return nil
+}
+
+// runAdd creates a todo
+func runAdd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	description := fs.String("description", "", "todo description")
+	output := fs.String("output", "table", "output format (table or json)")
+	_ = fs.Parse(args)
+
+	title := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if title == "" {
+		return errors.New("usage: taskjoy add [flags] <title>")
+	}
+
+	c, err := authenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	req := &client.CreateTodoRequest{Title: title}
+	if *description != "" {
+		req.Description = description
+	}
+
+	todo, err := c.CreateTodo(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return printTodos(*output, []*client.Todo{todo})
+}
+
+// runList lists todos
+func runList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	output := fs.String("output", "table", "output format (table or json)")
+	all := fs.Bool("all", false, "include completed todos")
+	_ = fs.Parse(args)
+
+	c, err := authenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	todos, err := c.ListTodos(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if !*all {
+		open := todos[:0]
+		for _, todo := range todos {
+			if !todo.Completed {
+				open = append(open, todo)
+			}
+		}
+		todos = open
+	}
+
+	return printTodos(*output, todos)
+}
+
+// runDone marks a todo as completed
+func runDone(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("done", flag.ExitOnError)
+	output := fs.String("output", "table", "output format (table or json)")
+	_ = fs.Parse(args)
+
+	id, err := parseTodoID(fs.Args(), "done")
+	if err != nil {
+		return err
+	}
+
+	c, err := authenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	completed := true
+	todo, err := c.UpdateTodo(ctx, id, &client.UpdateTodoRequest{Completed: &completed})
+	if err != nil {
+		return err
+	}
+
+	return printTodos(*output, []*client.Todo{todo})
+}
+
+// runRemove deletes a todo
+func runRemove(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	_ = fs.Parse(args)
+
+	id, err := parseTodoID(fs.Args(), "rm")
+	if err != nil {
+		return err
+	}
+
+	c, err := authenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	if err := c.DeleteTodo(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %s\n", id)
+	return nil
+}
+
+// authenticatedClient returns a client using the cached token
+func authenticatedClient() (*client.Client, error) {
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	url := creds.APIURL
+	if url == "" {
+		url = apiURL()
+	}
+
+	return client.New(url, client.WithToken(creds.Token)), nil
+}
+
+// parseTodoID parses the single todo ID argument of a command
+func parseTodoID(args []string, name string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.Nil, fmt.Errorf("usage: taskjoy %s <todo-id>", name)
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid todo ID: %s", args[0])
+	}
+	return id, nil
+}
+
+// printTodos writes todos to stdout in the requested format
+func printTodos(format string, todos []*client.Todo) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(todos)
+	case "table":
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tDONE\tTITLE\tCREATED")
+		for _, todo := range todos {
+			done := " "
+			if todo.Completed {
+				done = "x"
+			}
+			title := todo.Title
+			if todo.Encrypted {
+				title = "(encrypted)"
+			}
+			fmt.Fprintf(tw, "%s\t[%s]\t%s\t%s\n", todo.ID, done, title, todo.CreatedAt.Local().Format("2006-01-02 15:04"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s (must be table or json)", format)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+const usage = `Usage: taskjoy <command> [flags]
+
+Commands:
+  login    Log in and cache the access token
+  logout   Remove the cached access token
+  add      Create a todo
+  list     List todos
+  done     Mark a todo as completed
+  rm       Delete a todo
+
+Environment:
+  TASKJOY_API_URL  API base URL (default http://localhost:8080)
+
+Run "taskjoy <command> -h" for command flags.
+`
+
+// command is a CLI subcommand
+type command func(ctx context.Context, args []string) error
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]command{
+		"login":  runLogin,
+		"logout": runLogout,
+		"add":    runAdd,
+		"list":   runList,
+		"done":   runDone,
+		"rm":     runRemove,
+	}
+
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		fmt.Fprint(os.Stdout, usage)
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := cmd(ctx, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		cancel()
+		os.Exit(1)
+	}
+}
+
+// apiURL returns the API base URL from the environment
+func apiURL() string {
+	if url := os.Getenv("TASKJOY_API_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:8080"
+}
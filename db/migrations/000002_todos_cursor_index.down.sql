@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_todos_user_id_created_at_id;
@@ -0,0 +1,4 @@
+-- Support keyset (cursor) pagination ordered by creation time.
+-- The id column breaks ties between todos created in the same instant
+-- and works for both random (v4) and time-ordered (v7) UUIDs.
+CREATE INDEX idx_todos_user_id_created_at_id ON todos(user_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_todos_user_id_updated_at;
+DROP TABLE IF EXISTS todo_tombstones;
@@ -0,0 +1,12 @@
+-- Record deleted todos so sync clients can learn about deletions
+CREATE TABLE todo_tombstones (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on user_id and deleted_at for delta queries
+CREATE INDEX idx_todo_tombstones_user_id_deleted_at ON todo_tombstones(user_id, deleted_at);
+
+-- Create index on user_id and updated_at for delta queries
+CREATE INDEX idx_todos_user_id_updated_at ON todos(user_id, updated_at);
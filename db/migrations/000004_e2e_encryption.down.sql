@@ -0,0 +1,5 @@
+ALTER TABLE todos
+    DROP COLUMN IF EXISTS description_ciphertext,
+    DROP COLUMN IF EXISTS title_ciphertext,
+    DROP COLUMN IF EXISTS encrypted;
+ALTER TABLE users DROP COLUMN IF EXISTS e2e_enabled;
@@ -0,0 +1,9 @@
+-- Users in end-to-end encryption mode may only store encrypted todo content
+ALTER TABLE users ADD COLUMN e2e_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Encrypted todos keep their content as ciphertext produced with client-held keys.
+-- The plaintext title is empty and the plaintext description is NULL for them.
+ALTER TABLE todos
+    ADD COLUMN encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN title_ciphertext BYTEA,
+    ADD COLUMN description_ciphertext BYTEA;
@@ -0,0 +1,5 @@
+-- Account status: suspended and pending_deletion accounts cannot authenticate
+ALTER TABLE users
+    ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'suspended', 'pending_deletion')),
+    ADD COLUMN status_reason TEXT;
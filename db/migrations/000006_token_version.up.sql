@@ -0,0 +1,4 @@
+-- Token version: bumped on password change and "sign out everywhere" so
+-- access tokens issued before the bump stop validating
+ALTER TABLE users
+    ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,21 @@
+-- Announcement banners shown to every user while their window is active
+CREATE TABLE announcements (
+    id UUID PRIMARY KEY,
+    message TEXT NOT NULL,
+    severity VARCHAR(16) NOT NULL DEFAULT 'info'
+        CHECK (severity IN ('info', 'warning', 'critical')),
+    starts_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on the active window for listing current announcements
+CREATE INDEX idx_announcements_starts_at_ends_at ON announcements(starts_at, ends_at);
+
+-- Record which users dismissed which announcements
+CREATE TABLE announcement_dismissals (
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dismissed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (announcement_id, user_id)
+);
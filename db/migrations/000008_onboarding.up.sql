@@ -0,0 +1,7 @@
+-- Onboarding checklist: one row per step a user has completed
+CREATE TABLE onboarding_steps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    step VARCHAR(64) NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, step)
+);
@@ -0,0 +1,17 @@
+-- In-app notification inbox
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    todo_id UUID REFERENCES todos(id) ON DELETE SET NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on user_id and created_at for listing the inbox newest first
+CREATE INDEX idx_notifications_user_id_created_at_id ON notifications(user_id, created_at DESC, id DESC);
+
+-- Create partial index on unread notifications for unread counts
+CREATE INDEX idx_notifications_user_id_unread ON notifications(user_id) WHERE read_at IS NULL;
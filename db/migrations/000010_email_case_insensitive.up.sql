@@ -0,0 +1,8 @@
+-- Emails are stored lowercased and compared case-insensitively so
+-- "User@X.com" and "user@x.com" cannot become two accounts.
+-- Fails if existing rows differ only by case; merge those accounts first.
+UPDATE users
+    SET email = lower(btrim(email))
+    WHERE email <> lower(btrim(email));
+
+CREATE UNIQUE INDEX idx_users_email_lower ON users(lower(email));
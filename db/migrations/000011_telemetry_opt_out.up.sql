@@ -0,0 +1,3 @@
+-- Per-user opt-out from anonymized product analytics
+ALTER TABLE users
+    ADD COLUMN telemetry_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,6 @@
+-- Accounts whose owner asked for deletion stay pending_deletion, and can be
+-- restored by signing in, until purge_after; then the scheduler deletes them
+ALTER TABLE users
+    ADD COLUMN purge_after TIMESTAMP;
+
+CREATE INDEX idx_users_purge_after ON users(purge_after) WHERE purge_after IS NOT NULL;
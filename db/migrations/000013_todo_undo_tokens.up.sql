@@ -0,0 +1,12 @@
+-- Undo tokens for bulk completions: the todos one bulk completion changed,
+-- which can be reopened until expires_at unless they were changed again
+CREATE TABLE todo_undo_tokens (
+    token UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_ids UUID[] NOT NULL,
+    completed_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+-- Create index on user_id and expires_at for dropping a user's expired tokens
+CREATE INDEX idx_todo_undo_tokens_user_id_expires_at ON todo_undo_tokens(user_id, expires_at);
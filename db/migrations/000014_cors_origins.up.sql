@@ -0,0 +1,6 @@
+-- Origins allowed to call the API from browsers in addition to
+-- CORS_ALLOWED_ORIGINS, such as sites embedding a widget
+CREATE TABLE cors_origins (
+    origin VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS record_todos_changes ON todos;
+DROP FUNCTION IF EXISTS record_todo_change();
+DROP TABLE IF EXISTS todo_changes;
+DROP TABLE IF EXISTS user_change_seqs;
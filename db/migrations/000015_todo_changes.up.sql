@@ -0,0 +1,59 @@
+-- Last change sequence number handed out per user
+CREATE TABLE user_change_seqs (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+-- Change feed: the latest change of each todo, numbered per user in commit order
+CREATE TABLE todo_changes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    todo_id UUID NOT NULL,
+    op VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, seq)
+);
+
+-- Create index on user_id and todo_id for replacing a todo's earlier change
+CREATE INDEX idx_todo_changes_user_id_todo_id ON todo_changes(user_id, todo_id);
+
+-- Function to record every todo mutation in the change feed. The sequence row
+-- stays locked until the transaction commits, so a user's changes become
+-- visible in sequence order and readers never skip one.
+CREATE OR REPLACE FUNCTION record_todo_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    change_user_id UUID;
+    change_todo_id UUID;
+    change_op VARCHAR(10);
+    next_seq BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        change_user_id := OLD.user_id;
+        change_todo_id := OLD.id;
+        change_op := 'delete';
+        -- The todos of a deleted user go with it
+        IF NOT EXISTS (SELECT 1 FROM users WHERE id = change_user_id) THEN
+            RETURN NULL;
+        END IF;
+    ELSE
+        change_user_id := NEW.user_id;
+        change_todo_id := NEW.id;
+        change_op := 'upsert';
+    END IF;
+
+    INSERT INTO user_change_seqs (user_id, last_seq) VALUES (change_user_id, 1)
+    ON CONFLICT (user_id) DO UPDATE SET last_seq = user_change_seqs.last_seq + 1
+    RETURNING last_seq INTO next_seq;
+
+    DELETE FROM todo_changes WHERE user_id = change_user_id AND todo_id = change_todo_id;
+    INSERT INTO todo_changes (user_id, seq, todo_id, op)
+    VALUES (change_user_id, next_seq, change_todo_id, change_op);
+
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+-- Trigger to record changes on todos table
+CREATE TRIGGER record_todos_changes AFTER INSERT OR UPDATE OR DELETE ON todos
+    FOR EACH ROW EXECUTE FUNCTION record_todo_change();
@@ -0,0 +1,4 @@
+-- Plaintext descriptions are at most 2000 characters, like titles are bounded
+-- by their column type
+ALTER TABLE todos
+    ADD CONSTRAINT todos_description_length CHECK (char_length(description) <= 2000);
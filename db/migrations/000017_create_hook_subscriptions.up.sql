@@ -0,0 +1,13 @@
+-- REST hook subscriptions: URLs a user's todo changes are posted to
+CREATE TABLE hook_subscriptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    target_url TEXT NOT NULL,
+    last_seq BIGINT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on user_id for listing a user's subscriptions
+CREATE INDEX idx_hook_subscriptions_user_id ON hook_subscriptions(user_id);
@@ -0,0 +1,14 @@
+-- Audit log of tool calls made by agents on behalf of a user
+CREATE TABLE agent_actions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tool VARCHAR(100) NOT NULL,
+    arguments JSONB,
+    outcome VARCHAR(10) NOT NULL,
+    error_code VARCHAR(50),
+    todo_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on user_id and created_at for listing a user's actions, newest first
+CREATE INDEX idx_agent_actions_user_id_created_at_id ON agent_actions(user_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE agent_actions
+    DROP COLUMN IF EXISTS client_ip;
@@ -0,0 +1,3 @@
+-- Client IP address of each agent tool call
+ALTER TABLE agent_actions
+    ADD COLUMN client_ip VARCHAR(45);
@@ -0,0 +1,9 @@
+ALTER TABLE hook_subscriptions
+    ADD COLUMN event VARCHAR(50);
+
+UPDATE hook_subscriptions SET event = events[1];
+
+ALTER TABLE hook_subscriptions
+    ALTER COLUMN event SET NOT NULL,
+    DROP COLUMN IF EXISTS events,
+    DROP COLUMN IF EXISTS payload_version;
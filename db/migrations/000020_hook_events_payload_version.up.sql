@@ -0,0 +1,11 @@
+-- Hook subscriptions select any number of events, and keep the payload
+-- version they were created with; existing ones receive version 1
+ALTER TABLE hook_subscriptions
+    ADD COLUMN events TEXT[],
+    ADD COLUMN payload_version INTEGER NOT NULL DEFAULT 1;
+
+UPDATE hook_subscriptions SET events = ARRAY[event];
+
+ALTER TABLE hook_subscriptions
+    ALTER COLUMN events SET NOT NULL,
+    DROP COLUMN event;
@@ -0,0 +1,22 @@
+-- Delivery attempts of REST hooks, kept so users can inspect and replay them
+CREATE TABLE hook_deliveries (
+    id UUID PRIMARY KEY,
+    hook_id UUID NOT NULL REFERENCES hook_subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    seq BIGINT NOT NULL,
+    payload_version INTEGER NOT NULL,
+    request_body JSONB NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    succeeded BOOLEAN NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    redelivery BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create index on hook_id and created_at for listing a hook's deliveries, newest first
+CREATE INDEX idx_hook_deliveries_hook_id_created_at_id ON hook_deliveries(hook_id, created_at DESC, id DESC);
+
+-- Create index on created_at for pruning old deliveries
+CREATE INDEX idx_hook_deliveries_created_at ON hook_deliveries(created_at);
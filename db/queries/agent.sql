@@ -0,0 +1,19 @@
+-- name: CreateAgentAction :one
+INSERT INTO agent_actions (
+    id,
+    user_id,
+    tool,
+    arguments,
+    outcome,
+    error_code,
+    todo_id,
+    client_ip
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListAgentActionsByUserID :many
+SELECT * FROM agent_actions
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
@@ -0,0 +1,37 @@
+-- name: CreateAnnouncement :one
+INSERT INTO announcements (
+    id,
+    message,
+    severity,
+    starts_at,
+    ends_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetAnnouncementByID :one
+SELECT * FROM announcements
+WHERE id = $1 LIMIT 1;
+
+-- name: ListAnnouncements :many
+SELECT * FROM announcements
+ORDER BY starts_at DESC, id DESC;
+
+-- name: ListActiveAnnouncementsForUser :many
+SELECT a.* FROM announcements a
+WHERE a.starts_at <= NOW()
+  AND (a.ends_at IS NULL OR a.ends_at > NOW())
+  AND NOT EXISTS (
+      SELECT 1 FROM announcement_dismissals d
+      WHERE d.announcement_id = a.id AND d.user_id = $1
+  )
+ORDER BY a.starts_at DESC, a.id DESC;
+
+-- name: DeleteAnnouncement :exec
+DELETE FROM announcements
+WHERE id = $1;
+
+-- name: DismissAnnouncement :exec
+INSERT INTO announcement_dismissals (announcement_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (announcement_id, user_id) DO NOTHING;
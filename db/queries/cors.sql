@@ -0,0 +1,13 @@
+-- name: ListCORSOrigins :many
+SELECT * FROM cors_origins
+ORDER BY origin;
+
+-- name: AddCORSOrigin :one
+INSERT INTO cors_origins (origin)
+VALUES ($1)
+ON CONFLICT (origin) DO UPDATE SET origin = EXCLUDED.origin
+RETURNING *;
+
+-- name: DeleteCORSOrigin :execrows
+DELETE FROM cors_origins
+WHERE origin = $1;
@@ -0,0 +1,77 @@
+-- name: CreateHookSubscription :one
+INSERT INTO hook_subscriptions (
+    id,
+    user_id,
+    events,
+    payload_version,
+    target_url,
+    last_seq
+) VALUES (
+    $1, $2, $3, $4, $5,
+    COALESCE((SELECT last_seq FROM user_change_seqs WHERE user_id = $2), 0)
+) RETURNING *;
+
+-- name: CountHookSubscriptionsByUserID :one
+SELECT COUNT(*) FROM hook_subscriptions
+WHERE user_id = $1;
+
+-- name: ListHookSubscriptionsByUserID :many
+SELECT * FROM hook_subscriptions
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: ListHookSubscriptions :many
+SELECT * FROM hook_subscriptions
+ORDER BY created_at, id;
+
+-- name: DeleteHookSubscription :execrows
+DELETE FROM hook_subscriptions
+WHERE id = $1 AND user_id = $2;
+
+-- name: AdvanceHookSubscription :exec
+UPDATE hook_subscriptions
+SET last_seq = GREATEST(last_seq, $2), failures = 0
+WHERE id = $1;
+
+-- name: RecordHookSubscriptionFailure :one
+UPDATE hook_subscriptions
+SET failures = failures + 1
+WHERE id = $1
+RETURNING failures;
+
+-- name: GetHookSubscription :one
+SELECT * FROM hook_subscriptions
+WHERE id = $1 AND user_id = $2;
+
+-- name: CreateHookDelivery :one
+INSERT INTO hook_deliveries (
+    id,
+    hook_id,
+    user_id,
+    event,
+    seq,
+    payload_version,
+    request_body,
+    status_code,
+    error,
+    succeeded,
+    duration_ms,
+    redelivery
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING *;
+
+-- name: GetHookDelivery :one
+SELECT * FROM hook_deliveries
+WHERE id = $1 AND hook_id = $2 AND user_id = $3;
+
+-- name: ListHookDeliveries :many
+SELECT * FROM hook_deliveries
+WHERE hook_id = sqlc.arg('hook_id') AND user_id = sqlc.arg('user_id')
+  AND (NOT sqlc.arg('failed_only')::boolean OR NOT succeeded)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: PruneHookDeliveries :execrows
+DELETE FROM hook_deliveries
+WHERE created_at < $1;
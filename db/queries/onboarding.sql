@@ -0,0 +1,12 @@
+-- name: ListOnboardingSteps :many
+SELECT * FROM onboarding_steps
+WHERE user_id = $1;
+
+-- name: CompleteOnboardingStep :exec
+INSERT INTO onboarding_steps (user_id, step)
+VALUES ($1, $2)
+ON CONFLICT (user_id, step) DO NOTHING;
+
+-- name: ResetOnboardingStep :exec
+DELETE FROM onboarding_steps
+WHERE user_id = $1 AND step = $2;
@@ -0,0 +1,28 @@
+-- name: UsageReport :many
+SELECT 'new_users' AS metric, date_trunc($1::text, created_at) AS period_start, count(*) AS total
+FROM users
+WHERE created_at >= $2 AND created_at < $3
+GROUP BY 2
+UNION ALL
+SELECT 'active_users', date_trunc($1::text, changed_at), count(DISTINCT user_id)
+FROM (
+    SELECT user_id, updated_at AS changed_at FROM todos WHERE updated_at >= $2 AND updated_at < $3
+    UNION ALL
+    SELECT user_id, deleted_at FROM todo_tombstones WHERE deleted_at >= $2 AND deleted_at < $3
+) AS activity
+GROUP BY 2
+UNION ALL
+SELECT 'todos_created', date_trunc($1::text, created_at), count(*)
+FROM todos
+WHERE created_at >= $2 AND created_at < $3
+GROUP BY 2
+UNION ALL
+SELECT 'todos_completed', date_trunc($1::text, updated_at), count(*)
+FROM todos
+WHERE completed AND updated_at >= $2 AND updated_at < $3
+GROUP BY 2
+UNION ALL
+SELECT 'churned_users', date_trunc($1::text, updated_at), count(*)
+FROM users
+WHERE status = 'pending_deletion' AND updated_at >= $2 AND updated_at < $3
+GROUP BY 2;
@@ -1,12 +1,18 @@
 -- name: CreateTodo :one
+WITH cleared AS (
+    DELETE FROM todo_tombstones WHERE todo_tombstones.id = $1 AND todo_tombstones.user_id = $2
+)
 INSERT INTO todos (
     id,
     user_id,
     title,
     description,
-    completed
+    completed,
+    encrypted,
+    title_ciphertext,
+    description_ciphertext
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetTodoByID :one
@@ -18,6 +24,19 @@ SELECT * FROM todos
 WHERE user_id = $1
 ORDER BY created_at DESC;
 
+-- name: ListTodosByUserIDFirstPage :many
+SELECT * FROM todos
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: ListTodosByUserIDAfterCursor :many
+SELECT * FROM todos
+WHERE user_id = sqlc.arg('user_id')
+  AND (created_at, id) < (sqlc.arg('cursor_created_at')::timestamp, sqlc.arg('cursor_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
 -- name: ListTodosByUserIDAndStatus :many
 SELECT * FROM todos
 WHERE user_id = $1 AND completed = $2
@@ -27,15 +46,23 @@ ORDER BY created_at DESC;
 UPDATE todos
 SET
     title = COALESCE(sqlc.narg('title'), title),
-    description = COALESCE(sqlc.narg('description'), description),
+    description = sqlc.narg('description'),
     completed = COALESCE(sqlc.narg('completed'), completed),
+    encrypted = COALESCE(sqlc.narg('encrypted'), encrypted),
+    title_ciphertext = sqlc.narg('title_ciphertext'),
+    description_ciphertext = sqlc.narg('description_ciphertext'),
     updated_at = NOW()
 WHERE id = sqlc.arg('id')
 RETURNING *;
 
 -- name: DeleteTodo :exec
-DELETE FROM todos
-WHERE id = $1;
+WITH deleted AS (
+    DELETE FROM todos WHERE todos.id = $1
+    RETURNING id, user_id
+)
+INSERT INTO todo_tombstones (id, user_id)
+SELECT id, user_id FROM deleted
+ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, deleted_at = NOW();
 
 -- name: CountTodosByUserID :one
 SELECT COUNT(*) FROM todos
@@ -44,3 +71,93 @@ WHERE user_id = $1;
 -- name: CountCompletedTodosByUserID :one
 SELECT COUNT(*) FROM todos
 WHERE user_id = $1 AND completed = true;
+
+-- name: CompleteTodosByFilter :one
+WITH completed AS (
+    UPDATE todos
+    SET completed = TRUE, updated_at = NOW()
+    WHERE user_id = sqlc.arg('user_id')
+      AND completed = FALSE
+      AND (sqlc.narg('ids')::uuid[] IS NULL OR id = ANY(sqlc.narg('ids')::uuid[]))
+      AND (sqlc.narg('created_after')::timestamp IS NULL OR created_at >= sqlc.narg('created_after')::timestamp)
+      AND (sqlc.narg('created_before')::timestamp IS NULL OR created_at < sqlc.narg('created_before')::timestamp)
+      AND (sqlc.narg('updated_before')::timestamp IS NULL OR updated_at < sqlc.narg('updated_before')::timestamp)
+    RETURNING id
+), expired AS (
+    DELETE FROM todo_undo_tokens
+    WHERE user_id = sqlc.arg('user_id') AND expires_at <= NOW()
+), undo AS (
+    INSERT INTO todo_undo_tokens (token, user_id, todo_ids, completed_at, expires_at)
+    SELECT sqlc.arg('token')::uuid, sqlc.arg('user_id')::uuid, array_agg(id), NOW(), sqlc.arg('expires_at')::timestamp
+    FROM completed
+    HAVING COUNT(*) > 0
+)
+SELECT COUNT(*) FROM completed;
+
+-- name: UndoCompleteTodos :one
+WITH consumed AS (
+    DELETE FROM todo_undo_tokens
+    WHERE token = $1 AND user_id = $2 AND expires_at > NOW()
+    RETURNING todo_ids, completed_at
+), reopened AS (
+    UPDATE todos
+    SET completed = FALSE, updated_at = NOW()
+    FROM consumed
+    WHERE todos.user_id = $2
+      AND todos.id = ANY(consumed.todo_ids)
+      AND todos.completed = TRUE
+      AND todos.updated_at = consumed.completed_at
+    RETURNING todos.id
+)
+SELECT
+    (SELECT COUNT(*) FROM consumed) AS found,
+    (SELECT COUNT(*) FROM reopened) AS reopened;
+
+-- name: LockUserChangeSeq :one
+INSERT INTO user_change_seqs (user_id, last_seq) VALUES ($1, 0)
+ON CONFLICT (user_id) DO UPDATE SET last_seq = user_change_seqs.last_seq
+RETURNING last_seq;
+
+-- name: GetUserChangeSeq :one
+SELECT COALESCE((SELECT last_seq FROM user_change_seqs WHERE user_id = $1), 0)::BIGINT;
+
+-- name: ListTodoChangesSince :many
+SELECT
+    todo_changes.seq,
+    todo_changes.todo_id,
+    todo_changes.op,
+    todo_changes.changed_at,
+    todos.title,
+    todos.description,
+    todos.completed,
+    todos.encrypted,
+    todos.title_ciphertext,
+    todos.description_ciphertext,
+    todos.created_at,
+    todos.updated_at
+FROM todo_changes
+LEFT JOIN todos ON todos.id = todo_changes.todo_id AND todo_changes.op = 'upsert'
+WHERE todo_changes.user_id = $1 AND todo_changes.seq > $2
+ORDER BY todo_changes.seq ASC
+LIMIT $3;
+
+-- name: ListTodoSummariesByUserID :many
+SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+FROM todos
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListTodoSummariesByUserIDFirstPage :many
+SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+FROM todos
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2;
+
+-- name: ListTodoSummariesByUserIDAfterCursor :many
+SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+FROM todos
+WHERE user_id = sqlc.arg('user_id')
+  AND (created_at, id) < (sqlc.arg('cursor_created_at')::timestamp, sqlc.arg('cursor_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
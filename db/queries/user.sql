@@ -8,9 +8,12 @@ INSERT INTO users (
     $1, $2, $3, $4
 ) RETURNING *;
 
+-- name: LockUserEmail :exec
+SELECT pg_advisory_xact_lock(hashtext($1));
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
-WHERE email = $1 LIMIT 1;
+WHERE lower(email) = lower($1) LIMIT 1;
 
 -- name: GetUserByID :one
 SELECT * FROM users
@@ -25,6 +28,78 @@ SET
 WHERE id = sqlc.arg('id')
 RETURNING *;
 
+-- name: SetUserE2EEnabled :one
+UPDATE users
+SET
+    e2e_enabled = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserTelemetryOptOut :one
+UPDATE users
+SET
+    telemetry_opt_out = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserStatus :one
+UPDATE users
+SET
+    status = $2,
+    status_reason = $3,
+    purge_after = NULL,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+    password_hash = $2,
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET password_hash = $3
+WHERE id = $1 AND password_hash = $2;
+
+-- name: IncrementUserTokenVersion :one
+UPDATE users
+SET
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RequestUserDeletion :one
+UPDATE users
+SET
+    status = 'pending_deletion',
+    status_reason = NULL,
+    purge_after = $2,
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RestoreUser :one
+UPDATE users
+SET
+    status = 'active',
+    purge_after = NULL,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'pending_deletion' AND purge_after IS NOT NULL
+RETURNING *;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE status = 'pending_deletion' AND purge_after <= $1;
+
 -- name: DeleteUser :exec
 DELETE FROM users
 WHERE id = $1;
@@ -1,11 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/netip"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
 	"github.com/joho/godotenv"
+	"github.com/whauzan/todo-api/internal/domain"
 )
 
 // Config holds all configuration for the application
@@ -15,27 +21,168 @@ type Config struct {
 	Env  string `env:"ENV" envDefault:"development"`
 
 	// Database configuration
-	DatabaseURL string `env:"DATABASE_URL,required"`
+	DatabaseURL string `env:"DATABASE_URL"`
 
 	// JWT configuration
-	JWTSecret      string `env:"JWT_SECRET,required"`
+	JWTSecret      string `env:"JWT_SECRET"`
 	JWTExpiryHours int    `env:"JWT_EXPIRY_HOURS" envDefault:"72"`
 
-	// CORS configuration
-	CORSAllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," envDefault:"http://localhost:3000"`
+	// Password peppers as version:secret pairs. New hashes use the pepper of
+	// PASSWORD_PEPPER_VERSION (0 for none); older hashes are rehashed at sign-in,
+	// so a version can be dropped once no hash uses it.
+	PasswordPeppers       map[int]string `env:"PASSWORD_PEPPERS" envSeparator:"," envKeyValSeparator:":"`
+	PasswordPepperVersion int            `env:"PASSWORD_PEPPER_VERSION" envDefault:"0"`
+
+	// How long /health and /ready reuse the last dependency check results
+	HealthCacheTTL time.Duration `env:"HEALTH_CACHE_TTL" envDefault:"2s"`
+
+	// Graceful shutdown: how long readiness fails before the server stops
+	// accepting connections, and how long in-flight requests get to finish
+	ShutdownDrainDelay time.Duration `env:"SHUTDOWN_DRAIN_DELAY" envDefault:"0s"`
+	ShutdownTimeout    time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+
+	// ID generation: 4 for random UUIDs, 7 for time-ordered UUIDs
+	UUIDVersion int `env:"UUID_VERSION" envDefault:"4"`
+
+	// Concurrency limits (0 disables the limit)
+	MaxConcurrentRequests        int `env:"MAX_CONCURRENT_REQUESTS" envDefault:"200"`
+	MaxConcurrentRequestsPerUser int `env:"MAX_CONCURRENT_REQUESTS_PER_USER" envDefault:"10"`
+	MaxLongPollsPerUser          int `env:"MAX_LONG_POLLS_PER_USER" envDefault:"5"`
+
+	// Per-user rate limit (0 disables the limit)
+	RateLimitRequests int           `env:"RATE_LIMIT_REQUESTS" envDefault:"600"`
+	RateLimitWindow   time.Duration `env:"RATE_LIMIT_WINDOW" envDefault:"1m"`
+
+	// Per-client-IP rate limit of the public login and register routes
+	// (0 disables the limit)
+	AuthRateLimitRequests int           `env:"AUTH_RATE_LIMIT_REQUESTS" envDefault:"20"`
+	AuthRateLimitWindow   time.Duration `env:"AUTH_RATE_LIMIT_WINDOW" envDefault:"1m"`
+
+	// Size limits of plaintext todo content, at most 255 and 2000 characters,
+	// and the length of description previews in todo lists
+	TodoMaxTitleLength       int `env:"TODO_MAX_TITLE_LENGTH" envDefault:"255"`
+	TodoMaxDescriptionLength int `env:"TODO_MAX_DESCRIPTION_LENGTH" envDefault:"2000"`
+	TodoPreviewLength        int `env:"TODO_PREVIEW_LENGTH" envDefault:"140"`
+
+	// Change long polls wait up to LONG_POLL_MAX_WAIT, looking for changes
+	// every LONG_POLL_INTERVAL
+	LongPollMaxWait  time.Duration `env:"LONG_POLL_MAX_WAIT" envDefault:"25s"`
+	LongPollInterval time.Duration `env:"LONG_POLL_INTERVAL" envDefault:"1s"`
+
+	// REST hooks receive new changes every HOOK_DISPATCH_INTERVAL (0 disables
+	// delivery) and are unsubscribed after HOOK_MAX_FAILURES failed attempts in a
+	// row. Delivery attempts are kept for HOOK_DELIVERY_RETENTION.
+	HookDispatchInterval  time.Duration `env:"HOOK_DISPATCH_INTERVAL" envDefault:"5s"`
+	HookMaxFailures       int           `env:"HOOK_MAX_FAILURES" envDefault:"50"`
+	HookDeliveryRetention time.Duration `env:"HOOK_DELIVERY_RETENTION" envDefault:"168h"`
+
+	// Tool-calling endpoints for LLM agents, and the tools they may call
+	AgentEnabled bool     `env:"AGENT_ENABLED" envDefault:"true"`
+	AgentTools   []string `env:"AGENT_TOOLS" envSeparator:"," envDefault:"list_todos,create_todo,complete_todo"`
+
+	// Automatic suspension after repeated abuse signals such as rate limit
+	// rejections (0 disables it)
+	AbuseSuspendThreshold int           `env:"ABUSE_SUSPEND_THRESHOLD" envDefault:"0"`
+	AbuseWindow           time.Duration `env:"ABUSE_WINDOW" envDefault:"10m"`
+
+	// Accounts whose owner asks for deletion can be restored by signing in until
+	// the grace period ends; they are purged every ACCOUNT_PURGE_INTERVAL
+	// (0 disables in-process purging, e.g. when the purge-accounts command runs from cron)
+	AccountDeletionGracePeriod time.Duration `env:"ACCOUNT_DELETION_GRACE_PERIOD" envDefault:"720h"`
+	AccountPurgeInterval       time.Duration `env:"ACCOUNT_PURGE_INTERVAL" envDefault:"1h"`
+
+	// Bot checks of sign-up and sign-in: a honeypot body field real clients
+	// leave empty, the least time since form_started_at (0 disables it), and
+	// captcha verification with turnstile or hcaptcha (empty disables it)
+	BotGuardEnabled  bool          `env:"BOT_GUARD_ENABLED" envDefault:"true"`
+	BotHoneypotField string        `env:"BOT_HONEYPOT_FIELD" envDefault:"website"`
+	BotMinFillTime   time.Duration `env:"BOT_MIN_FILL_TIME" envDefault:"0s"`
+	CaptchaProvider  string        `env:"CAPTCHA_PROVIDER"`
+	CaptchaSecret    string        `env:"CAPTCHA_SECRET"`
+
+	// Replay protection for sensitive endpoints: signed requests are always
+	// verified, and unsigned ones are rejected when signing is required
+	RequestSigningRequired  bool          `env:"REQUEST_SIGNING_REQUIRED" envDefault:"false"`
+	RequestSigningTolerance time.Duration `env:"REQUEST_SIGNING_TOLERANCE" envDefault:"5m"`
+
+	// Fault injection for resilience testing, refused in production. Rules are
+	// a JSON array and can be replaced at runtime through /api/v1/admin/chaos.
+	ChaosEnabled bool   `env:"CHAOS_ENABLED" envDefault:"false"`
+	ChaosRules   string `env:"CHAOS_RULES"`
+
+	// Embeddable widget: lifetime of origin-bound widget tokens, and the per-user
+	// rate limit of widget requests, kept apart from the API rate limit
+	WidgetTokenTTL          time.Duration `env:"WIDGET_TOKEN_TTL" envDefault:"2160h"`
+	WidgetRateLimitRequests int           `env:"WIDGET_RATE_LIMIT_REQUESTS" envDefault:"60"`
+	WidgetRateLimitWindow   time.Duration `env:"WIDGET_RATE_LIMIT_WINDOW" envDefault:"1m"`
+
+	// Bearer token for /api/v1/admin endpoints (empty disables them)
+	AdminToken string `env:"ADMIN_TOKEN"`
+
+	// Response cache for authenticated GET requests
+	ResponseCacheEnabled    bool          `env:"RESPONSE_CACHE_ENABLED" envDefault:"true"`
+	ResponseCacheTTL        time.Duration `env:"RESPONSE_CACHE_TTL" envDefault:"10s"`
+	ResponseCacheMaxEntries int           `env:"RESPONSE_CACHE_MAX_ENTRIES" envDefault:"10000"`
+
+	// Business metrics served at /metrics, with alerts logged when the
+	// login failure ratio in one minute exceeds the threshold
+	MetricsEnabled           bool    `env:"METRICS_ENABLED" envDefault:"true"`
+	AnomalyLoginFailureRatio float64 `env:"ANOMALY_LOGIN_FAILURE_RATIO" envDefault:"0.5"`
+	AnomalyMinSamples        int     `env:"ANOMALY_MIN_SAMPLES" envDefault:"20"`
+
+	// Anonymized product analytics: stdout, segment or posthog (empty disables
+	// them). Users can opt out individually.
+	AnalyticsSink          string        `env:"ANALYTICS_SINK"`
+	AnalyticsHost          string        `env:"ANALYTICS_HOST"`
+	AnalyticsAPIKey        string        `env:"ANALYTICS_API_KEY"`
+	AnalyticsFlushInterval time.Duration `env:"ANALYTICS_FLUSH_INTERVAL" envDefault:"10s"`
+
+	// Deletes and logouts in API v1 respond 204 No Content; false restores the
+	// 200 responses with a message body of older releases
+	APIV1NoContent bool `env:"API_V1_NO_CONTENT" envDefault:"true"`
+
+	// CORS configuration per route group. API routes allow CORSAllowedOrigins
+	// and the origins on the allowlist managed through /api/v1/admin/cors-origins.
+	// Auth and admin routes only allow CORSAuthAllowedOrigins (default:
+	// CORSAllowedOrigins). Public read-only routes allow CORSPublicAllowedOrigins
+	// without credentials.
+	CORSAllowedOrigins       []string      `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," envDefault:"http://localhost:3000"`
+	CORSAuthAllowedOrigins   []string      `env:"CORS_AUTH_ALLOWED_ORIGINS" envSeparator:","`
+	CORSPublicAllowedOrigins []string      `env:"CORS_PUBLIC_ALLOWED_ORIGINS" envSeparator:"," envDefault:"*"`
+	CORSAllowlistRefresh     time.Duration `env:"CORS_ALLOWLIST_REFRESH" envDefault:"30s"`
+
+	// Reverse proxies, as IP addresses or CIDR ranges, whose X-Forwarded-For
+	// and X-Real-IP headers are believed when resolving the client IP
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
 
 	// Logging
 	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
+
+	// Access log written to its own file, separate from the application log (empty disables it)
+	AccessLogFile       string        `env:"ACCESS_LOG_FILE"`
+	AccessLogFormat     string        `env:"ACCESS_LOG_FORMAT" envDefault:"json"`
+	AccessLogMaxSizeMB  int           `env:"ACCESS_LOG_MAX_SIZE_MB" envDefault:"100"`
+	AccessLogMaxAge     time.Duration `env:"ACCESS_LOG_MAX_AGE" envDefault:"24h"`
+	AccessLogMaxBackups int           `env:"ACCESS_LOG_MAX_BACKUPS" envDefault:"7"`
+
+	// Embedded web UI
+	WebUIEnabled bool `env:"WEB_UI_ENABLED" envDefault:"false"`
 }
 
-// Load loads the configuration from environment variables
+// Load loads the configuration from, in increasing precedence: struct defaults,
+// the embedded profile for ENV, the YAML or TOML file named by CONFIG_FILE, and environment variables
 func Load() (*Config, error) {
 	// Load .env file if it exists (ignore error if file doesn't exist)
 	_ = godotenv.Load()
 
+	environment, err := layeredEnvironment(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{}
 
-	if err := env.Parse(cfg); err != nil {
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: environment}); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
@@ -46,26 +193,215 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Validate validates the configuration
+// Validate validates the configuration and reports every invalid key at once
 func (c *Config) Validate() error {
+	var errs []error
+
 	if c.Port < 1 || c.Port > 65535 {
-		return fmt.Errorf("invalid port: %d", c.Port)
+		errs = append(errs, fmt.Errorf("invalid port: %d", c.Port))
 	}
 
 	if c.DatabaseURL == "" {
-		return fmt.Errorf("DATABASE_URL is required")
+		errs = append(errs, fmt.Errorf("DATABASE_URL is required"))
 	}
 
 	if c.JWTSecret == "" {
-		return fmt.Errorf("JWT_SECRET is required")
+		errs = append(errs, fmt.Errorf("JWT_SECRET is required"))
+	} else if len(c.JWTSecret) < 32 {
+		errs = append(errs, fmt.Errorf("JWT_SECRET must be at least 32 characters long"))
 	}
 
-	if len(c.JWTSecret) < 32 {
-		return fmt.Errorf("JWT_SECRET must be at least 32 characters long")
+	for version, secret := range c.PasswordPeppers {
+		if version < 1 {
+			errs = append(errs, fmt.Errorf("invalid PASSWORD_PEPPERS version: %d (must be at least 1)", version))
+		} else if len(secret) < 32 {
+			errs = append(errs, fmt.Errorf("PASSWORD_PEPPERS version %d must be at least 32 characters long", version))
+		}
+	}
+
+	if _, ok := c.PasswordPeppers[c.PasswordPepperVersion]; c.PasswordPepperVersion != 0 && !ok {
+		errs = append(errs, fmt.Errorf("PASSWORD_PEPPER_VERSION %d has no secret in PASSWORD_PEPPERS", c.PasswordPepperVersion))
 	}
 
 	if c.JWTExpiryHours < 1 {
-		return fmt.Errorf("JWT_EXPIRY_HOURS must be at least 1")
+		errs = append(errs, fmt.Errorf("JWT_EXPIRY_HOURS must be at least 1"))
+	}
+
+	if c.ShutdownDrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_DRAIN_DELAY must not be negative"))
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT must be positive"))
+	}
+
+	if c.ChaosEnabled && c.IsProduction() {
+		errs = append(errs, fmt.Errorf("CHAOS_ENABLED must not be set in production"))
+	}
+
+	if c.CORSAllowlistRefresh <= 0 {
+		errs = append(errs, fmt.Errorf("CORS_ALLOWLIST_REFRESH must be positive"))
+	}
+
+	if c.TodoMaxTitleLength < 1 || c.TodoMaxTitleLength > domain.MaxTitleLength {
+		errs = append(errs, fmt.Errorf("TODO_MAX_TITLE_LENGTH must be between 1 and %d", domain.MaxTitleLength))
+	}
+
+	if c.TodoMaxDescriptionLength < 1 || c.TodoMaxDescriptionLength > domain.MaxDescriptionLength {
+		errs = append(errs, fmt.Errorf("TODO_MAX_DESCRIPTION_LENGTH must be between 1 and %d", domain.MaxDescriptionLength))
+	}
+
+	if c.TodoPreviewLength < 1 {
+		errs = append(errs, fmt.Errorf("TODO_PREVIEW_LENGTH must be at least 1"))
+	}
+
+	if c.LongPollMaxWait < 0 {
+		errs = append(errs, fmt.Errorf("LONG_POLL_MAX_WAIT must not be negative"))
+	}
+
+	if c.LongPollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("LONG_POLL_INTERVAL must be positive"))
+	}
+
+	if c.HookDispatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("HOOK_DISPATCH_INTERVAL must not be negative"))
+	}
+
+	if c.HookMaxFailures < 1 {
+		errs = append(errs, fmt.Errorf("HOOK_MAX_FAILURES must be at least 1"))
+	}
+
+	if c.HookDeliveryRetention <= 0 {
+		errs = append(errs, fmt.Errorf("HOOK_DELIVERY_RETENTION must be positive"))
+	}
+
+	for _, tool := range c.AgentTools {
+		if !slices.Contains(domain.AgentToolNames, tool) {
+			errs = append(errs, fmt.Errorf("invalid AGENT_TOOLS entry: %q (must be one of %s)", tool, strings.Join(domain.AgentToolNames, ", ")))
+		}
+	}
+
+	if c.WidgetTokenTTL <= 0 {
+		errs = append(errs, fmt.Errorf("WIDGET_TOKEN_TTL must be positive"))
+	}
+
+	if c.HealthCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("HEALTH_CACHE_TTL must not be negative"))
+	}
+
+	if c.UUIDVersion != 4 && c.UUIDVersion != 7 {
+		errs = append(errs, fmt.Errorf("invalid UUID_VERSION: %d (must be 4 or 7)", c.UUIDVersion))
+	}
+
+	if c.MaxConcurrentRequests < 0 {
+		errs = append(errs, fmt.Errorf("MAX_CONCURRENT_REQUESTS must not be negative"))
+	}
+
+	if c.MaxConcurrentRequestsPerUser < 0 {
+		errs = append(errs, fmt.Errorf("MAX_CONCURRENT_REQUESTS_PER_USER must not be negative"))
+	}
+
+	if c.MaxLongPollsPerUser < 0 {
+		errs = append(errs, fmt.Errorf("MAX_LONG_POLLS_PER_USER must not be negative"))
+	}
+
+	if c.RateLimitRequests < 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_REQUESTS must not be negative"))
+	}
+
+	if c.RateLimitRequests > 0 && c.RateLimitWindow <= 0 {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_WINDOW must be positive"))
+	}
+
+	if c.AuthRateLimitRequests < 0 {
+		errs = append(errs, fmt.Errorf("AUTH_RATE_LIMIT_REQUESTS must not be negative"))
+	}
+
+	if c.AuthRateLimitRequests > 0 && c.AuthRateLimitWindow <= 0 {
+		errs = append(errs, fmt.Errorf("AUTH_RATE_LIMIT_WINDOW must be positive"))
+	}
+
+	if c.AbuseSuspendThreshold < 0 {
+		errs = append(errs, fmt.Errorf("ABUSE_SUSPEND_THRESHOLD must not be negative"))
+	}
+
+	if c.AbuseSuspendThreshold > 0 && c.AbuseWindow <= 0 {
+		errs = append(errs, fmt.Errorf("ABUSE_WINDOW must be positive"))
+	}
+
+	if c.BotMinFillTime < 0 {
+		errs = append(errs, fmt.Errorf("BOT_MIN_FILL_TIME must not be negative"))
+	}
+
+	switch c.CaptchaProvider {
+	case "":
+	case "turnstile", "hcaptcha":
+		if c.CaptchaSecret == "" {
+			errs = append(errs, fmt.Errorf("CAPTCHA_SECRET is required for CAPTCHA_PROVIDER=%s", c.CaptchaProvider))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid CAPTCHA_PROVIDER: %s (must be turnstile or hcaptcha)", c.CaptchaProvider))
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if _, err := parseTrustedProxy(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("invalid TRUSTED_PROXIES entry: %q (must be an IP address or CIDR range)", proxy))
+		}
+	}
+
+	if c.RequestSigningTolerance <= 0 {
+		errs = append(errs, fmt.Errorf("REQUEST_SIGNING_TOLERANCE must be positive"))
+	}
+
+	if c.AccountDeletionGracePeriod <= 0 {
+		errs = append(errs, fmt.Errorf("ACCOUNT_DELETION_GRACE_PERIOD must be positive"))
+	}
+
+	if c.AccountPurgeInterval < 0 {
+		errs = append(errs, fmt.Errorf("ACCOUNT_PURGE_INTERVAL must not be negative"))
+	}
+
+	if c.AdminToken != "" && len(c.AdminToken) < 32 {
+		errs = append(errs, fmt.Errorf("ADMIN_TOKEN must be at least 32 characters long"))
+	}
+
+	if c.ResponseCacheEnabled && c.ResponseCacheTTL <= 0 {
+		errs = append(errs, fmt.Errorf("RESPONSE_CACHE_TTL must be positive"))
+	}
+
+	if c.ResponseCacheEnabled && c.ResponseCacheMaxEntries < 1 {
+		errs = append(errs, fmt.Errorf("RESPONSE_CACHE_MAX_ENTRIES must be at least 1"))
+	}
+
+	if c.AnomalyLoginFailureRatio <= 0 || c.AnomalyLoginFailureRatio > 1 {
+		errs = append(errs, fmt.Errorf("ANOMALY_LOGIN_FAILURE_RATIO must be greater than 0 and at most 1"))
+	}
+
+	if c.AnomalyMinSamples < 1 {
+		errs = append(errs, fmt.Errorf("ANOMALY_MIN_SAMPLES must be at least 1"))
+	}
+
+	switch c.AnalyticsSink {
+	case "", "stdout":
+	case "segment", "posthog":
+		if c.AnalyticsAPIKey == "" {
+			errs = append(errs, fmt.Errorf("ANALYTICS_API_KEY is required for ANALYTICS_SINK=%s", c.AnalyticsSink))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid ANALYTICS_SINK: %s (must be stdout, segment, or posthog)", c.AnalyticsSink))
+	}
+
+	if c.AnalyticsSink != "" && c.AnalyticsFlushInterval <= 0 {
+		errs = append(errs, fmt.Errorf("ANALYTICS_FLUSH_INTERVAL must be positive"))
+	}
+
+	if c.AccessLogFile != "" {
+		if c.AccessLogFormat != "json" && c.AccessLogFormat != "common" {
+			errs = append(errs, fmt.Errorf("invalid ACCESS_LOG_FORMAT: %s (must be json or common)", c.AccessLogFormat))
+		}
+		if c.AccessLogMaxSizeMB < 0 || c.AccessLogMaxAge < 0 || c.AccessLogMaxBackups < 0 {
+			errs = append(errs, fmt.Errorf("ACCESS_LOG_MAX_SIZE_MB, ACCESS_LOG_MAX_AGE and ACCESS_LOG_MAX_BACKUPS must not be negative"))
+		}
 	}
 
 	validEnvs := map[string]bool{
@@ -74,7 +410,7 @@ func (c *Config) Validate() error {
 		"production":  true,
 	}
 	if !validEnvs[c.Env] {
-		return fmt.Errorf("invalid ENV: %s (must be development, staging, or production)", c.Env)
+		errs = append(errs, fmt.Errorf("invalid ENV: %s (must be development, staging, or production)", c.Env))
 	}
 
 	validLogLevels := map[string]bool{
@@ -85,11 +421,12 @@ func (c *Config) Validate() error {
 	}
 	logLevel := strings.ToLower(c.LogLevel)
 	if !validLogLevels[logLevel] {
-		return fmt.Errorf("invalid LOG_LEVEL: %s (must be debug, info, warn, or error)", c.LogLevel)
+		errs = append(errs, fmt.Errorf("invalid LOG_LEVEL: %s (must be debug, info, warn, or error)", c.LogLevel))
+	} else {
+		c.LogLevel = logLevel
 	}
-	c.LogLevel = logLevel
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // IsProduction returns true if the environment is production
@@ -101,3 +438,50 @@ func (c *Config) IsProduction() bool {
 func (c *Config) IsDevelopment() bool {
 	return c.Env == "development"
 }
+
+// CORSAuthOrigins returns the origins allowed on auth and admin routes
+func (c *Config) CORSAuthOrigins() []string {
+	if len(c.CORSAuthAllowedOrigins) > 0 {
+		return c.CORSAuthAllowedOrigins
+	}
+	return c.CORSAllowedOrigins
+}
+
+// TrustedProxyPrefixes returns the address ranges of the trusted proxies.
+// Invalid entries, which Validate reports, are skipped.
+func (c *Config) TrustedProxyPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, proxy := range c.TrustedProxies {
+		if prefix, err := parseTrustedProxy(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// parseTrustedProxy parses a TRUSTED_PROXIES entry, treating a single address
+// as a range of one
+func parseTrustedProxy(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// TodoLimits returns the configured size limits of todo content
+func (c *Config) TodoLimits() domain.TodoLimits {
+	return domain.TodoLimits{
+		MaxTitleLength:       c.TodoMaxTitleLength,
+		MaxDescriptionLength: c.TodoMaxDescriptionLength,
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed profiles/*.yaml
+var profiles embed.FS
+
+// defaultEnv is the environment used when ENV is not set in any layer
+const defaultEnv = "development"
+
+// layeredEnvironment merges configuration layers into a single set of
+// environment variables, from lowest to highest precedence:
+// the embedded profile for ENV, the optional config file, and the process environment.
+// Struct tag defaults apply to keys missing from every layer.
+func layeredEnvironment(configFile string) (map[string]string, error) {
+	processEnv := env.ToMap(os.Environ())
+
+	var fileValues map[string]string
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		fileValues, err = parseConfigFile(configFile, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configFile, err)
+		}
+	}
+
+	// The profile is chosen by the highest-precedence layer that sets ENV
+	envName := defaultEnv
+	if v, ok := fileValues["ENV"]; ok {
+		envName = v
+	}
+	if v, ok := processEnv["ENV"]; ok {
+		envName = v
+	}
+
+	merged, err := profileValues(envName)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fileValues {
+		merged[k] = v
+	}
+	for k, v := range processEnv {
+		merged[k] = v
+	}
+
+	return merged, nil
+}
+
+// profileValues returns the embedded defaults for an environment.
+// Unknown environments have no profile; Validate reports them.
+func profileValues(envName string) (map[string]string, error) {
+	name := "profiles/" + envName + ".yaml"
+	data, err := profiles.ReadFile(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read profile %s: %w", envName, err)
+	}
+
+	values, err := parseConfigFile(name, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", envName, err)
+	}
+	return values, nil
+}
+
+// parseConfigFile parses a config file into environment variables. Files
+// ending in .toml are TOML, anything else is YAML (or JSON).
+// Keys are upper-cased, nested maps are joined with "_" (database.url -> DATABASE_URL),
+// and lists are joined with "," to match the env separators.
+func parseConfigFile(name string, data []byte) (map[string]string, error) {
+	var doc map[string]interface{}
+	if strings.EqualFold(filepath.Ext(name), ".toml") {
+		var err error
+		if doc, err = parseTOML(data); err != nil {
+			return nil, err
+		}
+	} else if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", doc, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flatten writes the scalar leaves of a config document into values
+func flatten(prefix string, node map[string]interface{}, values map[string]string) error {
+	for key, value := range node {
+		name := strings.ToUpper(key)
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(name, v, values); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, nested := item.(map[string]interface{}); nested {
+					return fmt.Errorf("%s: lists may only contain scalar values", name)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+			values[name] = ""
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
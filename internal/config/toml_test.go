@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExampleConfigFilesAgree(t *testing.T) {
+	values := make([]map[string]string, 0, 2)
+	for _, name := range []string{"../../config.example.yaml", "../../config.example.toml"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		v, err := parseConfigFile(name, data)
+		if err != nil {
+			t.Fatalf("parseConfigFile(%s): %v", name, err)
+		}
+		values = append(values, v)
+	}
+	if !reflect.DeepEqual(values[0], values[1]) {
+		t.Fatalf("YAML example = %v\nTOML example = %v", values[0], values[1])
+	}
+}
+
+func TestParseTOMLConfigFile(t *testing.T) {
+	data := []byte(`
+# Top-level keys come before the first table
+port = 8_080 # trailing comment
+"log_level" = 'debug'
+rate_limit.requests = 100
+
+[database]
+url = "postgres://localhost/todo?sslmode=disable"
+max_conns = 10
+
+[cors]
+allowed_origins = [
+  "http://localhost:3000", # local UI
+  "http://localhost:8080",
+]
+
+[access.log]
+max_age = "24h"
+enabled = true
+ratio = 0.5
+inline = { a = 1, b = "two" }
+escaped = "tab\there é"
+since = 2025-12-22T11:00:00Z
+`)
+
+	got, err := parseConfigFile("config.toml", data)
+	if err != nil {
+		t.Fatalf("parseConfigFile: %v", err)
+	}
+	want := map[string]string{
+		"PORT":                 "8080",
+		"LOG_LEVEL":            "debug",
+		"RATE_LIMIT_REQUESTS":  "100",
+		"DATABASE_URL":         "postgres://localhost/todo?sslmode=disable",
+		"DATABASE_MAX_CONNS":   "10",
+		"CORS_ALLOWED_ORIGINS": "http://localhost:3000,http://localhost:8080",
+		"ACCESS_LOG_MAX_AGE":   "24h",
+		"ACCESS_LOG_ENABLED":   "true",
+		"ACCESS_LOG_RATIO":     "0.5",
+		"ACCESS_LOG_INLINE_A":  "1",
+		"ACCESS_LOG_INLINE_B":  "two",
+		"ACCESS_LOG_ESCAPED":   "tab\there é",
+		"ACCESS_LOG_SINCE":     "2025-12-22T11:00:00Z",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseConfigFile =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := map[string]string{
+		"unquoted string":      "log_level = debug",
+		"duplicate key":        "port = 1\nport = 2",
+		"duplicate table":      "[database]\nurl = 'a'\n[database]\nmax_conns = 1",
+		"key under a value":    "port = 1\nport.number = 2",
+		"array of tables":      "[[hooks]]\nurl = 'a'",
+		"multi-line string":    "secret = \"\"\"\nabc\"\"\"",
+		"unterminated string":  "secret = \"abc",
+		"unterminated array":   "origins = [\"a\"",
+		"missing equals":       "port 8080",
+		"two values on a line": "port = 1 2",
+		"leading zero":         "port = 08080",
+		"invalid escape":       `secret = "\q"`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if values, err := parseConfigFile("config.toml", []byte(data)); err == nil {
+				t.Fatalf("parseConfigFile(%q) = %v, want an error", data, values)
+			}
+		})
+	}
+}
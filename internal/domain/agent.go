@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tools an agent can call. AgentToolNames lists every one of them.
+const (
+	// AgentToolListTodos lists a page of the user's todos
+	AgentToolListTodos = "list_todos"
+	// AgentToolCreateTodo creates a todo
+	AgentToolCreateTodo = "create_todo"
+	// AgentToolCompleteTodo marks a todo as completed
+	AgentToolCompleteTodo = "complete_todo"
+)
+
+// AgentToolNames are the names of every agent tool
+var AgentToolNames = []string{AgentToolListTodos, AgentToolCreateTodo, AgentToolCompleteTodo}
+
+// AgentTool describes a tool an agent can call, in the shape tool-calling
+// models and MCP servers expect. InputSchema is a JSON Schema of the arguments.
+type AgentTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Scope       string          `json:"scope"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// ListTodosToolInput holds the arguments of the list_todos tool
+type ListTodosToolInput struct {
+	Limit  *int   `json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `json:"cursor"`
+}
+
+// ListTodosToolOutput is the result of the list_todos tool
+type ListTodosToolOutput struct {
+	Todos      []*Todo `json:"todos"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// CreateTodoToolInput holds the arguments of the create_todo tool
+type CreateTodoToolInput struct {
+	Title       string  `json:"title" validate:"required,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=2000"`
+}
+
+// CompleteTodoToolInput holds the arguments of the complete_todo tool
+type CompleteTodoToolInput struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+}
+
+// Outcomes of an agent action
+const (
+	AgentOutcomeOK    = "ok"
+	AgentOutcomeError = "error"
+)
+
+// AgentAction is an entry of the audit log of tool calls made by agents on
+// behalf of a user. Arguments is nil when they were not valid JSON.
+type AgentAction struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"-"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+	Outcome   string          `json:"outcome"`
+	ErrorCode *string         `json:"error_code"`
+	TodoID    *uuid.UUID      `json:"todo_id"`
+	ClientIP  *string         `json:"client_ip"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnnouncementSeverity controls how prominently clients render an announcement
+type AnnouncementSeverity string
+
+const (
+	// AnnouncementSeverityInfo is a routine notice
+	AnnouncementSeverityInfo AnnouncementSeverity = "info"
+	// AnnouncementSeverityWarning is a notice users should act on, such as planned maintenance
+	AnnouncementSeverityWarning AnnouncementSeverity = "warning"
+	// AnnouncementSeverityCritical is an ongoing incident
+	AnnouncementSeverityCritical AnnouncementSeverity = "critical"
+)
+
+// Announcement is a banner message shown to every user between StartsAt and EndsAt.
+// A nil EndsAt keeps the announcement active until it is deleted.
+type Announcement struct {
+	ID        uuid.UUID            `json:"id"`
+	Message   string               `json:"message"`
+	Severity  AnnouncementSeverity `json:"severity"`
+	StartsAt  time.Time            `json:"starts_at"`
+	EndsAt    *time.Time           `json:"ends_at"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+// CreateAnnouncementRequest represents the request to create an announcement.
+// Severity defaults to info and StartsAt to now.
+type CreateAnnouncementRequest struct {
+	Message  string               `json:"message" validate:"required,max=1000"`
+	Severity AnnouncementSeverity `json:"severity" validate:"omitempty,oneof=info warning critical"`
+	StartsAt *time.Time           `json:"starts_at"`
+	EndsAt   *time.Time           `json:"ends_at"`
+}
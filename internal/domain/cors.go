@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// CORSOrigin is an origin allowed to call the API from browsers in addition
+// to the configured ones, such as a site embedding a widget
+type CORSOrigin struct {
+	Origin    string    `json:"origin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddCORSOriginRequest represents the request to allow an origin
+type AddCORSOriginRequest struct {
+	Origin string `json:"origin" validate:"required,max=255"`
+}
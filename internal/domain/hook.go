@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HookEvent names the changes a REST hook subscription is notified about
+type HookEvent string
+
+const (
+	// HookEventTodoChanged fires when a todo is created or updated
+	HookEventTodoChanged HookEvent = "todo.changed"
+	// HookEventTodoDeleted fires when a todo is deleted
+	HookEventTodoDeleted HookEvent = "todo.deleted"
+)
+
+// MaxHookSubscriptions is the most REST hook subscriptions a user may have
+const MaxHookSubscriptions = 20
+
+// Payload versions of hook deliveries. A subscription keeps the version it
+// was created with, so the deliveries an integration parses never change shape.
+const (
+	// HookPayloadV1 is the flat delivery of the first release, and the
+	// version of subscriptions that do not pick one
+	HookPayloadV1 = 1
+	// HookPayloadV2 names the event type and nests the todo under data
+	HookPayloadV2 = 2
+)
+
+// HookEventFor returns the event a change feed entry is delivered as
+func HookEventFor(op SyncOp) HookEvent {
+	if op == SyncOpDelete {
+		return HookEventTodoDeleted
+	}
+	return HookEventTodoChanged
+}
+
+// HookSubscription is a REST hook: a URL the API posts a user's todo changes
+// to, as integrations such as Zapier subscribe them
+type HookSubscription struct {
+	ID             uuid.UUID   `json:"id"`
+	UserID         uuid.UUID   `json:"-"`
+	Events         []HookEvent `json:"events"`
+	PayloadVersion int         `json:"payload_version"`
+	TargetURL      string      `json:"target_url"`
+	LastSeq        int64       `json:"-"` // Change feed position delivered up to
+	Failures       int         `json:"-"` // Consecutive failed deliveries
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Subscribes reports whether the subscription is notified about event
+func (s *HookSubscription) Subscribes(event HookEvent) bool {
+	return slices.Contains(s.Events, event)
+}
+
+// CreateHookRequest represents the request to subscribe a REST hook. Event
+// subscribes a single event, as in the first release; Events selects several.
+type CreateHookRequest struct {
+	Event          HookEvent   `json:"event" validate:"omitempty,oneof=todo.changed todo.deleted"`
+	Events         []HookEvent `json:"events" validate:"omitempty,unique,dive,oneof=todo.changed todo.deleted"`
+	PayloadVersion int         `json:"payload_version" validate:"omitempty,oneof=1 2"`
+	TargetURL      string      `json:"target_url" validate:"required,max=2048"`
+}
+
+// SubscribedEvents returns the events of Event and Events without duplicates
+func (r *CreateHookRequest) SubscribedEvents() []HookEvent {
+	events := slices.Clone(r.Events)
+	if r.Event != "" && !slices.Contains(events, r.Event) {
+		events = append([]HookEvent{r.Event}, events...)
+	}
+	return events
+}
+
+// HookDeliveryAttempt records one attempt to post a change to a REST hook.
+// Request is the body that was posted. StatusCode is nil when no response
+// arrived, and Error then says why.
+type HookDeliveryAttempt struct {
+	ID             uuid.UUID       `json:"id"`
+	HookID         uuid.UUID       `json:"hook_id"`
+	UserID         uuid.UUID       `json:"-"`
+	Event          HookEvent       `json:"event"`
+	Seq            int64           `json:"seq"`
+	PayloadVersion int             `json:"payload_version"`
+	Request        json.RawMessage `json:"request"`
+	StatusCode     *int            `json:"status_code"`
+	Error          *string         `json:"error"`
+	Succeeded      bool            `json:"succeeded"`
+	DurationMS     int64           `json:"duration_ms"`
+	Redelivery     bool            `json:"redelivery"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// HookDelivery is the body posted to a REST hook for each change in payload
+// version 1. Todo is the current version of the todo, for todo.changed.
+type HookDelivery struct {
+	Event      HookEvent `json:"event"`
+	Seq        int64     `json:"seq"`
+	TodoID     uuid.UUID `json:"todo_id"`
+	Todo       *Todo     `json:"todo,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// HookDeliveryV2 is the body posted to a REST hook for each change in payload
+// version 2
+type HookDeliveryV2 struct {
+	Type       HookEvent        `json:"type"`
+	Version    int              `json:"version"`
+	Seq        int64            `json:"seq"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       HookDeliveryData `json:"data"`
+}
+
+// HookDeliveryData is the todo a version 2 delivery is about
+type HookDeliveryData struct {
+	TodoID uuid.UUID `json:"todo_id"`
+	Todo   *Todo     `json:"todo,omitempty"`
+}
+
+// Payload returns the body of the delivery in the given payload version
+func (d *HookDelivery) Payload(version int) any {
+	if version == HookPayloadV2 {
+		return &HookDeliveryV2{
+			Type:       d.Event,
+			Version:    HookPayloadV2,
+			Seq:        d.Seq,
+			OccurredAt: d.OccurredAt,
+			Data:       HookDeliveryData{TodoID: d.TodoID, Todo: d.Todo},
+		}
+	}
+	return d
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationKind identifies what a notification is about
+type NotificationKind string
+
+const (
+	// NotificationKindShared tells a user that a todo was shared with them
+	NotificationKindShared NotificationKind = "shared"
+	// NotificationKindMentioned tells a user that they were mentioned on a todo
+	NotificationKindMentioned NotificationKind = "mentioned"
+	// NotificationKindDueSoon reminds a user of a todo that is due soon
+	NotificationKindDueSoon NotificationKind = "due_soon"
+)
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        uuid.UUID        `json:"id"`
+	UserID    uuid.UUID        `json:"-"`
+	Kind      NotificationKind `json:"kind"`
+	Title     string           `json:"title"`
+	Body      *string          `json:"body"`
+	TodoID    *uuid.UUID       `json:"todo_id"`
+	ReadAt    *time.Time       `json:"read_at"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+// NotificationInbox is a page of a user's notifications, newest first, with
+// the number of notifications they have not read yet
+type NotificationInbox struct {
+	Notifications []*Notification `json:"notifications"`
+	UnreadCount   int64           `json:"unread_count"`
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// OnboardingStep names a step of the onboarding checklist
+type OnboardingStep string
+
+const (
+	// OnboardingStepCreatedFirstTodo is completed automatically when the user creates a todo
+	OnboardingStepCreatedFirstTodo OnboardingStep = "created_first_todo"
+	// OnboardingStepSetTimezone is reported by the client once the user picks a timezone
+	OnboardingStepSetTimezone OnboardingStep = "set_timezone"
+	// OnboardingStepInstalledMobileApp is reported by the mobile app on first sign-in
+	OnboardingStepInstalledMobileApp OnboardingStep = "installed_mobile_app"
+)
+
+// OnboardingSteps lists the checklist steps in the order clients should render them
+var OnboardingSteps = []OnboardingStep{
+	OnboardingStepCreatedFirstTodo,
+	OnboardingStepSetTimezone,
+	OnboardingStepInstalledMobileApp,
+}
+
+// IsValid reports whether the step is part of the checklist
+func (s OnboardingStep) IsValid() bool {
+	for _, step := range OnboardingSteps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// OnboardingStepState is the completion state of one checklist step
+type OnboardingStepState struct {
+	Step        OnboardingStep `json:"step"`
+	Completed   bool           `json:"completed"`
+	CompletedAt *time.Time     `json:"completed_at"`
+}
+
+// Onboarding is a user's onboarding checklist progress
+type Onboarding struct {
+	Steps     []OnboardingStepState `json:"steps"`
+	Completed int                   `json:"completed"`
+	Total     int                   `json:"total"`
+}
+
+// NewOnboarding builds the checklist from the completion times of the completed steps
+func NewOnboarding(completedAt map[OnboardingStep]time.Time) *Onboarding {
+	onboarding := &Onboarding{
+		Steps: make([]OnboardingStepState, 0, len(OnboardingSteps)),
+		Total: len(OnboardingSteps),
+	}
+	for _, step := range OnboardingSteps {
+		state := OnboardingStepState{Step: step}
+		if at, ok := completedAt[step]; ok {
+			state.Completed = true
+			state.CompletedAt = &at
+			onboarding.Completed++
+		}
+		onboarding.Steps = append(onboarding.Steps, state)
+	}
+	return onboarding
+}
+
+// UpdateOnboardingRequest marks checklist steps as completed (true) or not completed (false)
+type UpdateOnboardingRequest struct {
+	Steps map[OnboardingStep]bool `json:"steps" validate:"required,min=1"`
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Optional is a JSON request field that distinguishes between being absent,
+// being explicitly null, and holding a value. This lets partial updates tell
+// "leave unchanged" (absent) apart from "clear" (null).
+type Optional[T any] struct {
+	// Set reports whether the field was present in the JSON document
+	Set bool
+	// Valid reports whether the field held a non-null value
+	Valid bool
+	// Value is the field value when Valid is true
+	Value T
+}
+
+// Some returns an Optional holding value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{Set: true, Valid: true, Value: value}
+}
+
+// Null returns an Optional that was explicitly set to null
+func Null[T any]() Optional[T] {
+	return Optional[T]{Set: true}
+}
+
+// IsNull reports whether the field was explicitly set to null
+func (o Optional[T]) IsNull() bool {
+	return o.Set && !o.Valid
+}
+
+// Ptr returns a pointer to the value, or nil if the field is absent or null
+func (o Optional[T]) Ptr() *T {
+	if !o.Valid {
+		return nil
+	}
+	v := o.Value
+	return &v
+}
+
+// ValidationValue returns a pointer to the value for struct validation, or nil
+// if the field is absent or null, so "omitempty" skips only missing values
+func (o Optional[T]) ValidationValue() interface{} {
+	if !o.Valid {
+		return nil
+	}
+	return o.Ptr()
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for fields
+// present in the document, which is how absent fields keep Set == false.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.Valid = false
+		var zero T
+		o.Value = zero
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &o.Value); err != nil {
+		return err
+	}
+	o.Valid = true
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import "time"
+
+// ReportPeriod is the length of the periods a report is broken down into
+type ReportPeriod string
+
+const (
+	// ReportPeriodDaily reports each UTC day
+	ReportPeriodDaily ReportPeriod = "daily"
+	// ReportPeriodWeekly reports each week, starting on Monday
+	ReportPeriodWeekly ReportPeriod = "weekly"
+)
+
+// Start returns the start of the period that contains t
+func (p ReportPeriod) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if p == ReportPeriodWeekly {
+		// Weeks start on Monday, as with date_trunc('week', ...)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+	return day
+}
+
+// Next returns the start of the period after the one starting at start
+func (p ReportPeriod) Next(start time.Time) time.Time {
+	if p == ReportPeriodWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// UsageReportRequest selects the periods of a usage report. From and To are
+// dates in YYYY-MM-DD format; the periods containing them are both included.
+// Period defaults to daily, To to today, and From to 30 periods before To.
+type UsageReportRequest struct {
+	Period ReportPeriod
+	From   string
+	To     string
+}
+
+// UsagePeriod holds the usage counts of one report period.
+// Users are active in a period if they created, changed or deleted a todo in it.
+// Completed todos are counted in the period they were last changed, and churned
+// users in the period they asked for their account to be deleted; accounts
+// already purged are no longer counted.
+type UsagePeriod struct {
+	Start          time.Time `json:"start"`
+	NewUsers       int64     `json:"new_users"`
+	ActiveUsers    int64     `json:"active_users"`
+	TodosCreated   int64     `json:"todos_created"`
+	TodosCompleted int64     `json:"todos_completed"`
+	ChurnedUsers   int64     `json:"churned_users"`
+}
+
+// UsageReport is a usage and growth report with one entry per period,
+// including periods without any activity
+type UsageReport struct {
+	Period  ReportPeriod   `json:"period"`
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Periods []*UsagePeriod `json:"periods"`
+}
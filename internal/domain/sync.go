@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyncPolicy decides how conflicting client and server changes are resolved
+type SyncPolicy string
+
+const (
+	// SyncPolicyServerWins keeps the server version and drops the client change
+	SyncPolicyServerWins SyncPolicy = "server_wins"
+	// SyncPolicyClientWins applies the client change over the server version
+	SyncPolicyClientWins SyncPolicy = "client_wins"
+	// SyncPolicyMerge applies the fields the client sent on top of the server version.
+	// A completion on either side is kept, and a server edit wins over a client delete.
+	SyncPolicyMerge SyncPolicy = "merge"
+)
+
+// SyncOp is the kind of change a client reports
+type SyncOp string
+
+const (
+	// SyncOpUpsert creates or updates a todo
+	SyncOpUpsert SyncOp = "upsert"
+	// SyncOpDelete deletes a todo
+	SyncOpDelete SyncOp = "delete"
+)
+
+// SyncRequest represents a delta-sync request from a client
+type SyncRequest struct {
+	SyncToken string       `json:"sync_token"`
+	Policy    SyncPolicy   `json:"policy" validate:"omitempty,oneof=server_wins client_wins merge"`
+	Changes   []SyncChange `json:"changes" validate:"max=500,dive"`
+}
+
+// SyncChange is a single local change made by the client since its last sync.
+// For upserts, nil fields are left unchanged; title or title_ciphertext is
+// required for new todos.
+type SyncChange struct {
+	ID          uuid.UUID `json:"id" validate:"required"`
+	Op          SyncOp    `json:"op" validate:"required,oneof=upsert delete"`
+	Title       *string   `json:"title" validate:"omitempty,min=1,max=255"`
+	Description *string   `json:"description" validate:"omitempty,max=2000"`
+	Completed   *bool     `json:"completed"`
+
+	TitleCiphertext       []byte `json:"title_ciphertext" validate:"omitempty,max=4096"`
+	DescriptionCiphertext []byte `json:"description_ciphertext" validate:"omitempty,max=16384"`
+}
+
+// Content returns the content the change sets
+func (c *SyncChange) Content() TodoContent {
+	var content TodoContent
+	if c.Title != nil {
+		content.Title = Some(*c.Title)
+	}
+	if c.Description != nil {
+		content.Description = Some(*c.Description)
+	}
+	if len(c.TitleCiphertext) > 0 {
+		content.TitleCiphertext = Some(c.TitleCiphertext)
+	}
+	if len(c.DescriptionCiphertext) > 0 {
+		content.DescriptionCiphertext = Some(c.DescriptionCiphertext)
+	}
+	return content
+}
+
+// SyncResponse is returned to the client after a sync
+type SyncResponse struct {
+	SyncToken string         `json:"sync_token"`
+	Changes   []*Todo        `json:"changes"`
+	Deleted   []uuid.UUID    `json:"deleted"`
+	Conflicts []SyncConflict `json:"conflicts"`
+}
+
+// ChangesResponse lists the server-side changes since a sync token, for
+// clients that only listen for changes
+type ChangesResponse struct {
+	SyncToken string      `json:"sync_token"`
+	Changes   []*Todo     `json:"changes"`
+	Deleted   []uuid.UUID `json:"deleted"`
+}
+
+// TodoChange is an entry of a user's change feed: the latest change of a
+// todo, numbered in the order the user's changes were committed
+type TodoChange struct {
+	Seq       int64     `json:"seq"`
+	Op        SyncOp    `json:"op"`
+	TodoID    uuid.UUID `json:"todo_id"`
+	Todo      *Todo     `json:"todo,omitempty"` // Current version, for upserts
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// ChangeFeed is a page of a user's change feed
+type ChangeFeed struct {
+	Changes []*TodoChange `json:"changes"`
+	NextSeq int64         `json:"next_seq"` // The since of the next page
+	HasMore bool          `json:"has_more"`
+}
+
+// SyncConflict describes a client change that collided with a server-side change
+type SyncConflict struct {
+	ID         uuid.UUID `json:"id"`
+	Reason     string    `json:"reason"`
+	Resolution string    `json:"resolution"`
+	Server     *Todo     `json:"server,omitempty"`
+}
+
+// syncTokenPrefix marks tokens holding a change feed sequence number.
+// Tokens without it came from the old updated_at-based format.
+const syncTokenPrefix = "seq:"
+
+// EncodeSyncToken returns the opaque sync token for a position in the
+// user's change feed
+func EncodeSyncToken(seq int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(seq, 10)))
+}
+
+// DecodeSyncToken parses a sync token. An empty token means the client has
+// never synced and decodes to 0.
+func DecodeSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sync token encoding: %w", err)
+	}
+
+	digits, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid sync token: unknown format")
+	}
+	seq, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, fmt.Errorf("invalid sync token: %q", digits)
+	}
+
+	return seq, nil
+}
@@ -0,0 +1,56 @@
+package domain_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/whauzan/todo-api/internal/domain"
+)
+
+func TestSyncTokenRoundTrip(t *testing.T) {
+	for _, seq := range []int64{0, 1, 41, 1 << 40} {
+		got, err := domain.DecodeSyncToken(domain.EncodeSyncToken(seq))
+		if err != nil {
+			t.Fatalf("DecodeSyncToken(EncodeSyncToken(%d)): %v", seq, err)
+		}
+		if got != seq {
+			t.Errorf("round trip of %d = %d", seq, got)
+		}
+	}
+}
+
+func TestDecodeSyncToken(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		token   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "empty token is a first sync", token: "", want: 0},
+		{name: "sequence", token: encode("seq:57"), want: 57},
+		{name: "timestamp token of the old format", token: encode("2025-12-22T11:00:00.123456Z"), wantErr: true},
+		{name: "negative sequence", token: encode("seq:-1"), wantErr: true},
+		{name: "not a number", token: encode("seq:abc"), wantErr: true},
+		{name: "not base64", token: "!!!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.DecodeSyncToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("DecodeSyncToken(%q) = %d, want an error", tt.token, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeSyncToken(%q): %v", tt.token, err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeSyncToken(%q) = %d, want %d", tt.token, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,31 +1,309 @@
 package domain
 
 import (
+	"encoding/base64"
+	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
-// Todo represents a todo item
+const (
+	// MaxTitleLength is the most characters a plaintext title can have. The
+	// database column and the request validation enforce it.
+	MaxTitleLength = 255
+	// MaxDescriptionLength is the most characters a plaintext description can
+	// have. A database constraint and the request validation enforce it.
+	MaxDescriptionLength = 2000
+)
+
+// Todo represents a todo item.
+// An encrypted todo keeps its content in TitleCiphertext and DescriptionCiphertext,
+// encrypted with keys only the client holds; its Title is empty and its Description nil.
 type Todo struct {
 	ID          uuid.UUID `json:"id"`
 	UserID      uuid.UUID `json:"user_id"`
 	Title       string    `json:"title"`
 	Description *string   `json:"description"`
 	Completed   bool      `json:"completed"`
+	Encrypted   bool      `json:"encrypted"`
+	Preview     *string   `json:"preview,omitempty"` // Plain-text start of the description, in list previews
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	TitleCiphertext       []byte `json:"title_ciphertext,omitempty"`
+	DescriptionCiphertext []byte `json:"description_ciphertext,omitempty"`
+}
+
+// TodoContent is a change to the plaintext or encrypted content of a todo.
+// Absent fields are left unchanged.
+type TodoContent struct {
+	Title                 Optional[string]
+	Description           Optional[string]
+	TitleCiphertext       Optional[[]byte]
+	DescriptionCiphertext Optional[[]byte]
+}
+
+// ApplyContent applies a content change to the todo and returns validation details
+// for changes that mix plaintext and ciphertext. Setting a title ciphertext encrypts
+// the todo and drops its plaintext content; setting a plaintext title does the reverse.
+func (t *Todo) ApplyContent(c TodoContent) []string {
+	var details []string
+	if c.TitleCiphertext.IsNull() {
+		details = append(details, "title_ciphertext: cannot be null")
+	}
+	if c.Title.Valid && c.TitleCiphertext.Valid {
+		details = append(details, "title: cannot be sent together with title_ciphertext")
+	}
+	if len(details) > 0 {
+		return details
+	}
+
+	switch {
+	case c.TitleCiphertext.Valid:
+		if !t.Encrypted {
+			t.Title = ""
+			t.Description = nil
+		}
+		t.Encrypted = true
+		t.TitleCiphertext = c.TitleCiphertext.Value
+	case c.Title.Valid:
+		if t.Encrypted {
+			t.TitleCiphertext = nil
+			t.DescriptionCiphertext = nil
+		}
+		t.Encrypted = false
+		t.Title = c.Title.Value
+	}
+
+	if c.Description.Set {
+		if t.Encrypted {
+			details = append(details, "description: todo is encrypted; send description_ciphertext")
+		} else {
+			t.Description = c.Description.Ptr()
+		}
+	}
+	if c.DescriptionCiphertext.Set {
+		if !t.Encrypted {
+			details = append(details, "description_ciphertext: todo is not encrypted; send description")
+		} else {
+			t.DescriptionCiphertext = nil
+			if c.DescriptionCiphertext.Valid {
+				t.DescriptionCiphertext = c.DescriptionCiphertext.Value
+			}
+		}
+	}
+
+	return details
 }
 
-// CreateTodoRequest represents the request to create a new todo
+// CheckContent returns validation details if the todo's content is incomplete or
+// mixes plaintext and ciphertext. requireEncrypted rejects plaintext todos, for
+// owners in end-to-end encryption mode.
+func (t *Todo) CheckContent(requireEncrypted bool) []string {
+	if t.Encrypted {
+		var details []string
+		if len(t.TitleCiphertext) == 0 {
+			details = append(details, "title_ciphertext: is required")
+		}
+		if t.Title != "" || t.Description != nil {
+			details = append(details, "title: encrypted todos cannot have plaintext content")
+		}
+		return details
+	}
+
+	if requireEncrypted {
+		return []string{"title: end-to-end encryption is enabled; send title_ciphertext"}
+	}
+	if t.Title == "" {
+		return []string{"title: is required"}
+	}
+	if t.TitleCiphertext != nil || t.DescriptionCiphertext != nil {
+		return []string{"title_ciphertext: only encrypted todos have ciphertext"}
+	}
+	return nil
+}
+
+// TodoLimits are the configured size limits of plaintext todo content, at
+// most MaxTitleLength and MaxDescriptionLength
+type TodoLimits struct {
+	MaxTitleLength       int
+	MaxDescriptionLength int
+}
+
+// Check returns validation details for plaintext content longer than the limits
+func (l TodoLimits) Check(t *Todo) []string {
+	var details []string
+	if utf8.RuneCountInString(t.Title) > l.MaxTitleLength {
+		details = append(details, fmt.Sprintf("title: must be at most %d characters", l.MaxTitleLength))
+	}
+	if t.Description != nil && utf8.RuneCountInString(*t.Description) > l.MaxDescriptionLength {
+		details = append(details, fmt.Sprintf("description: must be at most %d characters", l.MaxDescriptionLength))
+	}
+	return details
+}
+
+// CreateTodoRequest represents the request to create a new todo.
+// ID is optional; clients may supply their own UUID (v4 or v7) so that
+// retried creates are deduplicated instead of producing duplicates.
+// Encrypted todos send title_ciphertext (and description_ciphertext) instead of
+// title and description.
 type CreateTodoRequest struct {
-	Title       string  `json:"title" validate:"required,min=1,max=255"`
-	Description *string `json:"description" validate:"omitempty,max=2000"`
+	ID          *uuid.UUID `json:"id"`
+	Title       string     `json:"title" validate:"omitempty,max=255"`
+	Description *string    `json:"description" validate:"omitempty,max=2000"`
+
+	TitleCiphertext       []byte `json:"title_ciphertext" validate:"omitempty,max=4096"`
+	DescriptionCiphertext []byte `json:"description_ciphertext" validate:"omitempty,max=16384"`
 }
 
-// UpdateTodoRequest represents the request to update a todo
+// Content returns the content the request sets
+func (r *CreateTodoRequest) Content() TodoContent {
+	var c TodoContent
+	if r.Title != "" {
+		c.Title = Some(r.Title)
+	}
+	if r.Description != nil {
+		c.Description = Some(*r.Description)
+	}
+	if len(r.TitleCiphertext) > 0 {
+		c.TitleCiphertext = Some(r.TitleCiphertext)
+	}
+	if len(r.DescriptionCiphertext) > 0 {
+		c.DescriptionCiphertext = Some(r.DescriptionCiphertext)
+	}
+	return c
+}
+
+// CloneTodoRequest represents the optional request body of a clone. As with
+// CreateTodoRequest, a client-supplied ID deduplicates retried clones.
+type CloneTodoRequest struct {
+	ID *uuid.UUID `json:"id"`
+}
+
+// UpdateTodoRequest represents the request to update a todo.
+// Absent fields are left unchanged; an explicit null clears the description.
 type UpdateTodoRequest struct {
-	Title       *string `json:"title" validate:"omitempty,min=1,max=255"`
-	Description *string `json:"description" validate:"omitempty,max=2000"`
-	Completed   *bool   `json:"completed"`
+	Title       Optional[string] `json:"title" validate:"omitempty,min=1,max=255"`
+	Description Optional[string] `json:"description" validate:"omitempty,max=2000"`
+	Completed   Optional[bool]   `json:"completed"`
+
+	TitleCiphertext       Optional[[]byte] `json:"title_ciphertext" validate:"omitempty,min=1,max=4096"`
+	DescriptionCiphertext Optional[[]byte] `json:"description_ciphertext" validate:"omitempty,max=16384"`
+}
+
+// Content returns the content the request changes
+func (r *UpdateTodoRequest) Content() TodoContent {
+	return TodoContent{
+		Title:                 r.Title,
+		Description:           r.Description,
+		TitleCiphertext:       r.TitleCiphertext,
+		DescriptionCiphertext: r.DescriptionCiphertext,
+	}
+}
+
+// TodoCursor identifies a position in a user's todo list ordered by
+// creation time (newest first). The ID breaks ties between todos created
+// in the same instant, so both random and time-ordered IDs are supported.
+type TodoCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode returns the opaque string form of the cursor
+func (c TodoCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTodoCursor parses a cursor produced by TodoCursor.Encode
+func DecodeTodoCursor(s string) (*TodoCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor encoding: %w", err)
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, fmt.Errorf("invalid cursor format")
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor time: %w", err)
+	}
+
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor ID: %w", err)
+	}
+
+	return &TodoCursor{CreatedAt: t, ID: parsedID}, nil
+}
+
+// TodoPage is one page of a cursor-paginated todo list
+type TodoPage struct {
+	Todos      []*Todo
+	NextCursor *TodoCursor
+}
+
+// TodoSummary is the short form of a todo for list views. It leaves out the
+// description, and is read without it rather than trimmed from a whole todo.
+type TodoSummary struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	Encrypted bool      `json:"encrypted"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TitleCiphertext []byte `json:"title_ciphertext,omitempty"`
+}
+
+// TodoSummaryPage is one page of a cursor-paginated todo summary list
+type TodoSummaryPage struct {
+	Todos      []*TodoSummary
+	NextCursor *TodoCursor
+}
+
+// TodoFilter selects todos for a bulk operation. Every criterion that is set
+// must match; times are compared with the todo's created_at and updated_at.
+type TodoFilter struct {
+	IDs           []uuid.UUID `json:"ids" validate:"omitempty,max=1000"`
+	CreatedAfter  *time.Time  `json:"created_after"`
+	CreatedBefore *time.Time  `json:"created_before"`
+	UpdatedBefore *time.Time  `json:"updated_before"`
+}
+
+// IsEmpty reports whether the filter has no criteria and would match every todo
+func (f TodoFilter) IsEmpty() bool {
+	return f.IDs == nil && f.CreatedAfter == nil && f.CreatedBefore == nil && f.UpdatedBefore == nil
+}
+
+// CompleteByFilterRequest represents the request to complete every open todo
+// matching a filter
+type CompleteByFilterRequest struct {
+	Filter TodoFilter `json:"filter"`
+}
+
+// CompleteByFilterResult reports a bulk completion. The undo token is only
+// issued when at least one todo was completed.
+type CompleteByFilterResult struct {
+	Completed     int64      `json:"completed"`
+	UndoToken     *uuid.UUID `json:"undo_token,omitempty"`
+	UndoExpiresAt *time.Time `json:"undo_expires_at,omitempty"`
+}
+
+// UndoCompleteRequest represents the request to reopen the todos of a bulk completion
+type UndoCompleteRequest struct {
+	UndoToken uuid.UUID `json:"undo_token" validate:"required"`
+}
+
+// UndoCompleteResult reports how many todos an undo reopened. Todos changed
+// since the bulk completion are left as they are.
+type UndoCompleteResult struct {
+	Reopened int64 `json:"reopened"`
 }
@@ -1,19 +1,51 @@
 package domain
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// UserStatus is the lifecycle state of an account
+type UserStatus string
+
+const (
+	// UserStatusActive accounts can sign in and use the API
+	UserStatusActive UserStatus = "active"
+	// UserStatusSuspended accounts are locked until an administrator reactivates them
+	UserStatusSuspended UserStatus = "suspended"
+	// UserStatusPendingDeletion accounts are locked while their deletion is carried out
+	UserStatusPendingDeletion UserStatus = "pending_deletion"
 )
 
 // User represents a user in the system
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"` // Never expose password hash in JSON
-	Name         string    `json:"name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	Email           string     `json:"email"`
+	PasswordHash    string     `json:"-"` // Never expose password hash in JSON
+	Name            string     `json:"name"`
+	E2EEnabled      bool       `json:"e2e_enabled"` // Todo content must be encrypted by the client
+	Status          UserStatus `json:"status"`
+	StatusReason    *string    `json:"status_reason,omitempty"`
+	TokenVersion    int        `json:"-"`                     // Tokens carrying an older version are revoked
+	TelemetryOptOut bool       `json:"telemetry_opt_out"`     // No analytics events are recorded for the user
+	PurgeAfter      *time.Time `json:"purge_after,omitempty"` // When a requested deletion is carried out
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// IsActive reports whether the account may authenticate
+func (u *User) IsActive() bool {
+	return u.Status == UserStatusActive
+}
+
+// NormalizeEmail returns the canonical form of an email address: trimmed,
+// NFC-normalized and lowercased. Emails are stored and looked up in this form
+// so "User@X.com" and "user@x.com" refer to the same account.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(norm.NFC.String(strings.TrimSpace(email)))
 }
 
 // RegisterRequest represents the request to register a new user
@@ -23,33 +55,95 @@ type RegisterRequest struct {
 	Name     string `json:"name" validate:"required,min=1,max=255"`
 }
 
-// LoginRequest represents the request to login
+// Normalize canonicalizes the email before validation
+func (r *RegisterRequest) Normalize() {
+	r.Email = NormalizeEmail(r.Email)
+}
+
+// LoginRequest represents the request to login. Client selects the scopes of
+// the issued token and defaults to web.
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Client   string `json:"client" validate:"omitempty,oneof=web mobile api-key agent agent-readonly"`
+}
+
+// Normalize canonicalizes the email before validation
+func (r *LoginRequest) Normalize() {
+	r.Email = NormalizeEmail(r.Email)
 }
 
 // LoginResponse represents the response after successful login
 type LoginResponse struct {
 	Token     string    `json:"token"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Scopes    []string  `json:"scopes"`
 	User      *UserInfo `json:"user"`
 }
 
 // UserInfo represents public user information
 type UserInfo struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
+	ID              uuid.UUID `json:"id"`
+	Email           string    `json:"email"`
+	Name            string    `json:"name"`
+	E2EEnabled      bool      `json:"e2e_enabled"`
+	TelemetryOptOut bool      `json:"telemetry_opt_out"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CurrentUser is the authenticated user's own account, with the scopes of
+// the token the request was made with
+type CurrentUser struct {
+	*UserInfo
+	Scopes []string `json:"scopes"`
+}
+
+// ChangePasswordRequest represents the request to change the account password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// SuspendUserRequest is an administrator's request to suspend an account
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=500"`
+}
+
+// SetEncryptionRequest turns end-to-end encryption mode on or off
+type SetEncryptionRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// DeleteAccountRequest asks for the account to be deleted after the grace period
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletion reports a scheduled account deletion
+type AccountDeletion struct {
+	Status     UserStatus `json:"status"`
+	PurgeAfter time.Time  `json:"purge_after"`
+}
+
+// CanRestore reports whether the account is pending a deletion the user
+// requested and that has not been carried out yet
+func (u *User) CanRestore(now time.Time) bool {
+	return u.Status == UserStatusPendingDeletion && u.PurgeAfter != nil && now.Before(*u.PurgeAfter)
+}
+
+// SetTelemetryRequest turns product analytics on or off for the user
+type SetTelemetryRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
 }
 
 // ToUserInfo converts a User to UserInfo
 func (u *User) ToUserInfo() *UserInfo {
 	return &UserInfo{
-		ID:        u.ID,
-		Email:     u.Email,
-		Name:      u.Name,
-		CreatedAt: u.CreatedAt,
+		ID:              u.ID,
+		Email:           u.Email,
+		Name:            u.Name,
+		E2EEnabled:      u.E2EEnabled,
+		TelemetryOptOut: u.TelemetryOptOut,
+		CreatedAt:       u.CreatedAt,
 	}
 }
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateWidgetTokenRequest represents the request to issue a token for a
+// widget embedded on the site at Origin
+type CreateWidgetTokenRequest struct {
+	Origin string `json:"origin" validate:"required,max=255"`
+}
+
+// WidgetToken is a read-only token bound to the origin of the site embedding
+// the widget. Revoking every token of the account also revokes it.
+type WidgetToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Origin    string    `json:"origin"`
+	Scopes    []string  `json:"scopes"`
+}
+
+// WidgetTodo is the part of a todo shown by an embedded widget
+type WidgetTodo struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
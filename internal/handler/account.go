@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// AccountHandler handles requests about the authenticated user's own account
+type AccountHandler struct {
+	accountService *service.AccountService
+	logger         *slog.Logger
+}
+
+// NewAccountHandler creates a new AccountHandler
+func NewAccountHandler(accountService *service.AccountService, logger *slog.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// Delete schedules the authenticated user's account for deletion
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.DeleteAccountRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	deletion, err := h.accountService.RequestDeletion(r.Context(), userID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// The account is only purged after the grace period
+	JSON(w, r, http.StatusAccepted, deletion)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// AdminHandler handles administrative account requests
+type AdminHandler struct {
+	accountService *service.AccountService
+	logger         *slog.Logger
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(accountService *service.AccountService, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		accountService: accountService,
+		logger:         logger,
+	}
+}
+
+// GetUser handles retrieving a user with their account status
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.accountService.Get(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, user)
+}
+
+// Suspend handles suspending a user's account
+func (h *AdminHandler) Suspend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty one suspends without a reason
+	var req domain.SuspendUserRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+	}
+
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	user, err := h.accountService.Suspend(r.Context(), userID, req.Reason)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, user)
+}
+
+// Reactivate handles restoring a user's account to active
+func (h *AdminHandler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.accountService.Reactivate(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, user)
+}
+
+// userID parses the user ID from the URL, writing an error response if it is invalid
+func (h *AdminHandler) userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid user ID",
+			http.StatusBadRequest,
+			err,
+		))
+		return uuid.Nil, false
+	}
+	return userID, true
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// maxAgentArgumentsBytes bounds the arguments of a tool call
+const maxAgentArgumentsBytes = 16 << 10
+
+// AgentHandler handles tool calls from LLM agents and MCP servers
+type AgentHandler struct {
+	agentService *service.AgentService
+	logger       *slog.Logger
+}
+
+// NewAgentHandler creates a new AgentHandler
+func NewAgentHandler(agentService *service.AgentService, logger *slog.Logger) *AgentHandler {
+	return &AgentHandler{
+		agentService: agentService,
+		logger:       logger,
+	}
+}
+
+// listAgentActionsQuery holds the query parameters of the agent audit log.
+// The limit bounds match service.MaxPageLimit.
+type listAgentActionsQuery struct {
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// Tools handles listing the tools the token can call
+func (h *AgentHandler) Tools(w http.ResponseWriter, r *http.Request) {
+	JSON(w, r, http.StatusOK, h.agentService.Tools(middleware.GetScopes(r.Context())))
+}
+
+// Call handles a tool call. The body holds the tool's arguments, and the
+// call is recorded in the audit log whether or not it succeeds.
+func (h *AgentHandler) Call(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	name := chi.URLParam(r, "name")
+
+	var (
+		result any
+		todoID *uuid.UUID
+	)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAgentArgumentsBytes))
+	if err != nil {
+		err = apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid tool arguments",
+			http.StatusBadRequest,
+			err,
+		)
+	} else {
+		result, todoID, err = h.call(r, userID, name, body)
+	}
+
+	h.agentService.Record(r.Context(), userID, middleware.GetClientIP(r.Context()), name, body, todoID, err)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, result)
+}
+
+// call checks and runs a tool call and returns its result and the todo it touched
+func (h *AgentHandler) call(r *http.Request, userID uuid.UUID, name string, body []byte) (any, *uuid.UUID, error) {
+	tool, err := h.agentService.Authorize(name, middleware.GetScopes(r.Context()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := r.Context()
+	switch tool.Name {
+	case domain.AgentToolListTodos:
+		var in domain.ListTodosToolInput
+		if err := decodeToolArguments(body, &in); err != nil {
+			return nil, nil, err
+		}
+		out, err := h.agentService.ListTodos(ctx, userID, &in)
+		return out, nil, err
+
+	case domain.AgentToolCreateTodo:
+		var in domain.CreateTodoToolInput
+		if err := decodeToolArguments(body, &in); err != nil {
+			return nil, nil, err
+		}
+		todo, err := h.agentService.CreateTodo(ctx, userID, &in)
+		if err != nil {
+			return nil, nil, err
+		}
+		return todo, &todo.ID, nil
+
+	case domain.AgentToolCompleteTodo:
+		var in domain.CompleteTodoToolInput
+		if err := decodeToolArguments(body, &in); err != nil {
+			return nil, nil, err
+		}
+		todo, err := h.agentService.CompleteTodo(ctx, userID, &in)
+		return todo, &in.ID, err
+	}
+
+	return nil, nil, apperror.ErrNotFound
+}
+
+// decodeToolArguments strictly decodes and validates tool arguments: unknown
+// fields and trailing data are rejected, and an empty body means no arguments
+func decodeToolArguments(body []byte, v any) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		body = []byte("{}")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&json.RawMessage{}) != io.EOF {
+		err = errors.New("unexpected data after the arguments object")
+	}
+	if err != nil {
+		return apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid tool arguments",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+
+	return validateStruct(v)
+}
+
+// Actions handles listing the audit log of agent actions taken for the user
+func (h *AgentHandler) Actions(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query listAgentActionsQuery
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	limit := service.DefaultPageLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	actions, err := h.agentService.ListActions(r.Context(), userID, limit)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, actions)
+}
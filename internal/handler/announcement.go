@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// AnnouncementHandler handles announcement requests from users and admins
+type AnnouncementHandler struct {
+	announcementService *service.AnnouncementService
+	logger              *slog.Logger
+}
+
+// NewAnnouncementHandler creates a new AnnouncementHandler
+func NewAnnouncementHandler(announcementService *service.AnnouncementService, logger *slog.Logger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+		logger:              logger,
+	}
+}
+
+// List handles listing the active announcements the authenticated user has not dismissed
+func (h *AnnouncementHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	announcements, err := h.announcementService.ListActive(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, announcements)
+}
+
+// Dismiss handles hiding an announcement from the authenticated user
+func (h *AnnouncementHandler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	announcementID, ok := h.announcementID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.announcementService.Dismiss(r.Context(), userID, announcementID); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, map[string]string{
+		"message": "Announcement dismissed",
+	})
+}
+
+// Create handles scheduling a new announcement (admin)
+func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateAnnouncementRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	announcement, err := h.announcementService.Create(r.Context(), &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusCreated, announcement)
+}
+
+// ListAll handles listing every announcement, including scheduled and expired ones (admin)
+func (h *AnnouncementHandler) ListAll(w http.ResponseWriter, r *http.Request) {
+	announcements, err := h.announcementService.List(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, announcements)
+}
+
+// Delete handles removing an announcement (admin)
+func (h *AnnouncementHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	announcementID, ok := h.announcementID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.announcementService.Delete(r.Context(), announcementID); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	NoContent(w, r, "Announcement deleted successfully")
+}
+
+// announcementID parses the announcement ID from the URL, writing an error response if it is invalid
+func (h *AnnouncementHandler) announcementID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	announcementID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid announcement ID",
+			http.StatusBadRequest,
+			err,
+		))
+		return uuid.Nil, false
+	}
+	return announcementID, true
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
 	"github.com/whauzan/todo-api/internal/service"
 )
@@ -35,6 +36,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate request
+	req.Normalize()
 	if err := validateStruct(&req); err != nil {
 		JSONError(w, h.logger, r, err)
 		return
@@ -48,7 +50,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return created user with envelope
-	JSON(w, http.StatusCreated, userInfo)
+	JSON(w, r, http.StatusCreated, userInfo)
 }
 
 // Login handles user login
@@ -62,6 +64,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate request
+	req.Normalize()
 	if err := validateStruct(&req); err != nil {
 		JSONError(w, h.logger, r, err)
 		return
@@ -75,7 +78,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return token and user info with envelope
-	JSON(w, http.StatusOK, loginResp)
+	JSON(w, r, http.StatusOK, loginResp)
 }
 
 // Refresh handles JWT token refresh
@@ -109,17 +112,149 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return new token and user info with envelope
-	JSON(w, http.StatusOK, loginResp)
+	JSON(w, r, http.StatusOK, loginResp)
+}
+
+// Me returns the authenticated user's account as currently stored, so clients
+// need not decode their token
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	user, err := h.authService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return user info with envelope
+	JSON(w, r, http.StatusOK, &domain.CurrentUser{
+		UserInfo: user.ToUserInfo(),
+		Scopes:   middleware.GetScopes(r.Context()),
+	})
+}
+
+// SetEncryption turns end-to-end encryption mode on or off for the authenticated user
+func (h *AuthHandler) SetEncryption(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.SetEncryptionRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	userInfo, err := h.authService.SetEncryption(r.Context(), userID, *req.Enabled)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return updated user info with envelope
+	JSON(w, r, http.StatusOK, userInfo)
+}
+
+// SetTelemetry turns product analytics on or off for the authenticated user
+func (h *AuthHandler) SetTelemetry(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.SetTelemetryRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	userInfo, err := h.authService.SetTelemetry(r.Context(), userID, *req.Enabled)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return updated user info with envelope
+	JSON(w, r, http.StatusOK, userInfo)
+}
+
+// ChangePassword changes the authenticated user's password and revokes their other tokens
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.ChangePasswordRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	loginResp, err := h.authService.ChangePassword(r.Context(), userID, middleware.GetScopes(r.Context()), &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return the replacement token and user info with envelope
+	JSON(w, r, http.StatusOK, loginResp)
+}
+
+// LogoutAll revokes every token issued to the authenticated user
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := h.authService.LogoutAll(r.Context(), userID); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	NoContent(w, r, "Successfully logged out of all sessions")
 }
 
 // Logout handles user logout
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	// With stateless JWT, logout is handled client-side by discarding the token.
-	// This endpoint confirms the logout action and can be extended to support
-	// token blacklisting if needed in the future.
+	// This endpoint confirms the logout action; use LogoutAll to revoke
+	// every issued token.
 	h.logger.InfoContext(r.Context(), "user logged out")
 
-	JSON(w, http.StatusOK, map[string]string{
-		"message": "Successfully logged out",
-	})
+	NoContent(w, r, "Successfully logged out")
 }
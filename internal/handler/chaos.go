@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// ChaosHandler handles administrative requests that change the faults the
+// Chaos middleware injects. It is only routed when fault injection is enabled.
+type ChaosHandler struct {
+	chaos  *middleware.Chaos
+	logger *slog.Logger
+}
+
+// NewChaosHandler creates a new ChaosHandler
+func NewChaosHandler(chaos *middleware.Chaos, logger *slog.Logger) *ChaosHandler {
+	return &ChaosHandler{
+		chaos:  chaos,
+		logger: logger,
+	}
+}
+
+// chaosRules is the request and response body of the chaos endpoints
+type chaosRules struct {
+	Rules []middleware.ChaosRule `json:"rules"`
+}
+
+// Get handles listing the active fault injection rules
+func (h *ChaosHandler) Get(w http.ResponseWriter, r *http.Request) {
+	JSON(w, r, http.StatusOK, chaosRules{Rules: h.chaos.Rules()})
+}
+
+// Replace handles replacing every fault injection rule
+func (h *ChaosHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	var req chaosRules
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var details []string
+	for i, rule := range req.Rules {
+		if err := rule.Validate(); err != nil {
+			for _, problem := range strings.Split(err.Error(), "\n") {
+				details = append(details, fmt.Sprintf("rules[%d]: %s", i, problem))
+			}
+		}
+	}
+	if len(details) > 0 {
+		JSONError(w, h.logger, r, apperror.ErrValidation.WithDetails(details...))
+		return
+	}
+
+	if err := h.chaos.SetRules(req.Rules); err != nil {
+		JSONError(w, h.logger, r, apperror.ErrValidation.WithDetails(err.Error()))
+		return
+	}
+
+	h.logger.WarnContext(r.Context(), "chaos rules replaced", "rules", len(req.Rules))
+	JSON(w, r, http.StatusOK, chaosRules{Rules: h.chaos.Rules()})
+}
+
+// Clear handles removing every fault injection rule
+func (h *ChaosHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	_ = h.chaos.SetRules(nil)
+
+	h.logger.WarnContext(r.Context(), "chaos rules cleared")
+	NoContent(w, r, "Chaos rules cleared")
+}
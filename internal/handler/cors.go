@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// CORSHandler handles administrative requests for the CORS origin allowlist
+type CORSHandler struct {
+	originService *service.CORSOriginService
+	logger        *slog.Logger
+}
+
+// NewCORSHandler creates a new CORSHandler
+func NewCORSHandler(originService *service.CORSOriginService, logger *slog.Logger) *CORSHandler {
+	return &CORSHandler{
+		originService: originService,
+		logger:        logger,
+	}
+}
+
+// removeCORSOriginQuery holds the query parameters of an origin removal.
+// Origins contain slashes, so they are passed in the query rather than the path.
+type removeCORSOriginQuery struct {
+	Origin string `query:"origin" validate:"required"`
+}
+
+// List handles listing the allowed origins
+func (h *CORSHandler) List(w http.ResponseWriter, r *http.Request) {
+	origins, err := h.originService.List(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, origins)
+}
+
+// Add handles allowing an origin
+func (h *CORSHandler) Add(w http.ResponseWriter, r *http.Request) {
+	var req domain.AddCORSOriginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	origin, err := h.originService.Add(r.Context(), &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusCreated, origin)
+}
+
+// Remove handles disallowing an origin
+func (h *CORSHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	var query removeCORSOriginQuery
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := h.originService.Remove(r.Context(), query.Origin); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	NoContent(w, r, "Origin removed successfully")
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// ErrorHandler serves the error code listing and router-level errors
+type ErrorHandler struct {
+	logger *slog.Logger
+}
+
+// NewErrorHandler creates a new ErrorHandler
+func NewErrorHandler(logger *slog.Logger) *ErrorHandler {
+	return &ErrorHandler{
+		logger: logger,
+	}
+}
+
+// ErrorCodeData describes an error code in the error code listing
+type ErrorCodeData struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// ListCodes handles listing every error code the API can return
+func (h *ErrorHandler) ListCodes(w http.ResponseWriter, r *http.Request) {
+	codes := apperror.Codes()
+
+	data := make([]ErrorCodeData, 0, len(codes))
+	for _, c := range codes {
+		data = append(data, ErrorCodeData{
+			Code:        string(c.Code),
+			Status:      c.Status,
+			Description: c.Description,
+		})
+	}
+
+	JSON(w, r, http.StatusOK, data)
+}
+
+// NotFound handles requests for routes that do not exist
+func (h *ErrorHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	JSONError(w, h.logger, r, apperror.NewAppError(
+		apperror.CodeNotFound,
+		"Route not found",
+		apperror.StatusFor(apperror.CodeNotFound),
+		nil,
+	).WithDetails(fmt.Sprintf("no route matches %s %s", r.Method, r.URL.Path)))
+}
+
+// MethodNotAllowed handles requests for routes that exist but not for the request method
+func (h *ErrorHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	allowed := middleware.AllowedMethods(r)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+	JSONError(w, h.logger, r, apperror.ErrMethodNotAllowed.WithDetails(
+		fmt.Sprintf("allowed methods: %s", strings.Join(allowed, ", ")),
+	))
+}
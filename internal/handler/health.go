@@ -4,58 +4,203 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/pkg/buildinfo"
 )
 
-// HealthHandler handles health check requests
+// Health check statuses
+const (
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+)
+
+// DefaultHealthCheckTimeout bounds a dependency check that sets no timeout
+const DefaultHealthCheckTimeout = 2 * time.Second
+
+// HealthCheck checks that one dependency is reachable
+type HealthCheck struct {
+	// Name identifies the dependency in responses and logs
+	Name string
+	// Timeout bounds the check; zero uses DefaultHealthCheckTimeout
+	Timeout time.Duration
+	// Check returns an error when the dependency is unavailable
+	Check func(ctx context.Context) error
+}
+
+// DatabaseHealthCheck pings the database
+func DatabaseHealthCheck(pool *pgxpool.Pool) HealthCheck {
+	return HealthCheck{
+		Name:  "database",
+		Check: pool.Ping,
+	}
+}
+
+// HealthHandler handles health check requests. Dependencies are checked
+// concurrently, and the aggregate result is reused for cacheTTL so frequent
+// health checks do not add load to the dependencies.
 type HealthHandler struct {
-	pool   *pgxpool.Pool
-	logger *slog.Logger
+	checks   []HealthCheck
+	cacheTTL time.Duration
+	logger   *slog.Logger
+	draining atomic.Bool
+
+	mu   sync.Mutex
+	last *healthResult
 }
 
-// NewHealthHandler creates a new HealthHandler
-func NewHealthHandler(pool *pgxpool.Pool, logger *slog.Logger) *HealthHandler {
+// NewHealthHandler creates a new HealthHandler running the given checks.
+// A cacheTTL of 0 checks the dependencies on every request.
+func NewHealthHandler(checks []HealthCheck, cacheTTL time.Duration, logger *slog.Logger) *HealthHandler {
 	return &HealthHandler{
-		pool:   pool,
-		logger: logger,
+		checks:   checks,
+		cacheTTL: cacheTTL,
+		logger:   logger,
 	}
 }
 
 // HealthData represents the health check response data
 type HealthData struct {
-	Status   string `json:"status"`
-	Database string `json:"database"`
-	Time     string `json:"time"`
+	Status    string                      `json:"status"`
+	Database  string                      `json:"database"`
+	Checks    map[string]HealthCheckState `json:"checks,omitempty"`
+	CheckedAt *time.Time                  `json:"checked_at,omitempty"`
+	Version   string                      `json:"version"`
+	Commit    string                      `json:"commit"`
+	Time      string                      `json:"time"`
+}
+
+// HealthCheckState is the outcome of one dependency check
+type HealthCheckState struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// healthResult is the aggregate outcome of one round of checks
+type healthResult struct {
+	healthy   bool
+	checks    map[string]HealthCheckState
+	checkedAt time.Time
 }
 
 // Check handles health check requests
 func (h *HealthHandler) Check(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-	defer cancel()
+	result := h.evaluate(r.Context())
 
-	// Check database connection
-	dbStatus := "healthy"
-	if err := h.pool.Ping(ctx); err != nil {
-		h.logger.ErrorContext(ctx, "database health check failed", "error", err)
-		dbStatus = "unhealthy"
-	}
-
-	status := "healthy"
+	status := HealthStatusHealthy
 	statusCode := http.StatusOK
-
-	if dbStatus == "unhealthy" {
-		status = "unhealthy"
+	if !result.healthy {
+		status = HealthStatusUnhealthy
 		statusCode = http.StatusServiceUnavailable
 	}
 
+	build := buildinfo.Get()
 	healthData := HealthData{
-		Status:   status,
-		Database: dbStatus,
-		Time:     time.Now().UTC().Format(time.RFC3339),
+		Status:    status,
+		Database:  result.checks["database"].Status,
+		Checks:    result.checks,
+		CheckedAt: &result.checkedAt,
+		Version:   build.Version,
+		Commit:    build.Commit,
+		Time:      time.Now().UTC().Format(time.RFC3339),
 	}
 
 	// Return health data with envelope
-	JSON(w, statusCode, healthData)
+	JSON(w, r, statusCode, healthData)
+}
+
+// evaluate returns the cached result while it is fresh, and otherwise runs
+// every check. Concurrent callers wait for a single round of checks.
+func (h *HealthHandler) evaluate(ctx context.Context) *healthResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.last != nil && time.Since(h.last.checkedAt) < h.cacheTTL {
+		return h.last
+	}
+
+	// The result is shared, so a caller that goes away must not cut the checks short
+	ctx = context.WithoutCancel(ctx)
+
+	states := make([]HealthCheckState, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			states[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	result := &healthResult{
+		healthy:   true,
+		checks:    make(map[string]HealthCheckState, len(h.checks)),
+		checkedAt: time.Now().UTC(),
+	}
+	for i, check := range h.checks {
+		result.checks[check.Name] = states[i]
+		if states[i].Status != HealthStatusHealthy {
+			result.healthy = false
+		}
+	}
+
+	h.last = result
+	return result
+}
+
+// run runs one check within its timeout
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) HealthCheckState {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	latency := time.Since(start)
+
+	state := HealthCheckState{
+		Status:    HealthStatusHealthy,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "health check failed", "check", check.Name, "error", err, "latency", latency)
+		state.Status = HealthStatusUnhealthy
+	}
+	return state
+}
+
+// SetDraining makes readiness checks fail so load balancers stop routing
+// new requests here before the server shuts down
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Ready handles readiness checks: it fails while the server is draining
+// and otherwise reports the same status as Check
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		build := buildinfo.Get()
+		JSON(w, r, http.StatusServiceUnavailable, HealthData{
+			Status:   "draining",
+			Database: "unknown",
+			Version:  build.Version,
+			Commit:   build.Commit,
+			Time:     time.Now().UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
+	h.Check(w, r)
+}
+
+// Version handles requests for the running build's version information
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	JSON(w, r, http.StatusOK, buildinfo.Get())
 }
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// HookHandler handles REST hook subscription requests
+type HookHandler struct {
+	hookService *service.HookService
+	logger      *slog.Logger
+}
+
+// NewHookHandler creates a new HookHandler
+func NewHookHandler(hookService *service.HookService, logger *slog.Logger) *HookHandler {
+	return &HookHandler{
+		hookService: hookService,
+		logger:      logger,
+	}
+}
+
+// hookSamplesQuery holds the query parameters of the sample deliveries.
+// Version is the payload version, 1 by default.
+type hookSamplesQuery struct {
+	Event   domain.HookEvent `query:"event" validate:"required,oneof=todo.changed todo.deleted"`
+	Version int              `query:"version" validate:"omitempty,oneof=1 2"`
+}
+
+// listHookDeliveriesQuery holds the query parameters of the delivery list.
+// The limit bounds match service.MaxPageLimit.
+type listHookDeliveriesQuery struct {
+	Limit  *int `query:"limit" validate:"omitempty,min=1,max=100"`
+	Failed bool `query:"failed"`
+}
+
+// Subscribe handles subscribing a hook
+func (h *HookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.CreateHookRequest
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	sub, err := h.hookService.Subscribe(r.Context(), userID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusCreated, sub)
+}
+
+// List handles listing the authenticated user's hook subscriptions
+func (h *HookHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	subs, err := h.hookService.List(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, subs)
+}
+
+// Unsubscribe handles removing a hook subscription
+func (h *HookHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	hookID, err := parseHookID(r)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := h.hookService.Unsubscribe(r.Context(), userID, hookID); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	NoContent(w, r, "Hook unsubscribed successfully")
+}
+
+// Samples handles listing example deliveries of an event
+func (h *HookHandler) Samples(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query hookSamplesQuery
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	version := query.Version
+	if version == 0 {
+		version = domain.HookPayloadV1
+	}
+
+	samples, err := h.hookService.Samples(r.Context(), userID, query.Event, version)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, samples)
+}
+
+// Deliveries handles listing the recent delivery attempts of a hook subscription
+func (h *HookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	hookID, err := parseHookID(r)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query listHookDeliveriesQuery
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	limit := service.DefaultPageLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	attempts, err := h.hookService.ListDeliveries(r.Context(), userID, hookID, query.Failed, limit)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, attempts)
+}
+
+// Redeliver handles sending a past delivery attempt of a hook subscription again
+func (h *HookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	hookID, err := parseHookID(r)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid delivery ID",
+			http.StatusBadRequest,
+			err,
+		))
+		return
+	}
+
+	attempt, err := h.hookService.Redeliver(r.Context(), userID, hookID, deliveryID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusCreated, attempt)
+}
+
+// parseHookID parses the hook ID in the URL of a request
+func parseHookID(r *http.Request) (uuid.UUID, error) {
+	hookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		return uuid.Nil, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid hook ID",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	return hookID, nil
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/whauzan/todo-api/internal/mail"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// MailPreviewHandler renders the email templates with sample data so they can
+// be checked in a browser. It is only routed in development.
+type MailPreviewHandler struct {
+	renderer *mail.Renderer
+	logger   *slog.Logger
+}
+
+// NewMailPreviewHandler creates a new MailPreviewHandler
+func NewMailPreviewHandler(renderer *mail.Renderer, logger *slog.Logger) *MailPreviewHandler {
+	return &MailPreviewHandler{
+		renderer: renderer,
+		logger:   logger,
+	}
+}
+
+// previewMailQuery holds the query parameters of an email preview
+type previewMailQuery struct {
+	Format   string `query:"format" validate:"omitempty,oneof=html text"`
+	Locale   string `query:"locale"`
+	Timezone string `query:"tz"`
+}
+
+// List handles listing the emails that can be previewed
+func (h *MailPreviewHandler) List(w http.ResponseWriter, r *http.Request) {
+	JSON(w, r, http.StatusOK, h.renderer.Templates())
+}
+
+// Preview handles rendering one email with sample data. The body is written
+// as is rather than in the JSON envelope so browsers display it directly.
+func (h *MailPreviewHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "template")
+	if !h.renderer.Has(name) {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Email template not found",
+			http.StatusNotFound,
+			nil,
+		))
+		return
+	}
+
+	var query previewMailQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	to := mail.Recipient{
+		Name:     "Jane Doe",
+		Email:    "jane@example.com",
+		Locale:   query.Locale,
+		Timezone: query.Timezone,
+	}
+	msg, err := h.renderer.Render(name, to, mail.SampleData(name, time.Now()))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.ErrInternal.WithCause(err))
+		return
+	}
+
+	w.Header().Set("X-Mail-Subject", msg.Subject)
+	if query.Format == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(msg.Text))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(msg.HTML))
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// NotificationHandler handles notification inbox requests
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	logger              *slog.Logger
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(notificationService *service.NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// listNotificationsQuery holds the query parameters of the notification list.
+// The limit bounds match service.MaxPageLimit.
+type listNotificationsQuery struct {
+	Limit  *int `query:"limit" validate:"omitempty,min=1,max=100"`
+	Unread bool `query:"unread"`
+}
+
+// List handles listing the authenticated user's notifications with their unread count
+func (h *NotificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query listNotificationsQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	limit := service.DefaultPageLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	inbox, err := h.notificationService.List(r.Context(), userID, query.Unread, limit)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, inbox)
+}
+
+// MarkRead handles marking one notification as read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	notificationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid notification ID",
+			http.StatusBadRequest,
+			err,
+		))
+		return
+	}
+
+	notification, err := h.notificationService.MarkRead(r.Context(), userID, notificationID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, notification)
+}
+
+// MarkAllRead handles marking every notification of the authenticated user as read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, map[string]int64{
+		"marked_read": count,
+	})
+}
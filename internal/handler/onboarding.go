@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// OnboardingHandler handles onboarding checklist requests
+type OnboardingHandler struct {
+	onboardingService *service.OnboardingService
+	logger            *slog.Logger
+}
+
+// NewOnboardingHandler creates a new OnboardingHandler
+func NewOnboardingHandler(onboardingService *service.OnboardingService, logger *slog.Logger) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+		logger:            logger,
+	}
+}
+
+// Get handles retrieving the authenticated user's onboarding progress
+func (h *OnboardingHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	onboarding, err := h.onboardingService.Get(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, onboarding)
+}
+
+// Update handles marking onboarding steps as completed or not completed
+func (h *OnboardingHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.UpdateOnboardingRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	onboarding, err := h.onboardingService.Update(r.Context(), userID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, onboarding)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// bindQuery fills the fields of the struct pointed to by dst from the request's
+// query parameters, using each field's `query` tag as the parameter name, and then
+// validates the struct. Supported field types are strings, integers, booleans,
+// pointers to those (nil when the parameter is absent), and string slices for
+// repeated parameters. Every unparseable value is reported as a validation detail.
+func bindQuery(r *http.Request, dst interface{}) error {
+	query := r.URL.Query()
+
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	var details []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" || !query.Has(name) {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.String {
+			fv.Set(reflect.ValueOf(query[name]))
+			continue
+		}
+
+		if fv.Kind() == reflect.Pointer {
+			ptr := reflect.New(fv.Type().Elem())
+			if msg := setQueryValue(ptr.Elem(), query.Get(name)); msg != "" {
+				details = append(details, fmt.Sprintf("%s: %s", name, msg))
+				continue
+			}
+			fv.Set(ptr)
+			continue
+		}
+
+		if msg := setQueryValue(fv, query.Get(name)); msg != "" {
+			details = append(details, fmt.Sprintf("%s: %s", name, msg))
+		}
+	}
+
+	if len(details) > 0 {
+		return apperror.ErrValidation.WithDetails(details...)
+	}
+
+	return validateStruct(dst)
+}
+
+// setQueryValue parses raw into fv and returns a validation message on failure
+func setQueryValue(fv reflect.Value, raw string) string {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, fv.Type().Bits())
+		if err != nil {
+			return "must be a non-negative integer"
+		}
+		fv.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "must be true or false"
+		}
+		fv.SetBool(b)
+	default:
+		panic(fmt.Sprintf("bindQuery: unsupported field type %s", fv.Type()))
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// Report formats accepted by the admin report endpoints
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+)
+
+// ContentTypeCSV is the media type of CSV reports
+const ContentTypeCSV = "text/csv; charset=utf-8"
+
+// usageReportQuery holds the query parameters of a usage report
+type usageReportQuery struct {
+	Period string `query:"period" validate:"omitempty,oneof=daily weekly"`
+	From   string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To     string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Format string `query:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// usageReportColumns is the header row of CSV usage reports
+var usageReportColumns = []string{"period_start", "new_users", "active_users", "todos_created", "todos_completed", "churned_users"}
+
+// ReportHandler handles administrative report requests
+type ReportHandler struct {
+	reportService *service.ReportService
+	logger        *slog.Logger
+}
+
+// NewReportHandler creates a new ReportHandler
+func NewReportHandler(reportService *service.ReportService, logger *slog.Logger) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// Usage handles retrieving the usage and growth report as JSON or CSV
+func (h *ReportHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	var query usageReportQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	report, err := h.reportService.Usage(r.Context(), &domain.UsageReportRequest{
+		Period: domain.ReportPeriod(query.Period),
+		From:   query.From,
+		To:     query.To,
+	})
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if query.Format != ReportFormatCSV {
+		JSON(w, r, http.StatusOK, report)
+		return
+	}
+
+	// The To date in the file name is the last day covered, not the exclusive end
+	filename := fmt.Sprintf("usage-%s-%s-%s.csv", report.Period,
+		report.From.Format(time.DateOnly), report.To.AddDate(0, 0, -1).Format(time.DateOnly))
+	w.Header().Set("Content-Type", ContentTypeCSV)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeUsageCSV(w, report); err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to write usage report", "error", err)
+	}
+}
+
+// writeUsageCSV writes one row per report period after a header row
+func writeUsageCSV(w io.Writer, report *domain.UsageReport) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(usageReportColumns); err != nil {
+		return err
+	}
+
+	for _, p := range report.Periods {
+		record := []string{
+			p.Start.Format(time.DateOnly),
+			strconv.FormatInt(p.NewUsers, 10),
+			strconv.FormatInt(p.ActiveUsers, 10),
+			strconv.FormatInt(p.TodosCreated, 10),
+			strconv.FormatInt(p.TodosCompleted, 10),
+			strconv.FormatInt(p.ChurnedUsers, 10),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
@@ -5,13 +5,53 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/pgerror"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator creates the validator used for request structs
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// Validate Optional fields by their value; absent and null fields validate as empty
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		if opt, ok := field.Interface().(interface{ ValidationValue() interface{} }); ok {
+			return opt.ValidationValue()
+		}
+		return nil
+	}, domain.Optional[string]{}, domain.Optional[bool]{}, domain.Optional[[]byte]{})
+
+	// Report fields by the name the client used: the query parameter or JSON key
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		if name := field.Tag.Get("query"); name != "" {
+			return name
+		}
+		if name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]; name != "" && name != "-" {
+			return name
+		}
+		return field.Name
+	})
+
+	return v
+}
+
+const (
+	// ContentTypeNDJSON is the media type for newline-delimited JSON streams
+	ContentTypeNDJSON = "application/x-ndjson"
+
+	// ndjsonFlushEvery is the number of streamed records between flushes
+	ndjsonFlushEvery = 100
+)
 
 // Response is the standard envelope for all API responses
 type Response struct {
@@ -30,8 +70,9 @@ type ErrorInfo struct {
 
 // Meta contains optional metadata like pagination and request tracking
 type Meta struct {
-	RequestID  string      `json:"request_id,omitempty"`
-	Pagination *Pagination `json:"pagination,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Pagination *Pagination       `json:"pagination,omitempty"`
+	Cursor     *CursorPagination `json:"cursor,omitempty"`
 }
 
 // Pagination contains pagination information for list responses
@@ -42,30 +83,54 @@ type Pagination struct {
 	TotalPages int `json:"total_pages"`
 }
 
+// CursorPagination contains cursor information for keyset-paginated list responses
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
 // JSON sends a success response with data
-func JSON(w http.ResponseWriter, status int, data interface{}) {
+func JSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	JSONWithMeta(w, r, status, data, nil)
+}
+
+// JSONWithMeta sends a success response with data and metadata
+func JSONWithMeta(w http.ResponseWriter, r *http.Request, status int, data interface{}, meta *Meta) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(Response{
 		Success: true,
 		Data:    data,
+		Meta:    withRequestID(r, meta),
 	}); err != nil {
 		// If encoding fails, there's not much we can do at this point
-		slog.Error("failed to encode response", "error", err)
+		slog.ErrorContext(r.Context(), "failed to encode response", "error", err)
 	}
 }
 
-// JSONWithMeta sends a success response with data and metadata
-func JSONWithMeta(w http.ResponseWriter, status int, data interface{}, meta *Meta) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(Response{
-		Success: true,
-		Data:    data,
-		Meta:    meta,
-	}); err != nil {
-		slog.Error("failed to encode response with meta", "error", err)
+// NoContent sends 204 No Content for an action with nothing to return. API
+// versions configured for legacy responses get 200 with message instead.
+func NoContent(w http.ResponseWriter, r *http.Request, message string) {
+	if !middleware.NoContentEnabled(r.Context()) {
+		JSON(w, r, http.StatusOK, map[string]string{"message": message})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withRequestID returns meta with the request ID of r set, so every response
+// can be correlated with the server logs. meta may be nil.
+func withRequestID(r *http.Request, meta *Meta) *Meta {
+	requestID := middleware.GetRequestID(r.Context())
+	if requestID == "" {
+		return meta
+	}
+	if meta == nil {
+		meta = &Meta{}
 	}
+	meta.RequestID = requestID
+	return meta
 }
 
 // JSONError sends an error response from AppError
@@ -73,17 +138,25 @@ func JSONError(w http.ResponseWriter, logger *slog.Logger, r *http.Request, err
 	appErr, ok := err.(*apperror.AppError)
 	if !ok {
 		// If it's not an AppError, treat it as internal server error
-		logger.ErrorContext(r.Context(), "unexpected error", "error", err)
-		appErr = apperror.ErrInternal
+		appErr = apperror.ErrInternal.WithCause(err)
+	}
+
+	// Constraint violations from any repository are client errors, not internal ones
+	if appErr.Code == apperror.CodeInternal {
+		if translated := pgerror.Translate(appErr.Err); translated != nil {
+			appErr = translated
+		}
 	}
 
-	// Log errors that are not client errors
+	// Log errors that are not client errors once, with the context gathered on the way up
 	if appErr.Status >= 500 {
-		logger.ErrorContext(r.Context(), "server error",
+		attrs := []any{
 			"error", appErr.Error(),
 			"code", appErr.Code,
 			"status", appErr.Status,
-		)
+		}
+		attrs = append(attrs, errctx.LogAttrs(err)...)
+		logger.ErrorContext(r.Context(), "server error", attrs...)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -95,13 +168,14 @@ func JSONError(w http.ResponseWriter, logger *slog.Logger, r *http.Request, err
 			Message: appErr.Message,
 			Details: appErr.Details,
 		},
+		Meta: withRequestID(r, nil),
 	}); err != nil {
 		logger.ErrorContext(r.Context(), "failed to encode error response", "error", err)
 	}
 }
 
 // JSONErrorWithStatus sends an error response with custom status
-func JSONErrorWithStatus(w http.ResponseWriter, status int, code, message string, details []string) {
+func JSONErrorWithStatus(w http.ResponseWriter, r *http.Request, status int, code, message string, details []string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(Response{
@@ -111,11 +185,25 @@ func JSONErrorWithStatus(w http.ResponseWriter, status int, code, message string
 			Message: message,
 			Details: details,
 		},
+		Meta: withRequestID(r, nil),
 	}); err != nil {
-		slog.Error("failed to encode error response", "error", err)
+		slog.ErrorContext(r.Context(), "failed to encode error response", "error", err)
 	}
 }
 
+// acceptsNDJSON reports whether the client asked for a newline-delimited JSON stream
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+			if strings.EqualFold(mediaType, ContentTypeNDJSON) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // decodeJSON decodes a JSON request body
 func decodeJSON(r *http.Request, v interface{}) error {
 	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
@@ -153,12 +241,32 @@ func formatValidationErrors(errs validator.ValidationErrors) []string {
 		case "email":
 			details = append(details, fmt.Sprintf("%s: must be a valid email", field))
 		case "min":
-			details = append(details, fmt.Sprintf("%s: must be at least %s characters", field, e.Param()))
+			details = append(details, fmt.Sprintf("%s: must be at least %s%s", field, e.Param(), lengthUnit(e.Type())))
 		case "max":
-			details = append(details, fmt.Sprintf("%s: must be at most %s characters", field, e.Param()))
+			details = append(details, fmt.Sprintf("%s: must be at most %s%s", field, e.Param(), lengthUnit(e.Type())))
+		case "oneof":
+			details = append(details, fmt.Sprintf("%s: must be one of %s", field, strings.ReplaceAll(e.Param(), " ", ", ")))
+		case "datetime":
+			if e.Param() == time.DateOnly {
+				details = append(details, fmt.Sprintf("%s: must be a date in YYYY-MM-DD format", field))
+			} else {
+				details = append(details, fmt.Sprintf("%s: must match the layout %s", field, e.Param()))
+			}
 		default:
 			details = append(details, fmt.Sprintf("%s: failed %s validation", field, e.Tag()))
 		}
 	}
 	return details
 }
+
+// lengthUnit returns the unit for min and max messages: strings are measured
+// in characters and byte slices in bytes
+func lengthUnit(typ reflect.Type) string {
+	switch {
+	case typ.Kind() == reflect.String:
+		return " characters"
+	case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return " bytes"
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// longPollWriteSlack is the time a long poll has to write its response after
+// waiting
+const longPollWriteSlack = 10 * time.Second
+
+// SyncHandler handles offline sync requests
+type SyncHandler struct {
+	syncService *service.SyncService
+	maxWait     time.Duration
+	logger      *slog.Logger
+}
+
+// NewSyncHandler creates a new SyncHandler. maxWait bounds how long a change
+// long poll waits.
+func NewSyncHandler(syncService *service.SyncService, maxWait time.Duration, logger *slog.Logger) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+		maxWait:     maxWait,
+		logger:      logger,
+	}
+}
+
+// changesQuery represents the query parameters of a change long poll
+type changesQuery struct {
+	Since string `query:"since"`
+	Wait  *int   `query:"wait" validate:"omitempty,min=0"`
+}
+
+// Sync handles a delta-sync exchange with a client
+func (h *SyncHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.SyncRequest
+
+	// Decode request body
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Validate request
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Sync changes
+	resp, err := h.syncService.Sync(r.Context(), userID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return server changes and the new sync token with envelope
+	JSON(w, r, http.StatusOK, resp)
+}
+
+// feedQuery represents the query parameters of the change feed
+type feedQuery struct {
+	Since int64 `query:"since" validate:"min=0"`
+	Limit int   `query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+// defaultFeedLimit is the page size of the change feed when none is given
+const defaultFeedLimit = 100
+
+// Feed handles reading the user's change feed after a sequence number
+func (h *SyncHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query feedQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultFeedLimit
+	}
+
+	feed, err := h.syncService.Feed(r.Context(), userID, query.Since, limit)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return the page of changes with envelope
+	JSON(w, r, http.StatusOK, feed)
+}
+
+// Changes handles a long poll for server-side changes since a sync token. It
+// answers as soon as there are changes, or with none after the wait (in
+// seconds, default and at most the configured maximum).
+func (h *SyncHandler) Changes(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query changesQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Compare in seconds, as a large wait would overflow once converted
+	wait := h.maxWait
+	if query.Wait != nil && time.Duration(*query.Wait) < h.maxWait/time.Second {
+		wait = time.Duration(*query.Wait) * time.Second
+	}
+
+	// Every answer depends on when it was asked
+	w.Header().Set("Cache-Control", "no-store")
+
+	// The wait may outlast the server's write timeout; extending the deadline
+	// is best effort, as not every writer supports it
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + longPollWriteSlack))
+
+	resp, err := h.syncService.Changes(r.Context(), userID, query.Since, wait)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return server changes and the new sync token with envelope
+	JSON(w, r, http.StatusOK, resp)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
 
@@ -9,20 +10,25 @@ import (
 	"github.com/whauzan/todo-api/internal/domain"
 	"github.com/whauzan/todo-api/internal/middleware"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/mdtext"
 	"github.com/whauzan/todo-api/internal/service"
 )
 
 // TodoHandler handles todo requests
 type TodoHandler struct {
-	todoService *service.TodoService
-	logger      *slog.Logger
+	todoService   *service.TodoService
+	previewLength int
+	logger        *slog.Logger
 }
 
-// NewTodoHandler creates a new TodoHandler
-func NewTodoHandler(todoService *service.TodoService, logger *slog.Logger) *TodoHandler {
+// NewTodoHandler creates a new TodoHandler. previewLength is the most
+// characters of a description preview in lists.
+func NewTodoHandler(todoService *service.TodoService, previewLength int, logger *slog.Logger) *TodoHandler {
 	return &TodoHandler{
-		todoService: todoService,
-		logger:      logger,
+		todoService:   todoService,
+		previewLength: previewLength,
+		logger:        logger,
 	}
 }
 
@@ -50,14 +56,20 @@ func (h *TodoHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create todo
-	todo, err := h.todoService.Create(r.Context(), userID, &req)
+	todo, created, err := h.todoService.Create(r.Context(), userID, &req)
 	if err != nil {
 		JSONError(w, h.logger, r, err)
 		return
 	}
 
+	// A deduplicated retry returns the existing todo with 200 instead of 201
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+
 	// Return created todo with envelope
-	JSON(w, http.StatusCreated, todo)
+	JSON(w, r, status, todo)
 }
 
 // List handles listing all todos for a user
@@ -69,15 +81,192 @@ func (h *TodoHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var query listTodosQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Stream todos one per line when the client asks for NDJSON
+	if acceptsNDJSON(r) {
+		h.streamNDJSON(w, r, userID, &query)
+		return
+	}
+
+	// Paginate by cursor when the client asks for a page
+	if query.Limit != nil || r.URL.Query().Has("cursor") {
+		h.listPage(w, r, userID, &query)
+		return
+	}
+
+	if query.View == listViewSummary {
+		summaries, err := h.todoService.ListSummaries(r.Context(), userID)
+		if err != nil {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+
+		// Return todo summaries with envelope
+		JSON(w, r, http.StatusOK, summaries)
+		return
+	}
+
 	// List todos
 	todos, err := h.todoService.List(r.Context(), userID)
 	if err != nil {
 		JSONError(w, h.logger, r, err)
 		return
 	}
+	if query.Preview {
+		h.setPreviews(todos)
+	}
 
 	// Return todos with envelope
-	JSON(w, http.StatusOK, todos)
+	JSON(w, r, http.StatusOK, todos)
+}
+
+// listViewSummary lists todo summaries instead of whole todos
+const listViewSummary = "summary"
+
+// listTodosQuery holds the query parameters of a todo list. Limit and cursor
+// paginate it; the limit bounds match service.MaxPageLimit. Previews only
+// apply to the full view, as summaries have no description.
+type listTodosQuery struct {
+	Limit   *int   `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor  string `query:"cursor"`
+	View    string `query:"view" validate:"omitempty,oneof=summary full"`
+	Preview bool   `query:"preview"`
+}
+
+// setPreviews replaces the description of each plaintext todo with a short
+// plain-text preview, so list views need not download whole descriptions
+func (h *TodoHandler) setPreviews(todos []*domain.Todo) {
+	for _, todo := range todos {
+		h.setPreview(todo)
+	}
+}
+
+// setPreview replaces the description of a plaintext todo with its preview
+func (h *TodoHandler) setPreview(todo *domain.Todo) {
+	if todo.Description == nil {
+		return
+	}
+	preview := mdtext.Preview(*todo.Description, h.previewLength)
+	todo.Preview = &preview
+	todo.Description = nil
+}
+
+// listPage handles cursor-paginated listing via the limit and cursor query parameters
+func (h *TodoHandler) listPage(w http.ResponseWriter, r *http.Request, userID uuid.UUID, query *listTodosQuery) {
+	limit := service.DefaultPageLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	var after *domain.TodoCursor
+	if query.Cursor != "" {
+		cursor, err := domain.DecodeTodoCursor(query.Cursor)
+		if err != nil {
+			JSONError(w, h.logger, r, apperror.NewAppError(
+				apperror.CodeBadRequest,
+				"Invalid cursor",
+				http.StatusBadRequest,
+				err,
+			))
+			return
+		}
+		after = cursor
+	}
+
+	var (
+		todos any
+		next  *domain.TodoCursor
+	)
+	if query.View == listViewSummary {
+		page, err := h.todoService.ListSummaryPage(r.Context(), userID, after, limit)
+		if err != nil {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+		todos, next = page.Todos, page.NextCursor
+	} else {
+		page, err := h.todoService.ListPage(r.Context(), userID, after, limit)
+		if err != nil {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+		if query.Preview {
+			h.setPreviews(page.Todos)
+		}
+		todos, next = page.Todos, page.NextCursor
+	}
+
+	cursorMeta := &CursorPagination{Limit: limit}
+	if next != nil {
+		cursorMeta.NextCursor = next.Encode()
+		cursorMeta.HasMore = true
+	}
+
+	// Return the page with cursor metadata
+	JSONWithMeta(w, r, http.StatusOK, todos, &Meta{Cursor: cursorMeta})
+}
+
+// streamNDJSON writes the user's todos as newline-delimited JSON without buffering them
+func (h *TodoHandler) streamNDJSON(w http.ResponseWriter, r *http.Request, userID uuid.UUID, query *listTodosQuery) {
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	count := 0
+
+	write := func(v any) error {
+		// Defer the header until the first row so early errors still get an envelope
+		if count == 0 {
+			w.Header().Set("Content-Type", ContentTypeNDJSON)
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+
+		count++
+		if count%ndjsonFlushEvery == 0 {
+			// Flushing is best effort; not every writer supports it
+			_ = rc.Flush()
+		}
+		return nil
+	}
+
+	var err error
+	if query.View == listViewSummary {
+		err = h.todoService.StreamSummaries(r.Context(), userID, func(summary *domain.TodoSummary) error {
+			return write(summary)
+		})
+	} else {
+		err = h.todoService.Stream(r.Context(), userID, func(todo *domain.Todo) error {
+			if query.Preview {
+				h.setPreview(todo)
+			}
+			return write(todo)
+		})
+	}
+
+	if err != nil {
+		if count == 0 {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+		// Headers are already sent; the truncated stream is all the client gets
+		attrs := append([]any{"error", err, "written", count}, errctx.LogAttrs(err)...)
+		h.logger.ErrorContext(r.Context(), "todo stream interrupted", attrs...)
+		return
+	}
+
+	if count == 0 {
+		w.Header().Set("Content-Type", ContentTypeNDJSON)
+		w.WriteHeader(http.StatusOK)
+	}
 }
 
 // GetByID handles getting a single todo
@@ -110,7 +299,52 @@ func (h *TodoHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return todo with envelope
-	JSON(w, http.StatusOK, todo)
+	JSON(w, r, http.StatusOK, todo)
+}
+
+// Clone handles copying a todo into a new open todo
+func (h *TodoHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Get todo ID from URL
+	todoID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid todo ID",
+			http.StatusBadRequest,
+			err,
+		))
+		return
+	}
+
+	// The body is optional; an empty one clones with a server-generated ID
+	var req domain.CloneTodoRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			JSONError(w, h.logger, r, err)
+			return
+		}
+	}
+
+	todo, created, err := h.todoService.Clone(r.Context(), userID, todoID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// A deduplicated retry returns the existing copy with 200 instead of 201
+	status := http.StatusCreated
+	if !created {
+		status = http.StatusOK
+	}
+
+	JSON(w, r, status, todo)
 }
 
 // Update handles updating a todo
@@ -135,6 +369,12 @@ func (h *TodoHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Patch documents carry their own semantics (null clears a field)
+	if contentType := patchContentType(r); contentType != "" {
+		h.patch(w, r, userID, todoID, contentType)
+		return
+	}
+
 	var req domain.UpdateTodoRequest
 
 	// Decode request body
@@ -157,7 +397,7 @@ func (h *TodoHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return updated todo with envelope
-	JSON(w, http.StatusOK, todo)
+	JSON(w, r, http.StatusOK, todo)
 }
 
 // Delete handles deleting a todo
@@ -188,8 +428,5 @@ func (h *TodoHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return success message with envelope
-	JSON(w, http.StatusOK, map[string]string{
-		"message": "Todo deleted successfully",
-	})
+	NoContent(w, r, "Todo deleted successfully")
 }
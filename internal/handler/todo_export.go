@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+)
+
+// Export formats accepted by GET /todos/export
+const (
+	ExportFormatMarkdown = "markdown"
+)
+
+// ContentTypeMarkdown is the media type of Markdown exports
+const ContentTypeMarkdown = "text/markdown; charset=utf-8"
+
+// exportTodosQuery holds the query parameters of a todo export
+type exportTodosQuery struct {
+	Format string `query:"format" validate:"omitempty,oneof=markdown"`
+}
+
+// markdownEscaper escapes characters that Markdown would otherwise treat as markup
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"#", `\#`,
+	"\r\n", " ",
+	"\n", " ",
+)
+
+// Export handles downloading the user's todos as a printable document
+func (h *TodoHandler) Export(w http.ResponseWriter, r *http.Request) {
+	// Get user ID from context
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query exportTodosQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	todos, err := h.todoService.List(r.Context(), userID)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	w.Header().Set("Content-Type", ContentTypeMarkdown)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="todos-%s.md"`, now.Format(time.DateOnly)))
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeMarkdown(w, todos, now); err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to write todo export", "error", err, "user_id", userID)
+	}
+}
+
+// writeMarkdown writes todos as a Markdown checklist with open todos first.
+// Todos are listed newest first within each section, as the repository returns them.
+func writeMarkdown(out io.Writer, todos []*domain.Todo, now time.Time) error {
+	var open, completed []*domain.Todo
+	for _, todo := range todos {
+		if todo.Completed {
+			completed = append(completed, todo)
+		} else {
+			open = append(open, todo)
+		}
+	}
+
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "# Todos\n\nExported %s\n", now.Format(time.DateOnly))
+	writeMarkdownSection(w, "Open", open)
+	writeMarkdownSection(w, "Completed", completed)
+	return w.Flush()
+}
+
+// writeMarkdownSection writes one heading and its checklist
+func writeMarkdownSection(w *bufio.Writer, heading string, todos []*domain.Todo) {
+	fmt.Fprintf(w, "\n## %s (%d)\n\n", heading, len(todos))
+	if len(todos) == 0 {
+		w.WriteString("_Nothing here._\n")
+		return
+	}
+
+	for _, todo := range todos {
+		check := " "
+		if todo.Completed {
+			check = "x"
+		}
+
+		// The server cannot read end-to-end encrypted content
+		if todo.Encrypted {
+			fmt.Fprintf(w, "- [%s] _Encrypted todo_\n", check)
+			continue
+		}
+
+		fmt.Fprintf(w, "- [%s] %s\n", check, markdownEscaper.Replace(todo.Title))
+		if todo.Description != nil && *todo.Description != "" {
+			for _, line := range strings.Split(strings.ReplaceAll(*todo.Description, "\r\n", "\n"), "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/jsonpatch"
+)
+
+// maxPatchBytes limits the size of patch documents
+const maxPatchBytes = 1 << 20
+
+// todoDocument is the JSON representation of a todo that patches are applied to.
+// Its encrypted field is derived from title_ciphertext, so patches need not change it.
+type todoDocument struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	Title       *string   `json:"title" validate:"omitempty,max=255"`
+	Description *string   `json:"description" validate:"omitempty,max=2000"`
+	Completed   *bool     `json:"completed" validate:"required"`
+	Encrypted   bool      `json:"encrypted"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	TitleCiphertext       []byte `json:"title_ciphertext" validate:"omitempty,max=4096"`
+	DescriptionCiphertext []byte `json:"description_ciphertext" validate:"omitempty,max=16384"`
+}
+
+// patchContentType returns the patch media type of the request, or "" for plain JSON
+func patchContentType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	switch mediaType {
+	case jsonpatch.MergePatchContentType, jsonpatch.PatchContentType:
+		return mediaType
+	default:
+		return ""
+	}
+}
+
+// patch handles JSON Merge Patch and JSON Patch updates of a todo
+func (h *TodoHandler) patch(w http.ResponseWriter, r *http.Request, userID, todoID uuid.UUID, contentType string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		JSONError(w, h.logger, r, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid patch document",
+			http.StatusBadRequest,
+			err,
+		))
+		return
+	}
+
+	// Decode JSON Patch operations up front so malformed documents fail before any lookup
+	var ops []jsonpatch.Operation
+	if contentType == jsonpatch.PatchContentType {
+		ops, err = jsonpatch.DecodePatch(body)
+		if err != nil {
+			JSONError(w, h.logger, r, apperror.NewAppError(
+				apperror.CodeBadRequest,
+				"Invalid JSON Patch document",
+				http.StatusBadRequest,
+				err,
+			))
+			return
+		}
+	}
+
+	todo, err := h.todoService.Patch(r.Context(), userID, todoID, func(todo *domain.Todo) error {
+		current, err := json.Marshal(todo)
+		if err != nil {
+			return apperror.ErrInternal
+		}
+
+		var patched []byte
+		if contentType == jsonpatch.PatchContentType {
+			patched, err = jsonpatch.Apply(current, ops)
+		} else {
+			patched, err = jsonpatch.MergePatch(current, body)
+		}
+		if err != nil {
+			return patchError(err)
+		}
+
+		return applyTodoDocument(todo, patched)
+	})
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	// Return updated todo with envelope
+	JSON(w, r, http.StatusOK, todo)
+}
+
+// applyTodoDocument validates a patched todo document and copies its editable fields onto todo
+func applyTodoDocument(todo *domain.Todo, patched []byte) error {
+	var doc todoDocument
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return apperror.ErrValidation.WithDetails("patch produces an invalid todo: " + err.Error())
+	}
+
+	if err := validateStruct(&doc); err != nil {
+		return err
+	}
+
+	var details []string
+	if doc.ID != todo.ID {
+		details = append(details, "id: is read-only")
+	}
+	if doc.UserID != todo.UserID {
+		details = append(details, "user_id: is read-only")
+	}
+	if !doc.CreatedAt.Equal(todo.CreatedAt) {
+		details = append(details, "created_at: is read-only")
+	}
+	if !doc.UpdatedAt.Equal(todo.UpdatedAt) {
+		details = append(details, "updated_at: is read-only")
+	}
+	if len(details) > 0 {
+		return apperror.ErrValidation.WithDetails(details...)
+	}
+
+	todo.Title = ""
+	if doc.Title != nil {
+		todo.Title = *doc.Title
+	}
+	todo.Description = doc.Description
+	todo.Completed = *doc.Completed
+	todo.Encrypted = len(doc.TitleCiphertext) > 0
+	todo.TitleCiphertext = doc.TitleCiphertext
+	todo.DescriptionCiphertext = doc.DescriptionCiphertext
+
+	return nil
+}
+
+// patchError converts a patch application error into an AppError
+func patchError(err error) error {
+	if errors.Is(err, jsonpatch.ErrTestFailed) {
+		return apperror.NewAppError(
+			apperror.CodeConflict,
+			"Patch test operation failed",
+			http.StatusConflict,
+			err,
+		)
+	}
+	return apperror.NewAppError(
+		apperror.CodeBadRequest,
+		"Patch could not be applied",
+		http.StatusBadRequest,
+		err,
+	).WithDetails(err.Error())
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/middleware"
+	"github.com/whauzan/todo-api/internal/service"
+)
+
+// WidgetHandler handles widget token and embedded todo list requests
+type WidgetHandler struct {
+	widgetService *service.WidgetService
+	logger        *slog.Logger
+}
+
+// NewWidgetHandler creates a new WidgetHandler
+func NewWidgetHandler(widgetService *service.WidgetService, logger *slog.Logger) *WidgetHandler {
+	return &WidgetHandler{
+		widgetService: widgetService,
+		logger:        logger,
+	}
+}
+
+// listWidgetTodosQuery holds the query parameters of the widget todo list.
+// The limit bounds match service.MaxWidgetLimit.
+type listWidgetTodosQuery struct {
+	Limit *int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// CreateToken handles issuing a widget token for the authenticated user
+func (h *WidgetHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var req domain.CreateWidgetTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	if err := validateStruct(&req); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	token, err := h.widgetService.IssueToken(r.Context(), userID, &req)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusCreated, token)
+}
+
+// ListTodos handles listing the open todos shown by an embedded widget
+func (h *WidgetHandler) ListTodos(w http.ResponseWriter, r *http.Request) {
+	userID, err := middleware.GetUserID(r.Context())
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	var query listWidgetTodosQuery
+
+	// Bind and validate query parameters
+	if err := bindQuery(r, &query); err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	limit := service.DefaultWidgetLimit
+	if query.Limit != nil {
+		limit = *query.Limit
+	}
+
+	todos, err := h.widgetService.ListTodos(r.Context(), userID, limit)
+	if err != nil {
+		JSONError(w, h.logger, r, err)
+		return
+	}
+
+	JSON(w, r, http.StatusOK, todos)
+}
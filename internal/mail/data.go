@@ -0,0 +1,64 @@
+package mail
+
+import "time"
+
+// VerificationData is the payload of the verification email
+type VerificationData struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// PasswordResetData is the payload of the password reset email
+type PasswordResetData struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// DigestTodo is one open todo listed in a digest
+type DigestTodo struct {
+	Title     string
+	CreatedAt time.Time
+}
+
+// DigestData is the payload of the digest email. Open lists at most a handful
+// of todos; OpenCount is the full number of open todos.
+type DigestData struct {
+	Date           time.Time
+	Open           []DigestTodo
+	OpenCount      int
+	CompletedCount int
+	AppURL         string
+}
+
+// More returns how many open todos are not listed
+func (d DigestData) More() int {
+	return d.OpenCount - len(d.Open)
+}
+
+// SampleData returns example payloads for previewing each email
+func SampleData(name string, now time.Time) any {
+	switch name {
+	case TemplateVerification:
+		return VerificationData{
+			URL:       "https://example.com/verify?token=sample",
+			ExpiresAt: now.Add(24 * time.Hour),
+		}
+	case TemplatePasswordReset:
+		return PasswordResetData{
+			URL:       "https://example.com/reset-password?token=sample",
+			ExpiresAt: now.Add(time.Hour),
+		}
+	case TemplateDigest:
+		return DigestData{
+			Date: now,
+			Open: []DigestTodo{
+				{Title: "Buy groceries", CreatedAt: now.Add(-26 * time.Hour)},
+				{Title: "Renew passport <before June>", CreatedAt: now.Add(-72 * time.Hour)},
+			},
+			OpenCount:      5,
+			CompletedCount: 3,
+			AppURL:         "https://example.com",
+		}
+	}
+	return nil
+}
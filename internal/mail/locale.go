@@ -0,0 +1,144 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultLocale is used for recipients without a supported locale and for
+// messages missing from another locale's catalog
+const defaultLocale = "en"
+
+// catalogs holds the translated strings of each supported locale, keyed by
+// message ID. Values are fmt format strings.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"greeting":               "Hi %s,",
+		"footer":                 "You are receiving this email because you have a Todo API account.",
+		"verification.subject":   "Verify your email address",
+		"verification.intro":     "Confirm your email address to finish setting up your account.",
+		"verification.action":    "Verify email",
+		"verification.expires":   "This link expires on %s.",
+		"verification.ignore":    "If you did not create an account, you can ignore this email.",
+		"password_reset.subject": "Reset your password",
+		"password_reset.intro":   "We received a request to reset your password.",
+		"password_reset.action":  "Reset password",
+		"password_reset.expires": "This link expires on %s.",
+		"password_reset.ignore":  "If you did not ask to reset your password, you can ignore this email; your password will not change.",
+		"digest.subject":         "Your todos for %s",
+		"digest.summary":         "You have %d open todos and completed %d since your last digest.",
+		"digest.open_heading":    "Still open",
+		"digest.created":         "added %s",
+		"digest.none":            "Nothing open. Enjoy your day!",
+		"digest.more":            "and %d more",
+		"digest.open_app":        "Open Todo API",
+	},
+	"id": {
+		"greeting":               "Halo %s,",
+		"footer":                 "Anda menerima email ini karena memiliki akun Todo API.",
+		"verification.subject":   "Verifikasi alamat email Anda",
+		"verification.intro":     "Konfirmasi alamat email Anda untuk menyelesaikan pembuatan akun.",
+		"verification.action":    "Verifikasi email",
+		"verification.expires":   "Tautan ini berlaku hingga %s.",
+		"verification.ignore":    "Jika Anda tidak membuat akun, abaikan email ini.",
+		"password_reset.subject": "Atur ulang kata sandi Anda",
+		"password_reset.intro":   "Kami menerima permintaan untuk mengatur ulang kata sandi Anda.",
+		"password_reset.action":  "Atur ulang kata sandi",
+		"password_reset.expires": "Tautan ini berlaku hingga %s.",
+		"password_reset.ignore":  "Jika Anda tidak meminta pengaturan ulang kata sandi, abaikan email ini; kata sandi Anda tidak akan berubah.",
+		"digest.subject":         "Todo Anda untuk %s",
+		"digest.summary":         "Anda memiliki %d todo terbuka dan telah menyelesaikan %d sejak ringkasan terakhir.",
+		"digest.open_heading":    "Masih terbuka",
+		"digest.created":         "ditambahkan %s",
+		"digest.none":            "Tidak ada yang terbuka. Selamat menikmati hari Anda!",
+		"digest.more":            "dan %d lainnya",
+		"digest.open_app":        "Buka Todo API",
+	},
+}
+
+// dateFormats describes how each locale writes dates
+var dateFormats = map[string]struct {
+	date  func(t time.Time) string
+	clock string
+}{
+	"en": {
+		date:  func(t time.Time) string { return t.Format("January 2, 2006") },
+		clock: "3:04 PM MST",
+	},
+	"id": {
+		date: func(t time.Time) string {
+			return fmt.Sprintf("%d %s %d", t.Day(), indonesianMonths[t.Month()-1], t.Year())
+		},
+		clock: "15.04 MST",
+	},
+}
+
+var indonesianMonths = []string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// resolveLocale maps a locale tag such as "id-ID" to a supported catalog
+func resolveLocale(tag string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(strings.ReplaceAll(tag, "_", "-")), "-")
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return defaultLocale
+}
+
+// resolveTimezone loads an IANA timezone name, falling back to UTC
+func resolveTimezone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// newFuncs returns the template functions for a locale and timezone:
+// t translates a message ID, date and datetime format a time in the timezone,
+// and button builds the argument of the layout's button template.
+// A nil timezone means UTC.
+func newFuncs(locale string, tz *time.Location) map[string]any {
+	if tz == nil {
+		tz = time.UTC
+	}
+	formats := dateFormats[locale]
+
+	return map[string]any{
+		"t": func(key string, args ...any) string {
+			msg, ok := catalogs[locale][key]
+			if !ok {
+				msg, ok = catalogs[defaultLocale][key]
+			}
+			if !ok {
+				return key
+			}
+			if len(args) == 0 {
+				return msg
+			}
+			return fmt.Sprintf(msg, args...)
+		},
+		"date": func(t time.Time) string {
+			return formats.date(t.In(tz))
+		},
+		"datetime": func(t time.Time) string {
+			t = t.In(tz)
+			return formats.date(t) + " " + t.Format(formats.clock)
+		},
+		"button": func(url, label string) button {
+			return button{URL: url, Label: label}
+		},
+	}
+}
+
+// button is a call-to-action link rendered by the layout's button template
+type button struct {
+	URL   string
+	Label string
+}
@@ -0,0 +1,149 @@
+// Package mail renders transactional emails from templates embedded in the
+// binary. Every email has an HTML and a plain-text body, each wrapped in a
+// shared layout, and is localized to the recipient's locale and timezone.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Template names
+const (
+	TemplateVerification  = "verification"
+	TemplatePasswordReset = "password_reset"
+	TemplateDigest        = "digest"
+)
+
+// templateNames lists every email; each needs a .html and a .txt file in templates/
+var templateNames = []string{
+	TemplateVerification,
+	TemplatePasswordReset,
+	TemplateDigest,
+}
+
+// Recipient is who an email is rendered for. An unsupported locale falls back
+// to English and an unknown timezone to UTC.
+type Recipient struct {
+	Name     string
+	Email    string
+	Locale   string
+	Timezone string
+}
+
+// Message is a rendered email ready to hand to a sender
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// view is the data every template is executed with
+type view struct {
+	Recipient Recipient
+	Locale    string
+	Data      any
+}
+
+// Renderer renders the embedded email templates
+type Renderer struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewRenderer parses the embedded templates
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{
+		html: make(map[string]*htmltemplate.Template, len(templateNames)),
+		text: make(map[string]*texttemplate.Template, len(templateNames)),
+	}
+
+	// The real functions depend on the recipient and are bound at render time
+	funcs := newFuncs(defaultLocale, nil)
+
+	for _, name := range templateNames {
+		html, err := htmltemplate.New("layout.html").Funcs(htmltemplate.FuncMap(funcs)).
+			ParseFS(templateFiles, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s html template: %w", name, err)
+		}
+		text, err := texttemplate.New("layout.txt").Funcs(texttemplate.FuncMap(funcs)).
+			ParseFS(templateFiles, "templates/layout.txt", "templates/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template: %w", name, err)
+		}
+		r.html[name] = html
+		r.text[name] = text
+	}
+
+	return r, nil
+}
+
+// Templates returns the names of the emails the renderer can render
+func (r *Renderer) Templates() []string {
+	names := make([]string, 0, len(r.html))
+	for name := range r.html {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether name is a known email
+func (r *Renderer) Has(name string) bool {
+	_, ok := r.html[name]
+	return ok
+}
+
+// Render renders the named email for a recipient. data is the email-specific
+// payload, such as VerificationData for the verification email.
+func (r *Renderer) Render(name string, to Recipient, data any) (*Message, error) {
+	html, ok := r.html[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+	text := r.text[name]
+
+	locale := resolveLocale(to.Locale)
+	funcs := newFuncs(locale, resolveTimezone(to.Timezone))
+	v := view{Recipient: to, Locale: locale, Data: data}
+
+	// Templates are cloned so concurrent renders can bind their own functions
+	htmlClone, err := html.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s html template: %w", name, err)
+	}
+	textClone, err := text.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone %s text template: %w", name, err)
+	}
+	htmlClone.Funcs(htmltemplate.FuncMap(funcs))
+	textClone.Funcs(texttemplate.FuncMap(funcs))
+
+	var subject, htmlBody, textBody bytes.Buffer
+	if err := textClone.ExecuteTemplate(&subject, "subject", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := htmlClone.ExecuteTemplate(&htmlBody, "layout", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s html body: %w", name, err)
+	}
+	if err := textClone.ExecuteTemplate(&textBody, "layout", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s text body: %w", name, err)
+	}
+
+	return &Message{
+		To:      to.Email,
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    htmlBody.String(),
+		Text:    textBody.String(),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Access log formats
+const (
+	AccessLogFormatJSON   = "json"
+	AccessLogFormatCommon = "common"
+)
+
+// commonLogTimeFormat is the timestamp layout of the Common Log Format
+const commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLog is a middleware that writes one line per request to a dedicated
+// writer, separate from the application log, in JSON or Common Log Format
+type AccessLog struct {
+	out    io.Writer
+	format string
+	logger *slog.Logger
+
+	mu sync.Mutex
+}
+
+// accessLogEntry is a JSON access log line
+type accessLogEntry struct {
+	Time       string `json:"time"`
+	RemoteAddr string `json:"remote_addr"`
+	ClientIP   string `json:"client_ip"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Route      string `json:"route"`
+	Proto      string `json:"proto"`
+	Status     int    `json:"status"`
+	Bytes      int    `json:"bytes"`
+	DurationMS int64  `json:"duration_ms"`
+	RequestID  string `json:"request_id,omitempty"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	Referer    string `json:"referer,omitempty"`
+}
+
+// NewAccessLog creates a new AccessLog middleware writing to out in the given format
+func NewAccessLog(out io.Writer, format string, logger *slog.Logger) *AccessLog {
+	return &AccessLog{
+		out:    out,
+		format: format,
+		logger: logger,
+	}
+}
+
+// Handle writes an access log line after each request completes
+func (a *AccessLog) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := newResponseWriter(w)
+
+		next.ServeHTTP(wrapped, r)
+
+		var line []byte
+		if a.format == AccessLogFormatCommon {
+			line = a.commonLine(r, wrapped, start)
+		} else {
+			line = a.jsonLine(r, wrapped, start)
+		}
+
+		a.mu.Lock()
+		_, err := a.out.Write(line)
+		a.mu.Unlock()
+		if err != nil {
+			a.logger.ErrorContext(r.Context(), "failed to write access log", "error", err)
+		}
+	})
+}
+
+// jsonLine formats a request as a JSON object followed by a newline
+func (a *AccessLog) jsonLine(r *http.Request, rw *responseWriter, start time.Time) []byte {
+	line, _ := json.Marshal(accessLogEntry{
+		Time:       start.UTC().Format(time.RFC3339Nano),
+		RemoteAddr: r.RemoteAddr,
+		ClientIP:   requestClientIP(r),
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		Route:      routePattern(r),
+		Proto:      r.Proto,
+		Status:     rw.statusCode,
+		Bytes:      rw.written,
+		DurationMS: time.Since(start).Milliseconds(),
+		RequestID:  GetRequestID(r.Context()),
+		UserAgent:  r.UserAgent(),
+		Referer:    r.Referer(),
+	})
+	return append(line, '\n')
+}
+
+// commonLine formats a request in the Common Log Format:
+// host ident authuser [date] "request line" status bytes
+func (a *AccessLog) commonLine(r *http.Request, rw *responseWriter, start time.Time) []byte {
+	host := requestClientIP(r)
+
+	bytes := "-"
+	if rw.written > 0 {
+		bytes = fmt.Sprint(rw.written)
+	}
+
+	return []byte(fmt.Sprintf("%s - - [%s] %q %d %s\n",
+		host,
+		start.Format(commonLogTimeFormat),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		rw.statusCode,
+		bytes,
+	))
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// Admin is a middleware that protects administrative endpoints with a shared
+// bearer token, separate from user JWTs
+type Admin struct {
+	token  string
+	logger *slog.Logger
+}
+
+// NewAdmin creates a new Admin middleware for the given token
+func NewAdmin(token string, logger *slog.Logger) *Admin {
+	return &Admin{
+		token:  token,
+		logger: logger,
+	}
+}
+
+// Handle rejects requests that do not carry the admin token
+func (a *Admin) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			a.logger.WarnContext(r.Context(), "admin request rejected: invalid token", "path", r.URL.Path)
+			writeError(w, r, a.logger, apperror.ErrUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/analytics"
+)
+
+// apiPrefix is stripped from route patterns to name the feature a request used
+const apiPrefix = "/api/v1/"
+
+// Analytics is a middleware that records an anonymized event for each request
+// of users who have not opted out. It must run after Auth.Authenticate.
+type Analytics struct {
+	tracker *analytics.Tracker
+}
+
+// NewAnalytics creates a new Analytics middleware.
+// When tracker is nil, no events are recorded.
+func NewAnalytics(tracker *analytics.Tracker) *Analytics {
+	return &Analytics{tracker: tracker}
+}
+
+// Handle records the request after it completes
+func (a *Analytics) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.tracker == nil || TelemetryOptedOut(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		wrapped := newResponseWriter(w)
+
+		next.ServeHTTP(wrapped, r)
+
+		// The route pattern keeps IDs and other path values out of the event
+		endpoint := routePattern(r)
+		a.tracker.Track(analytics.Event{
+			Name:        analytics.EventAPIRequest,
+			AnonymousID: a.tracker.AnonymousID(userID),
+			Feature:     feature(endpoint),
+			Endpoint:    endpoint,
+			Method:      r.Method,
+			Status:      wrapped.statusCode,
+			LatencyMS:   time.Since(start).Milliseconds(),
+		})
+	})
+}
+
+// feature names the part of the API a route belongs to, such as "todos" for
+// /api/v1/todos/{id} or "users/me" for /api/v1/users/me/onboarding
+func feature(pattern string) string {
+	rest, ok := strings.CutPrefix(pattern, apiPrefix)
+	if !ok {
+		return pattern
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(segments) > 1 && segments[0] == "users" && segments[1] == "me" {
+		return "users/me"
+	}
+	return segments[0]
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// APIVersionKey is the context key for the API version of a route
+const APIVersionKey ContextKey = "api_version"
+
+// APIVersion is a middleware that records which API version a route belongs to,
+// along with the response conventions configured for that version
+type APIVersion struct {
+	name      string
+	noContent bool
+}
+
+// NewAPIVersion creates a new APIVersion middleware. When noContent is false,
+// actions with nothing to return respond 200 with a message instead of 204,
+// as older releases did.
+func NewAPIVersion(name string, noContent bool) *APIVersion {
+	return &APIVersion{
+		name:      name,
+		noContent: noContent,
+	}
+}
+
+// Handle adds the API version to the context
+func (v *APIVersion) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), APIVersionKey, v)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// NoContentEnabled reports whether the API version of the request answers
+// actions with nothing to return with 204 No Content. Routes outside a
+// versioned API always do.
+func NoContentEnabled(ctx context.Context) bool {
+	v, ok := ctx.Value(APIVersionKey).(*APIVersion)
+	return !ok || v.noContent
+}
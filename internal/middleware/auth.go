@@ -3,13 +3,16 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
 	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/repository"
 )
 
 // ContextKey is a custom type for context keys
@@ -20,18 +23,25 @@ const (
 	UserIDKey ContextKey = "user_id"
 	// UserEmailKey is the context key for user email
 	UserEmailKey ContextKey = "user_email"
+	// TelemetryOptOutKey is the context key for the user's analytics opt-out
+	TelemetryOptOutKey ContextKey = "telemetry_opt_out"
+	// ScopesKey is the context key for the scopes granted by the token
+	ScopesKey ContextKey = "scopes"
 )
 
-// Auth is a middleware that validates JWT tokens
+// Auth is a middleware that validates JWT tokens and rejects tokens of
+// accounts that are no longer active or that have since been revoked
 type Auth struct {
 	tokenManager *jwt.TokenManager
+	userRepo     repository.UserRepository
 	logger       *slog.Logger
 }
 
 // NewAuth creates a new Auth middleware
-func NewAuth(tokenManager *jwt.TokenManager, logger *slog.Logger) *Auth {
+func NewAuth(tokenManager *jwt.TokenManager, userRepo repository.UserRepository, logger *slog.Logger) *Auth {
 	return &Auth{
 		tokenManager: tokenManager,
+		userRepo:     userRepo,
 		logger:       logger,
 	}
 }
@@ -40,6 +50,7 @@ func NewAuth(tokenManager *jwt.TokenManager, logger *slog.Logger) *Auth {
 type Response struct {
 	Success bool       `json:"success"`
 	Error   *ErrorInfo `json:"error,omitempty"`
+	Meta    *Meta      `json:"meta,omitempty"`
 }
 
 // ErrorInfo contains structured error information
@@ -49,6 +60,24 @@ type ErrorInfo struct {
 	Details []string `json:"details,omitempty"`
 }
 
+// Meta carries the request ID of an error response
+type Meta struct {
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// responseMeta returns the Meta of a response to r, or nil without a request ID.
+// Middleware running before RequestID finds the ID in the response headers.
+func responseMeta(w http.ResponseWriter, r *http.Request) *Meta {
+	requestID := GetRequestID(r.Context())
+	if requestID == "" {
+		requestID = w.Header().Get(RequestIDHeader)
+	}
+	if requestID == "" {
+		return nil
+	}
+	return &Meta{RequestID: requestID}
+}
+
 // Authenticate validates the JWT token and adds user info to context
 func (a *Auth) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -86,15 +115,77 @@ func (a *Auth) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		// Tokens outlive suspensions, so the account status is checked on every request
+		user, err := a.userRepo.GetByID(r.Context(), claims.UserID)
+		if err != nil {
+			a.logger.ErrorContext(r.Context(), "failed to load user for authentication",
+				"error", err, "user_id", claims.UserID)
+			a.writeError(w, r, apperror.ErrInternal)
+			return
+		}
+		if user == nil {
+			a.writeError(w, r, apperror.ErrUnauthorized)
+			return
+		}
+		if !user.IsActive() {
+			a.logger.WarnContext(r.Context(), "request rejected: account not active",
+				"user_id", user.ID, "status", user.Status)
+			a.writeError(w, r, apperror.ErrAccountSuspended)
+			return
+		}
+		if claims.TokenVersion != user.TokenVersion {
+			a.writeError(w, r, apperror.NewAppError(
+				apperror.CodeUnauthorized,
+				"Token has been revoked",
+				http.StatusUnauthorized,
+				nil,
+			))
+			return
+		}
+
+		// Origin-bound tokens are only accepted from pages on their origin
+		if claims.Origin != "" && r.Header.Get("Origin") != claims.Origin {
+			a.logger.WarnContext(r.Context(), "request rejected: token used from another origin",
+				"user_id", user.ID, "origin", r.Header.Get("Origin"))
+			a.writeError(w, r, apperror.NewAppError(
+				apperror.CodeForbidden,
+				"Token is bound to another origin",
+				http.StatusForbidden,
+				nil,
+			))
+			return
+		}
+
 		// Add user info to context
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
+		ctx = context.WithValue(ctx, TelemetryOptOutKey, user.TelemetryOptOut)
+		ctx = context.WithValue(ctx, ScopesKey, claims.Scopes())
 
 		// Call the next handler with the updated context
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// RequireScope returns a middleware that rejects tokens not granting scope.
+// It must run after Authenticate.
+func (a *Auth) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(GetScopes(r.Context()), scope) {
+				a.writeError(w, r, apperror.NewAppError(
+					apperror.CodeForbidden,
+					fmt.Sprintf("Token does not grant the %s scope", scope),
+					http.StatusForbidden,
+					nil,
+				))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // GetUserID extracts the user ID from the request context
 func GetUserID(ctx context.Context) (uuid.UUID, error) {
 	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
@@ -113,8 +204,27 @@ func GetUserEmail(ctx context.Context) (string, error) {
 	return email, nil
 }
 
+// GetScopes returns the scopes granted by the request's token, or nil for
+// unauthenticated requests
+func GetScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(ScopesKey).([]string)
+	return scopes
+}
+
+// TelemetryOptedOut reports whether the authenticated user has opted out of
+// product analytics. Unauthenticated requests count as opted out.
+func TelemetryOptedOut(ctx context.Context) bool {
+	optOut, ok := ctx.Value(TelemetryOptOutKey).(bool)
+	return !ok || optOut
+}
+
 // writeError writes an error response in envelope format
 func (a *Auth) writeError(w http.ResponseWriter, r *http.Request, appErr *apperror.AppError) {
+	writeError(w, r, a.logger, appErr)
+}
+
+// writeError writes an AppError response in envelope format
+func writeError(w http.ResponseWriter, r *http.Request, logger *slog.Logger, appErr *apperror.AppError) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(appErr.Status)
 
@@ -125,9 +235,10 @@ func (a *Auth) writeError(w http.ResponseWriter, r *http.Request, appErr *apperr
 			Message: appErr.Message,
 			Details: appErr.Details,
 		},
+		Meta: responseMeta(w, r),
 	}
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		a.logger.ErrorContext(r.Context(), "failed to encode error response", "error", err)
+		logger.ErrorContext(r.Context(), "failed to encode error response", "error", err)
 	}
 }
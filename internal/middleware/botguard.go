@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/captcha"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+)
+
+// CaptchaTokenHeader carries the token of a solved captcha challenge
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// FormStartedAtField is the body field holding the Unix time in seconds at
+// which the client showed the form
+const FormStartedAtField = "form_started_at"
+
+// maxBotGuardBodyBytes bounds the bodies BotGuard reads; sign-up and sign-in
+// bodies are far smaller
+const maxBotGuardBodyBytes = 64 << 10
+
+// Outcomes of a bot check, as reported to metrics
+const (
+	botOutcomePassed         = "passed"
+	botOutcomeHoneypot       = "honeypot"
+	botOutcomeTooFast        = "too_fast"
+	botOutcomeCaptchaMissing = "captcha_missing"
+	botOutcomeCaptchaFailed  = "captcha_failed"
+	botOutcomeCaptchaError   = "captcha_error"
+)
+
+// BotGuardConfig configures the checks of BotGuard
+type BotGuardConfig struct {
+	// HoneypotField is a body field real clients leave empty; forms hide it
+	// from people, so only bots fill it in. Empty disables the check.
+	HoneypotField string
+	// MinFillTime is the least time a person takes to fill in the form,
+	// measured from the form_started_at field. 0 disables the check.
+	MinFillTime time.Duration
+	// Captcha verifies the X-Captcha-Token header. Nil disables the check.
+	Captcha *captcha.Verifier
+}
+
+// BotGuard is a middleware that rejects automated requests to public forms
+// such as sign-up and sign-in with 403 BOT_DETECTED. Captcha verification
+// fails open: when the provider cannot be reached the request is let through
+// and logged, so an outage of the provider does not lock people out.
+type BotGuard struct {
+	cfg     BotGuardConfig
+	metrics *metrics.Bots
+	logger  *slog.Logger
+}
+
+// NewBotGuard creates a new BotGuard middleware. m may be nil.
+func NewBotGuard(cfg BotGuardConfig, m *metrics.Bots, logger *slog.Logger) *BotGuard {
+	return &BotGuard{
+		cfg:     cfg,
+		metrics: m,
+		logger:  logger,
+	}
+}
+
+// Handle runs the configured checks before the request reaches next
+func (g *BotGuard) Handle(next http.Handler) http.Handler {
+	if g.cfg.HoneypotField == "" && g.cfg.MinFillTime <= 0 && g.cfg.Captcha == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.cfg.HoneypotField != "" || g.cfg.MinFillTime > 0 {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBotGuardBodyBytes))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					writeError(w, r, g.logger, apperror.ErrPayloadTooLarge)
+					return
+				}
+				writeError(w, r, g.logger, apperror.ErrBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			if outcome := g.checkBody(body); outcome != "" {
+				g.reject(w, r, outcome)
+				return
+			}
+		}
+
+		outcome := botOutcomePassed
+		if g.cfg.Captcha != nil {
+			outcome = g.checkCaptcha(r)
+			if outcome != botOutcomePassed && outcome != botOutcomeCaptchaError {
+				g.reject(w, r, outcome)
+				return
+			}
+		}
+
+		g.metrics.Observe(r.URL.Path, outcome)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkBody runs the honeypot and timing checks on a request body and returns
+// the outcome of a failed check, or "" if they pass. Bodies that are not JSON
+// objects are left for the handler to reject.
+func (g *BotGuard) checkBody(body []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+
+	if g.cfg.HoneypotField != "" {
+		if value, ok := fields[g.cfg.HoneypotField]; ok && !emptyJSON(value) {
+			return botOutcomeHoneypot
+		}
+	}
+
+	if g.cfg.MinFillTime > 0 {
+		var startedAt int64
+		if err := json.Unmarshal(fields[FormStartedAtField], &startedAt); err != nil || startedAt <= 0 {
+			return botOutcomeTooFast
+		}
+		// Times in the future count as too fast too
+		if time.Since(time.Unix(startedAt, 0)) < g.cfg.MinFillTime {
+			return botOutcomeTooFast
+		}
+	}
+
+	return ""
+}
+
+// checkCaptcha verifies the captcha token of a request and returns the
+// outcome of the check
+func (g *BotGuard) checkCaptcha(r *http.Request) string {
+	token := r.Header.Get(CaptchaTokenHeader)
+	if token == "" {
+		return botOutcomeCaptchaMissing
+	}
+
+	if err := g.cfg.Captcha.Verify(r.Context(), token, requestClientIP(r)); err != nil {
+		if errors.Is(err, captcha.ErrRejected) {
+			return botOutcomeCaptchaFailed
+		}
+		g.logger.ErrorContext(r.Context(), "captcha verification unavailable, letting request through", "error", err, "path", r.URL.Path)
+		return botOutcomeCaptchaError
+	}
+	return botOutcomePassed
+}
+
+// reject answers a request that failed a bot check
+func (g *BotGuard) reject(w http.ResponseWriter, r *http.Request, outcome string) {
+	g.logger.WarnContext(r.Context(), "request rejected: bot check failed", "reason", outcome, "client_ip", requestClientIP(r), "path", r.URL.Path)
+	g.metrics.Observe(r.URL.Path, outcome)
+
+	appErr := apperror.ErrBotDetected
+	switch outcome {
+	case botOutcomeCaptchaMissing:
+		appErr = appErr.WithDetails(CaptchaTokenHeader + ": is required")
+	case botOutcomeCaptchaFailed:
+		appErr = appErr.WithDetails(CaptchaTokenHeader + ": is invalid or expired")
+	}
+	writeError(w, r, g.logger, appErr)
+}
+
+// emptyJSON reports whether a JSON value is null, false, zero or an empty string
+func emptyJSON(value json.RawMessage) bool {
+	switch string(bytes.TrimSpace(value)) {
+	case "null", `""`, "false", "0":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/pkg/respcache"
+)
+
+// maxCachedBodyBytes is the largest response body that is cached
+const maxCachedBodyBytes = 1 << 20
+
+// ResponseCache is a middleware that caches authenticated GET responses per user
+// and invalidates them when the same user performs a successful write.
+// It must run after Auth.Authenticate.
+type ResponseCache struct {
+	store   *respcache.Store
+	enabled bool
+	logger  *slog.Logger
+}
+
+// NewResponseCache creates a new ResponseCache middleware.
+// When store is nil, only Cache-Control headers are set.
+func NewResponseCache(store *respcache.Store, logger *slog.Logger) *ResponseCache {
+	return &ResponseCache{
+		store:   store,
+		enabled: store != nil,
+		logger:  logger,
+	}
+}
+
+// Handle serves cached responses for GET requests and purges them on writes
+func (c *ResponseCache) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Responses are user-specific and must be revalidated by shared caches
+		if r.Method == http.MethodGet {
+			w.Header().Set("Cache-Control", "private, no-cache")
+		}
+
+		if !c.enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		surrogateKey := userSurrogateKey(userID)
+
+		if r.Method != http.MethodGet {
+			rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// Any successful write may change what the user's GETs return
+			if rec.statusCode < 400 {
+				c.store.Purge(surrogateKey)
+			}
+			return
+		}
+
+		if !cacheable(r) {
+			w.Header().Set("X-Cache", "BYPASS")
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := cacheKey(userID, GetScopes(r.Context()), r)
+		if entry, ok := c.store.Get(key); ok {
+			for name, values := range entry.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("X-Cache", "HIT")
+			w.WriteHeader(entry.Status)
+			if _, err := w.Write(swapRequestID(entry.Body, "", GetRequestID(r.Context()))); err != nil {
+				c.logger.ErrorContext(r.Context(), "failed to write cached response", "error", err)
+			}
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		w.Header().Set("Surrogate-Key", surrogateKey)
+		rec := &bodyRecorder{statusRecorder: statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}}
+		next.ServeHTTP(rec, r)
+
+		if rec.statusCode == http.StatusOK && !rec.overflow {
+			header := w.Header().Clone()
+			for name := range header {
+				if isPerRequestHeader(name) {
+					header.Del(name)
+				}
+			}
+			// The body carries the request ID too; it is filled in again on every hit
+			body := swapRequestID(rec.body.Bytes(), GetRequestID(r.Context()), "")
+			c.store.Set(key, rec.statusCode, header, body, surrogateKey)
+		}
+	})
+}
+
+// isPerRequestHeader reports whether a header describes the request rather than
+// the resource, so it must not be replayed from the cache
+func isPerRequestHeader(name string) bool {
+	for _, exact := range []string{RequestIDHeader, "X-Cache", "Retry-After"} {
+		if strings.EqualFold(name, exact) {
+			return true
+		}
+	}
+	for _, prefix := range []string{RateLimitHeaderPrefix, QuotaHeaderPrefix} {
+		if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// swapRequestID replaces the request ID in a response envelope. The ID is in the
+// envelope's meta, which is encoded last, so the last occurrence is replaced.
+func swapRequestID(body []byte, from, to string) []byte {
+	old := requestIDField(from)
+	i := bytes.LastIndex(body, old)
+	if i < 0 {
+		return body
+	}
+
+	field := requestIDField(to)
+	out := make([]byte, 0, len(body)-len(old)+len(field))
+	out = append(out, body[:i]...)
+	out = append(out, field...)
+	return append(out, body[i+len(old):]...)
+}
+
+// requestIDField encodes the request ID member of an envelope's meta
+func requestIDField(requestID string) []byte {
+	value, _ := json.Marshal(requestID)
+	return append([]byte(`"request_id":`), value...)
+}
+
+// cacheable reports whether a GET request may be served from the cache
+func cacheable(r *http.Request) bool {
+	// Streams are never cached, and clients may explicitly ask for a fresh response
+	if strings.Contains(r.Header.Get("Accept"), "application/x-ndjson") {
+		return false
+	}
+	return !strings.Contains(r.Header.Get("Cache-Control"), "no-cache")
+}
+
+// cacheKey builds the cache key from the user, token scopes, route, filters and
+// negotiated format. Responses may depend on the scopes, such as the agent tools
+// a token may call, so tokens with other scopes never share an entry.
+func cacheKey(userID uuid.UUID, scopes []string, r *http.Request) string {
+	// url.Values.Encode sorts by key, so equivalent filters share an entry
+	query, _ := url.ParseQuery(r.URL.RawQuery)
+	sorted := slices.Sorted(slices.Values(scopes))
+	return userID.String() + " " + strings.Join(sorted, ",") + " " + r.URL.Path + "?" + query.Encode() + " " + r.Header.Get("Accept")
+}
+
+// userSurrogateKey is the surrogate key tagging every cached response of a user
+func userSurrogateKey(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// statusRecorder records the status code written by the next handler
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.statusCode = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the underlying ResponseWriter so http.ResponseController can reach it
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// bodyRecorder additionally keeps a copy of the body for caching
+type bodyRecorder struct {
+	statusRecorder
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (rec *bodyRecorder) Write(b []byte) (int, error) {
+	if !rec.overflow {
+		if rec.body.Len()+len(b) > maxCachedBodyBytes {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(b)
+		}
+	}
+	return rec.ResponseWriter.Write(b)
+}
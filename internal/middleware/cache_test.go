@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/pkg/respcache"
+)
+
+func TestResponseCacheSeparatesScopes(t *testing.T) {
+	cache := NewResponseCache(respcache.NewStore(time.Minute, 100), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	// The response lists the scopes, as the agent tool list does
+	h := cache.Handle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, strings.Join(GetScopes(r.Context()), " "))
+	}))
+	userID := uuid.New()
+
+	get := func(scopes ...string) *httptest.ResponseRecorder {
+		ctx := context.WithValue(context.Background(), UserIDKey, userID)
+		ctx = context.WithValue(ctx, ScopesKey, scopes)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/agent/tools", nil).WithContext(ctx))
+		return rec
+	}
+
+	tests := []struct {
+		scopes []string
+		cache  string
+		body   string
+	}{
+		{[]string{"agent:read", "agent:write"}, "MISS", "agent:read agent:write"},
+		{[]string{"agent:read"}, "MISS", "agent:read"},
+		{[]string{"agent:read"}, "HIT", "agent:read"},
+		// The order of the scopes in the token does not matter
+		{[]string{"agent:write", "agent:read"}, "HIT", "agent:read agent:write"},
+	}
+	for _, tt := range tests {
+		rec := get(tt.scopes...)
+		if got := rec.Header().Get("X-Cache"); got != tt.cache {
+			t.Errorf("scopes %v: X-Cache = %s, want %s", tt.scopes, got, tt.cache)
+		}
+		if got := rec.Body.String(); got != tt.body {
+			t.Errorf("scopes %v: body = %q, want %q", tt.scopes, got, tt.body)
+		}
+	}
+}
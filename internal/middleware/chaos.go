@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// ChaosHeader names the faults injected into a response, so clients under
+// test can tell injected failures from real ones
+const ChaosHeader = "X-Chaos-Injected"
+
+// ChaosExemptPath is never faulted, so the rules can always be changed back
+const ChaosExemptPath = "/api/v1/admin/chaos"
+
+// maxChaosLatency bounds injected latency below the server write timeout
+const maxChaosLatency = 10 * time.Second
+
+// ChaosRule injects faults into a share of the requests to matching routes.
+// Each fault is rolled independently, so one request can be delayed and then
+// fail.
+type ChaosRule struct {
+	// Method limits the rule to one HTTP method; empty matches any
+	Method string `json:"method,omitempty"`
+	// Path matches request paths it is a prefix of, such as /api/v1/todos
+	Path string `json:"path"`
+
+	// LatencyMS delays LatencyRate of the matching requests
+	LatencyMS   int     `json:"latency_ms,omitempty"`
+	LatencyRate float64 `json:"latency_rate,omitempty"`
+
+	// ErrorStatus is returned instead of the response to ErrorRate of the
+	// matching requests (default: 503)
+	ErrorStatus int     `json:"error_status,omitempty"`
+	ErrorRate   float64 `json:"error_rate,omitempty"`
+
+	// DropRate of the matching requests have their connection closed
+	// without a response
+	DropRate float64 `json:"drop_rate,omitempty"`
+}
+
+// Validate checks that the rule is well-formed
+func (c ChaosRule) Validate() error {
+	var errs []error
+	if !strings.HasPrefix(c.Path, "/") {
+		errs = append(errs, fmt.Errorf("path must start with /"))
+	}
+	if c.Method != "" && !slices.Contains(routeMethods, c.Method) {
+		errs = append(errs, fmt.Errorf("method must be one of %s", strings.Join(routeMethods, " ")))
+	}
+	if c.LatencyMS < 0 || time.Duration(c.LatencyMS)*time.Millisecond > maxChaosLatency {
+		errs = append(errs, fmt.Errorf("latency_ms must be between 0 and %d", maxChaosLatency.Milliseconds()))
+	}
+	if c.ErrorStatus != 0 && (c.ErrorStatus < 400 || c.ErrorStatus > 599) {
+		errs = append(errs, fmt.Errorf("error_status must be between 400 and 599"))
+	}
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"latency_rate", c.LatencyRate},
+		{"error_rate", c.ErrorRate},
+		{"drop_rate", c.DropRate},
+	}
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			errs = append(errs, fmt.Errorf("%s must be between 0 and 1", r.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// matches reports whether the rule applies to the request
+func (c ChaosRule) matches(r *http.Request) bool {
+	return (c.Method == "" || c.Method == r.Method) && strings.HasPrefix(r.URL.Path, c.Path)
+}
+
+// ParseChaosRules parses and validates rules from a JSON array. An empty
+// string yields no rules.
+func ParseChaosRules(s string) ([]ChaosRule, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var rules []ChaosRule
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil, fmt.Errorf("invalid chaos rules: %w", err)
+	}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid chaos rule %d: %w", i, err)
+		}
+	}
+	return rules, nil
+}
+
+// Chaos is a middleware that injects latency, errors and dropped connections
+// into matching requests for resilience testing of clients and their retries.
+// It is only installed outside production. The rules can be replaced at runtime.
+type Chaos struct {
+	logger *slog.Logger
+
+	mu    sync.RWMutex
+	rules []ChaosRule
+}
+
+// NewChaos creates a new Chaos middleware with the given rules
+func NewChaos(rules []ChaosRule, logger *slog.Logger) *Chaos {
+	return &Chaos{
+		logger: logger,
+		rules:  rules,
+	}
+}
+
+// Rules returns the current rules
+func (c *Chaos) Rules() []ChaosRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.rules)
+}
+
+// SetRules validates and replaces the rules; nil removes them all
+func (c *Chaos) SetRules(rules []ChaosRule) error {
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = rules
+	return nil
+}
+
+// Handle injects the faults of the first rule matching the request
+func (c *Chaos) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule, ok := c.match(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if rule.LatencyMS > 0 && roll(rule.LatencyRate) {
+			w.Header().Add(ChaosHeader, "latency")
+			select {
+			case <-time.After(time.Duration(rule.LatencyMS) * time.Millisecond):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if roll(rule.DropRate) {
+			c.logger.InfoContext(r.Context(), "chaos: dropping connection", "method", r.Method, "path", r.URL.Path)
+			drop(w)
+			return
+		}
+
+		if roll(rule.ErrorRate) {
+			status := rule.ErrorStatus
+			if status == 0 {
+				status = http.StatusServiceUnavailable
+			}
+			c.logger.InfoContext(r.Context(), "chaos: injecting error", "method", r.Method, "path", r.URL.Path, "status", status)
+			w.Header().Add(ChaosHeader, "error")
+			writeError(w, r, c.logger, chaosError(status))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// match returns the first rule that applies to the request
+func (c *Chaos) match(r *http.Request) (ChaosRule, bool) {
+	if strings.HasPrefix(r.URL.Path, ChaosExemptPath) {
+		return ChaosRule{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, rule := range c.rules {
+		if rule.matches(r) {
+			return rule, true
+		}
+	}
+	return ChaosRule{}, false
+}
+
+// roll reports whether a fault with the given rate happens this time
+func roll(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// drop closes the client connection without writing a response. Connections
+// that cannot be hijacked, such as HTTP/2 streams, are reset by aborting the
+// handler instead.
+func drop(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	_ = conn.Close()
+}
+
+// chaosError returns the error envelope of an injected failure
+func chaosError(status int) *apperror.AppError {
+	code := apperror.CodeUnavailable
+	if status < http.StatusInternalServerError {
+		code = apperror.CodeBadRequest
+	} else if status != http.StatusServiceUnavailable {
+		code = apperror.CodeInternal
+	}
+	return apperror.NewAppError(code, "Injected fault", status, nil)
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPKey is the context key for the client IP address
+const ClientIPKey ContextKey = "client_ip"
+
+// ClientIP is a middleware that resolves the IP address of the client behind
+// trusted reverse proxies. Requests from a trusted proxy are attributed to the
+// nearest untrusted address in X-Forwarded-For, or to X-Real-IP when there is
+// no X-Forwarded-For. Forwarding headers from other peers are ignored, as the
+// client could have made them up.
+type ClientIP struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIP creates a new ClientIP middleware trusting the proxies in
+// trusted. With none, the client is always the peer of the connection.
+func NewClientIP(trusted []netip.Prefix) *ClientIP {
+	return &ClientIP{trusted: trusted}
+}
+
+// Handle adds the client IP to the context
+func (c *ClientIP) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), ClientIPKey, c.resolve(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// resolve returns the client IP of a request
+func (c *ClientIP) resolve(r *http.Request) string {
+	peer, err := parseIP(r.RemoteAddr)
+	if err != nil {
+		return remoteHost(r)
+	}
+	if !c.isTrusted(peer) {
+		return peer.String()
+	}
+
+	hops := forwardedFor(r.Header)
+	if len(hops) == 0 {
+		if realIP, err := parseIP(r.Header.Get("X-Real-IP")); err == nil {
+			return realIP.String()
+		}
+		return peer.String()
+	}
+
+	// Each proxy appends the address it received the request from, so the
+	// client is the last address not added by a trusted proxy
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := parseIP(hops[i])
+		if err != nil {
+			break
+		}
+		client = hop
+		if !c.isTrusted(hop) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// isTrusted reports whether addr belongs to a trusted proxy
+func (c *ClientIP) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClientIP extracts the client IP from the context
+func GetClientIP(ctx context.Context) string {
+	clientIP, ok := ctx.Value(ClientIPKey).(string)
+	if !ok {
+		return ""
+	}
+	return clientIP
+}
+
+// requestClientIP returns the resolved client IP of a request, or the host
+// of its peer address when ClientIP did not run
+func requestClientIP(r *http.Request) string {
+	if clientIP := GetClientIP(r.Context()); clientIP != "" {
+		return clientIP
+	}
+	return remoteHost(r)
+}
+
+// remoteHost returns the host of a request's peer address
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// forwardedFor returns the addresses of every X-Forwarded-For header, in order
+func forwardedFor(h http.Header) []string {
+	var hops []string
+	for _, value := range h.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseIP parses an IP address with or without a port, mapping IPv4-mapped
+// IPv6 addresses to IPv4
+func parseIP(s string) (netip.Addr, error) {
+	s = strings.TrimSpace(s)
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
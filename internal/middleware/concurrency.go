@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// globalSlotKey is the context key of the request's globalSlot
+const globalSlotKey ContextKey = "global_slot"
+
+// globalSlot is a request's hold on the global limit, which LimitLongPolls
+// hands back once it admits a poll, since a poll holds its request open while
+// idle
+type globalSlot struct {
+	once    sync.Once
+	release func()
+}
+
+// free hands the slot back; later calls do nothing
+func (s *globalSlot) free() {
+	s.once.Do(s.release)
+}
+
+// ConcurrencyLimit is a middleware that caps the number of in-flight requests,
+// globally and per authenticated user, shedding excess load with 503 responses.
+// Long polls have their own per-user cap.
+type ConcurrencyLimit struct {
+	global     chan struct{}
+	perUser    int
+	longPolls  int
+	retryAfter time.Duration
+	logger     *slog.Logger
+
+	mu      sync.Mutex
+	users   map[uuid.UUID]int
+	pollers map[uuid.UUID]int
+}
+
+// NewConcurrencyLimit creates a new ConcurrencyLimit middleware.
+// A limit of 0 disables the corresponding check.
+func NewConcurrencyLimit(globalLimit, perUserLimit, longPollsPerUser int, retryAfter time.Duration, logger *slog.Logger) *ConcurrencyLimit {
+	var global chan struct{}
+	if globalLimit > 0 {
+		global = make(chan struct{}, globalLimit)
+	}
+
+	return &ConcurrencyLimit{
+		global:     global,
+		perUser:    perUserLimit,
+		longPolls:  longPollsPerUser,
+		retryAfter: retryAfter,
+		logger:     logger,
+		users:      make(map[uuid.UUID]int),
+		pollers:    make(map[uuid.UUID]int),
+	}
+}
+
+// Limit caps the number of requests in flight across all clients. Long polls
+// leave the count once LimitLongPolls admits them.
+func (c *ConcurrencyLimit) Limit(next http.Handler) http.Handler {
+	if c.global == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case c.global <- struct{}{}:
+			slot := &globalSlot{release: func() { <-c.global }}
+			defer slot.free()
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), globalSlotKey, slot)))
+		default:
+			c.logger.WarnContext(r.Context(), "request shed: global concurrency limit reached",
+				"limit", cap(c.global), "path", r.URL.Path)
+			c.reject(w, r)
+		}
+	})
+}
+
+// LimitUser caps the number of requests in flight per authenticated user.
+// It must run after Auth.Authenticate; unauthenticated requests pass through.
+func (c *ConcurrencyLimit) LimitUser(next http.Handler) http.Handler {
+	if c.perUser <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !c.acquire(c.users, c.perUser, userID) {
+			c.logger.WarnContext(r.Context(), "request shed: per-user concurrency limit reached",
+				"limit", c.perUser, "user_id", userID, "path", r.URL.Path)
+			c.reject(w, r)
+			return
+		}
+		defer c.release(c.users, userID)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// LimitLongPolls caps the number of long polls waiting per authenticated
+// user, in place of LimitUser, so idle polls do not hold the slots of the
+// user's other requests. An admitted poll no longer counts toward the global
+// limit. It must run after Auth.Authenticate; unauthenticated requests pass
+// through and keep their global slot.
+func (c *ConcurrencyLimit) LimitLongPolls(next http.Handler) http.Handler {
+	if c.global == nil && c.longPolls <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.longPolls > 0 {
+			if !c.acquire(c.pollers, c.longPolls, userID) {
+				c.logger.WarnContext(r.Context(), "request shed: per-user long poll limit reached",
+					"limit", c.longPolls, "user_id", userID, "path", r.URL.Path)
+				c.reject(w, r)
+				return
+			}
+			defer c.release(c.pollers, userID)
+		}
+
+		if slot, ok := r.Context().Value(globalSlotKey).(*globalSlot); ok {
+			slot.free()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acquire reserves one of a user's limit slots in counts if one is free
+func (c *ConcurrencyLimit) acquire(counts map[uuid.UUID]int, limit int, userID uuid.UUID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if counts[userID] >= limit {
+		return false
+	}
+	counts[userID]++
+	return true
+}
+
+// release frees a user's slot in counts
+func (c *ConcurrencyLimit) release(counts map[uuid.UUID]int, userID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts[userID]--
+	if counts[userID] <= 0 {
+		delete(counts, userID)
+	}
+}
+
+// reject writes a 503 response asking the client to retry later
+func (c *ConcurrencyLimit) reject(w http.ResponseWriter, r *http.Request) {
+	seconds := int(c.retryAfter.Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, r, c.logger, apperror.ErrUnavailable)
+}
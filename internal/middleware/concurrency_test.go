@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLongPollsLeaveTheGlobalLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticated bool
+		wantStatus    int
+	}{
+		{name: "admitted poll", authenticated: true, wantStatus: http.StatusOK},
+		{name: "unauthenticated poll", authenticated: false, wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := NewConcurrencyLimit(1, 0, 1, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			waiting := make(chan struct{})
+			done := make(chan struct{})
+			poll := limit.Limit(limit.LimitLongPolls(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(waiting)
+				<-done
+			})))
+			other := limit.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/todos/changes", nil)
+			if tt.authenticated {
+				req = req.WithContext(context.WithValue(req.Context(), UserIDKey, uuid.New()))
+			}
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				poll.ServeHTTP(httptest.NewRecorder(), req)
+			}()
+			<-waiting
+
+			rec := httptest.NewRecorder()
+			other.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/todos", nil))
+			close(done)
+			<-finished
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("request while a poll waits: status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
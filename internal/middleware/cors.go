@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/cors"
+)
+
+// corsAllowedHeaders are the request headers browsers may send cross-origin
+var corsAllowedHeaders = []string{
+	"Accept", "Authorization", "Content-Type", "X-Request-ID",
+	"traceparent", "tracestate", "b3",
+	SignatureHeader, SignatureTimestampHeader, SignatureNonceHeader,
+	CaptchaTokenHeader,
+}
+
+// corsExposedHeaders are the response headers cross-origin scripts may read
+var corsExposedHeaders = []string{
+	"X-Request-ID",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After",
+	ChaosHeader,
+}
+
+// CORSPolicy is the CORS configuration of the routes under PathPrefix
+type CORSPolicy struct {
+	// PathPrefix selects the routes, matching whole path segments;
+	// "/" matches every route
+	PathPrefix string
+	// AllowedOrigins lists the allowed origins. "*" allows any origin, and
+	// one "*" inside an entry matches any text, as in https://*.example.com.
+	AllowedOrigins []string
+	// OriginAllowed is asked about origins missing from AllowedOrigins,
+	// such as those on an allowlist managed at runtime. Nil allows no others.
+	OriginAllowed func(origin string) bool
+	// AllowCredentials lets browsers send cookies and Authorization headers
+	AllowCredentials bool
+}
+
+// CORS is a middleware that applies the CORS policy of the route group a
+// request belongs to, so public, auth and API routes can allow different
+// origins. It runs in front of the router so preflight requests are answered
+// before Methods answers OPTIONS.
+type CORS struct {
+	policies []CORSPolicy
+}
+
+// NewCORS creates a new CORS middleware. A request gets the policy with the
+// longest PathPrefix matching it, and no CORS headers if none matches.
+func NewCORS(policies ...CORSPolicy) *CORS {
+	sorted := make([]CORSPolicy, len(policies))
+	copy(sorted, policies)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].PathPrefix) > len(sorted[j].PathPrefix)
+	})
+	return &CORS{policies: sorted}
+}
+
+// Handle applies the matching CORS policy to the request
+func (c *CORS) Handle(next http.Handler) http.Handler {
+	handlers := make([]http.Handler, len(c.policies))
+	for i, policy := range c.policies {
+		handlers[i] = policy.handler()(next)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i, policy := range c.policies {
+			if underPrefix(r.URL.Path, policy.PathPrefix) {
+				handlers[i].ServeHTTP(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handler builds the go-chi/cors middleware of the policy
+func (p CORSPolicy) handler() func(http.Handler) http.Handler {
+	origins := newOriginMatcher(p.AllowedOrigins)
+	return cors.Handler(cors.Options{
+		AllowOriginFunc: func(_ *http.Request, origin string) bool {
+			return origins.match(origin) || (p.OriginAllowed != nil && p.OriginAllowed(origin))
+		},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   corsAllowedHeaders,
+		ExposedHeaders:   corsExposedHeaders,
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           300,
+	})
+}
+
+// underPrefix reports whether path is prefix or lies below it
+func underPrefix(path, prefix string) bool {
+	if prefix == "/" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// originMatcher matches origins against a configured list
+type originMatcher struct {
+	all       bool
+	exact     map[string]bool
+	wildcards [][2]string
+}
+
+// newOriginMatcher compiles a list of origins, ignoring case
+func newOriginMatcher(origins []string) originMatcher {
+	m := originMatcher{exact: make(map[string]bool)}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "":
+		case origin == "*":
+			m.all = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			m.wildcards = append(m.wildcards, [2]string{prefix, suffix})
+		default:
+			m.exact[origin] = true
+		}
+	}
+	return m
+}
+
+// match reports whether an origin is on the list
+func (m originMatcher) match(origin string) bool {
+	if m.all {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		if len(origin) >= len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,14 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
 )
 
+// unmatchedRoute labels requests that did not match any route
+const unmatchedRoute = "unmatched"
+
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -31,15 +37,23 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-// Logging is a middleware that logs HTTP requests
+// Unwrap returns the underlying ResponseWriter so http.ResponseController can reach it
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Logging is a middleware that logs HTTP requests and records per-route metrics
 type Logging struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	metrics *metrics.HTTP
 }
 
-// NewLogging creates a new Logging middleware
-func NewLogging(logger *slog.Logger) *Logging {
+// NewLogging creates a new Logging middleware.
+// When httpMetrics is nil, requests are only logged.
+func NewLogging(logger *slog.Logger, httpMetrics *metrics.HTTP) *Logging {
 	return &Logging{
-		logger: logger,
+		logger:  logger,
+		metrics: httpMetrics,
 	}
 }
 
@@ -52,17 +66,36 @@ func (l *Logging) Log(next http.Handler) http.Handler {
 		// Call the next handler
 		next.ServeHTTP(wrapped, r)
 
-		// Log the request
+		// The route pattern is known only after routing, and keeps labels bounded
+		route := routePattern(r)
 		duration := time.Since(start)
+		l.metrics.Observe(r.Method, route, wrapped.statusCode, duration)
+
+		// Log the request
 		l.logger.InfoContext(r.Context(),
 			"HTTP request",
 			"method", r.Method,
+			"route", route,
 			"path", r.URL.Path,
 			"status", wrapped.statusCode,
 			"duration_ms", duration.Milliseconds(),
 			"bytes", wrapped.written,
 			"remote_addr", r.RemoteAddr,
+			"client_ip", requestClientIP(r),
 			"user_agent", r.UserAgent(),
 		)
 	})
 }
+
+// routePattern returns the chi route pattern that handled the request, such as
+// /api/v1/todos/{id}, or "unmatched" when no route matched
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeMethods are the methods checked when listing what a route allows
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Methods is a middleware that answers HEAD requests with the metadata of the
+// matching GET route and OPTIONS requests with the methods a route allows.
+// It must run inside the router so the route tree is available, and after CORS
+// so preflight requests are answered there.
+type Methods struct{}
+
+// NewMethods creates a new Methods middleware
+func NewMethods() *Methods {
+	return &Methods{}
+}
+
+// Handle serves HEAD and OPTIONS requests for routes that only register other methods
+func (m *Methods) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			// Run the GET handler and drop its body, keeping the headers it would send
+			get := r.WithContext(r.Context())
+			get.Method = http.MethodGet
+
+			hw := &headWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(hw, get)
+			hw.finish()
+		case http.MethodOptions:
+			allowed := AllowedMethods(r)
+			if len(allowed) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// AllowedMethods returns the methods the router accepts for the request path,
+// including HEAD for GET routes and OPTIONS for any route. It returns nil when
+// no route matches the path.
+func AllowedMethods(r *http.Request) []string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+
+	var allowed []string
+	for _, method := range routeMethods {
+		if !rctx.Routes.Match(chi.NewRouteContext(), method, r.URL.Path) {
+			continue
+		}
+		allowed = append(allowed, method)
+		if method == http.MethodGet {
+			allowed = append(allowed, http.MethodHead)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return nil
+	}
+	return append(allowed, http.MethodOptions)
+}
+
+// headWriter discards the response body and counts its length so the
+// Content-Length of a HEAD response matches the GET response.
+// It deliberately does not support flushing, which would send headers early.
+type headWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	length      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code until the body length is known
+func (hw *headWriter) WriteHeader(code int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.statusCode = code
+	hw.wroteHeader = true
+}
+
+// Write counts the body bytes without sending them
+func (hw *headWriter) Write(b []byte) (int, error) {
+	hw.wroteHeader = true
+	hw.length += len(b)
+	return len(b), nil
+}
+
+// finish sends the headers with the counted Content-Length
+func (hw *headWriter) finish() {
+	if hw.Header().Get("Content-Length") == "" && hw.statusCode != http.StatusNoContent && hw.statusCode != http.StatusNotModified {
+		hw.Header().Set("Content-Length", strconv.Itoa(hw.length))
+	}
+	hw.ResponseWriter.WriteHeader(hw.statusCode)
+}
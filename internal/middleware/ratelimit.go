@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// Header prefixes for limit headers. Every limit is described by the same
+// Limit/Remaining/Reset triple so clients can parse them uniformly.
+const (
+	RateLimitHeaderPrefix = "X-RateLimit-"
+	QuotaHeaderPrefix     = "X-Quota-"
+)
+
+// writeLimitHeaders sets the <prefix>Limit, <prefix>Remaining and <prefix>Reset
+// headers. Reset is the Unix time in seconds when the allowance is restored.
+func writeLimitHeaders(h http.Header, prefix string, limit, remaining int, reset time.Time) {
+	if remaining < 0 {
+		remaining = 0
+	}
+	h.Set(prefix+"Limit", strconv.Itoa(limit))
+	h.Set(prefix+"Remaining", strconv.Itoa(remaining))
+	h.Set(prefix+"Reset", strconv.FormatInt(reset.Unix(), 10))
+}
+
+// AbuseRecorder is notified of requests that suggest an account is being abused
+type AbuseRecorder interface {
+	RecordAbuse(ctx context.Context, userID uuid.UUID, signal string)
+}
+
+// AbuseSignalRateLimited is reported for each request rejected by RateLimit
+const AbuseSignalRateLimited = "rate_limited"
+
+// rateWindow counts a client's requests in the current fixed window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateWindows holds the current windows of clients identified by K
+type rateWindows[K comparable] struct {
+	windows   map[K]*rateWindow
+	lastSweep time.Time
+}
+
+// take counts a request for the client and returns the count so far in the
+// current window and when the window resets
+func (ws *rateWindows[K]) take(key K, window time.Duration) (int, time.Time) {
+	now := time.Now()
+
+	// Drop windows of clients who have gone quiet so the map does not grow forever
+	if now.Sub(ws.lastSweep) >= window {
+		for k, w := range ws.windows {
+			if now.Sub(w.start) >= window {
+				delete(ws.windows, k)
+			}
+		}
+		ws.lastSweep = now
+	}
+
+	w, ok := ws.windows[key]
+	if !ok || now.Sub(w.start) >= window {
+		w = &rateWindow{start: now}
+		ws.windows[key] = w
+	}
+	w.count++
+
+	return w.count, w.start.Add(window)
+}
+
+// RateLimit is a middleware that limits each authenticated user, or each
+// client IP on public routes, to a number of requests per fixed window and
+// reports the remaining allowance in headers
+type RateLimit struct {
+	limit  int
+	window time.Duration
+	abuse  AbuseRecorder
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	users   rateWindows[uuid.UUID]
+	clients rateWindows[string]
+}
+
+// NewRateLimit creates a new RateLimit middleware.
+// A limit of 0 disables rate limiting. abuse may be nil.
+func NewRateLimit(limit int, window time.Duration, abuse AbuseRecorder, logger *slog.Logger) *RateLimit {
+	return &RateLimit{
+		limit:   limit,
+		window:  window,
+		abuse:   abuse,
+		logger:  logger,
+		users:   rateWindows[uuid.UUID]{windows: make(map[uuid.UUID]*rateWindow)},
+		clients: rateWindows[string]{windows: make(map[string]*rateWindow)},
+	}
+}
+
+// Handle counts the request against the user's window, setting X-RateLimit-*
+// headers on every response and rejecting requests over the limit with 429.
+// It must run after Auth.Authenticate; unauthenticated requests pass through.
+func (rl *RateLimit) Handle(next http.Handler) http.Handler {
+	if rl.limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rl.mu.Lock()
+		count, reset := rl.users.take(userID, rl.window)
+		rl.mu.Unlock()
+
+		if !rl.allow(w, r, count, reset, "user_id", userID) {
+			if rl.abuse != nil {
+				rl.abuse.RecordAbuse(r.Context(), userID, AbuseSignalRateLimited)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleClientIP counts the request against the window of its client IP, for
+// public routes without a user to count against. It otherwise behaves like
+// Handle and should run after ClientIP.
+func (rl *RateLimit) HandleClientIP(next http.Handler) http.Handler {
+	if rl.limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientIP := requestClientIP(r)
+
+		rl.mu.Lock()
+		count, reset := rl.clients.take(clientIP, rl.window)
+		rl.mu.Unlock()
+
+		if !rl.allow(w, r, count, reset) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow sets the X-RateLimit-* headers for a request counted in a window and
+// rejects it with 429 when it is over the limit. attrs identify the client in
+// the rejection log line.
+func (rl *RateLimit) allow(w http.ResponseWriter, r *http.Request, count int, reset time.Time, attrs ...any) bool {
+	writeLimitHeaders(w.Header(), RateLimitHeaderPrefix, rl.limit, rl.limit-count, reset)
+
+	if count <= rl.limit {
+		return true
+	}
+
+	args := append([]any{"limit", rl.limit, "window", rl.window}, attrs...)
+	rl.logger.WarnContext(r.Context(), "request rejected: rate limit exceeded",
+		append(args, "client_ip", requestClientIP(r), "path", r.URL.Path)...)
+
+	seconds := int(time.Until(reset).Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, r, rl.logger, apperror.ErrRateLimited)
+	return false
+}
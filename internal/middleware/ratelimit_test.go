@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitByClientIP(t *testing.T) {
+	limit := NewRateLimit(2, time.Minute, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h := limit.HandleClientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	login := func(clientIP string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		req = req.WithContext(context.WithValue(req.Context(), ClientIPKey, clientIP))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		clientIP   string
+		wantStatus int
+		remaining  string
+	}{
+		{"203.0.113.1", http.StatusOK, "1"},
+		{"203.0.113.1", http.StatusOK, "0"},
+		{"203.0.113.1", http.StatusTooManyRequests, "0"},
+		// Other clients keep their own allowance
+		{"203.0.113.2", http.StatusOK, "1"},
+	}
+	for i, tt := range tests {
+		rec := login(tt.clientIP)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("request %d from %s: status = %d, want %d", i, tt.clientIP, rec.Code, tt.wantStatus)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.remaining {
+			t.Errorf("request %d from %s: X-RateLimit-Remaining = %s, want %s", i, tt.clientIP, got, tt.remaining)
+		}
+	}
+}
@@ -26,6 +26,11 @@ func (rec *Recover) Handle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				// An aborted handler asks the server to drop the connection
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
 				// Log the panic
 				rec.logger.ErrorContext(r.Context(),
 					"panic recovered",
@@ -45,6 +50,7 @@ func (rec *Recover) Handle(next http.Handler) http.Handler {
 						Code:    string(apperror.CodeInternal),
 						Message: "An unexpected error occurred",
 					},
+					Meta: responseMeta(w, r),
 				}
 
 				if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
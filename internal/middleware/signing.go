@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/webhooksig"
+)
+
+// Request signing headers. A client opts in to signing by sending all three.
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	SignatureNonceHeader     = "X-Signature-Nonce"
+)
+
+// Nonces must be long enough to be unique per request and short enough to
+// keep the replay cache small
+const (
+	minNonceLength = 16
+	maxNonceLength = 128
+)
+
+// RequestSigning is a middleware that protects sensitive endpoints against
+// replayed requests. Signed requests carry a Unix timestamp, a random nonce and
+// a hex HMAC-SHA256, keyed with the caller's access token, over
+//
+//	<timestamp>\n<nonce>\n<METHOD>\n<path and query>\n<body>
+//
+// Requests older than the tolerance are rejected, and each nonce is accepted
+// once per user while its timestamp could still be valid. Nonces are kept in
+// memory, so replays are only detected by the instance that saw the original.
+// It must run after Auth.Authenticate.
+type RequestSigning struct {
+	verifier  *webhooksig.Verifier
+	required  bool
+	retention time.Duration
+	logger    *slog.Logger
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewRequestSigning creates a new RequestSigning middleware. When required is
+// false, unsigned requests pass through and only signed ones are checked.
+// A zero tolerance uses webhooksig.DefaultTolerance.
+func NewRequestSigning(required bool, tolerance time.Duration, logger *slog.Logger) *RequestSigning {
+	if tolerance <= 0 {
+		tolerance = webhooksig.DefaultTolerance
+	}
+
+	return &RequestSigning{
+		verifier: webhooksig.NewVerifier(requestScheme{}, bearerSecret, tolerance),
+		required: required,
+		// A timestamp is accepted from tolerance before to tolerance after now,
+		// so its nonce must be remembered for twice the tolerance
+		retention: 2 * tolerance,
+		logger:    logger,
+		nonces:    make(map[string]time.Time),
+	}
+}
+
+// Handle verifies the signature of signed requests and rejects replays with 401
+func (s *RequestSigning) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.required && !hasSignature(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := GetUserID(r.Context())
+		if err != nil {
+			writeError(w, r, s.logger, apperror.ErrUnauthorized)
+			return
+		}
+
+		if _, err := s.verifier.Verify(r); err != nil {
+			s.logger.WarnContext(r.Context(), "signed request rejected", "error", err, "user_id", userID, "path", r.URL.Path)
+
+			detail := "signature is missing or does not match the request"
+			if errors.Is(err, webhooksig.ErrReplayed) {
+				detail = "timestamp is outside the allowed window"
+			}
+			writeError(w, r, s.logger, apperror.ErrInvalidSignature.WithDetails(detail))
+			return
+		}
+
+		// Only nonces of valid signatures are recorded, so a forged request
+		// cannot use up a nonce the client has not sent yet
+		if !s.claim(userID.String() + ":" + r.Header.Get(SignatureNonceHeader)) {
+			s.logger.WarnContext(r.Context(), "signed request rejected: nonce reused", "user_id", userID, "path", r.URL.Path)
+			writeError(w, r, s.logger, apperror.ErrInvalidSignature.WithDetails("nonce has already been used"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// claim records a nonce and reports whether it had not been seen before
+func (s *RequestSigning) claim(key string) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop nonces whose timestamps can no longer pass verification
+	if now.Sub(s.lastSweep) >= s.retention {
+		for k, seen := range s.nonces {
+			if now.Sub(seen) >= s.retention {
+				delete(s.nonces, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if seen, ok := s.nonces[key]; ok && now.Sub(seen) < s.retention {
+		return false
+	}
+	s.nonces[key] = now
+	return true
+}
+
+// hasSignature reports whether the client sent any of the signing headers
+func hasSignature(r *http.Request) bool {
+	return r.Header.Get(SignatureHeader) != "" ||
+		r.Header.Get(SignatureTimestampHeader) != "" ||
+		r.Header.Get(SignatureNonceHeader) != ""
+}
+
+// bearerSecret keys request signatures with the access token of the request
+func bearerSecret(_ context.Context, r *http.Request) ([][]byte, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, nil
+	}
+	return [][]byte{[]byte(token)}, nil
+}
+
+// requestScheme extracts the signed parts of a client request
+type requestScheme struct{}
+
+func (requestScheme) Extract(r *http.Request, body []byte) (*webhooksig.Signed, error) {
+	timestamp := r.Header.Get(SignatureTimestampHeader)
+	nonce := r.Header.Get(SignatureNonceHeader)
+	signature := r.Header.Get(SignatureHeader)
+	if timestamp == "" || signature == "" || len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return nil, webhooksig.ErrMissingSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, webhooksig.ErrMissingSignature
+	}
+
+	var message strings.Builder
+	message.WriteString(timestamp + "\n" + nonce + "\n" + r.Method + "\n" + r.URL.RequestURI() + "\n")
+	message.Write(body)
+
+	signed := &webhooksig.Signed{
+		Timestamp: time.Unix(seconds, 0),
+		Message:   []byte(message.String()),
+	}
+	if sig, err := hex.DecodeString(signature); err == nil {
+		signed.Signatures = append(signed.Signatures, sig)
+	}
+	return signed, nil
+}
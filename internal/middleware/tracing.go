@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/whauzan/todo-api/internal/pkg/tracing"
+)
+
+// Tracing is a middleware that joins the caller's distributed trace from
+// traceparent or B3 headers, or starts a new one, and stores it in the context
+// for logging and outbound requests
+type Tracing struct{}
+
+// NewTracing creates a new Tracing middleware
+func NewTracing() *Tracing {
+	return &Tracing{}
+}
+
+// Handle adds the request's span context to the context
+func (t *Tracing) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := tracing.Extract(r.Header)
+		ctx := tracing.NewContext(r.Context(), sc)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,173 @@
+// Package analytics records anonymized product analytics events and delivers
+// them in batches to a pluggable sink. Events never carry user IDs, emails or
+// request content: users are identified by a keyed hash of their ID, and
+// endpoints by their route pattern rather than the request path.
+package analytics
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventAPIRequest is recorded once per authenticated API request
+const EventAPIRequest = "api_request"
+
+// shutdownFlushTimeout bounds the final flush when the tracker stops
+const shutdownFlushTimeout = 5 * time.Second
+
+// Event is a single anonymized analytics event
+type Event struct {
+	Name        string    `json:"event"`
+	AnonymousID string    `json:"anonymous_id"`
+	Feature     string    `json:"feature"`
+	Endpoint    string    `json:"endpoint"`
+	Method      string    `json:"method"`
+	Status      int       `json:"status"`
+	LatencyMS   int64     `json:"latency_ms"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// Sink delivers a batch of events to an analytics backend
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// Config tunes a Tracker. Zero fields take the values from DefaultConfig.
+type Config struct {
+	// BatchSize events are sent together; a full batch is sent immediately
+	BatchSize int
+	// FlushInterval bounds how long a partial batch waits before it is sent
+	FlushInterval time.Duration
+	// BufferSize events may be queued; events tracked while it is full are dropped
+	BufferSize int
+}
+
+// DefaultConfig returns settings suitable for hosted analytics APIs
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:     100,
+		FlushInterval: 10 * time.Second,
+		BufferSize:    10000,
+	}
+}
+
+// Tracker queues events and sends them to a sink from a single background
+// goroutine, so tracking never blocks a request. It is safe for concurrent use.
+type Tracker struct {
+	sink    Sink
+	cfg     Config
+	key     []byte
+	events  chan Event
+	dropped atomic.Int64
+	logger  *slog.Logger
+}
+
+// NewTracker creates a Tracker sending to sink. key anonymizes user IDs and
+// must stay secret and stable, or the same user shows up under a new ID.
+func NewTracker(sink Sink, key []byte, cfg Config, logger *slog.Logger) *Tracker {
+	def := DefaultConfig()
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = def.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = def.BufferSize
+	}
+
+	return &Tracker{
+		sink:   sink,
+		cfg:    cfg,
+		key:    key,
+		events: make(chan Event, cfg.BufferSize),
+		logger: logger,
+	}
+}
+
+// AnonymousID returns the stable pseudonymous ID reported for a user
+func (t *Tracker) AnonymousID(userID uuid.UUID) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write(userID[:])
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// Track queues an event, dropping it if the buffer is full
+func (t *Tracker) Track(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case t.events <- event:
+	default:
+		t.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer was full
+func (t *Tracker) Dropped() int64 {
+	return t.dropped.Load()
+}
+
+// Run sends queued events until ctx is cancelled, then flushes what is left
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, t.cfg.BatchSize)
+	for {
+		select {
+		case event := <-t.events:
+			batch = append(batch, event)
+			if len(batch) >= t.cfg.BatchSize {
+				batch = t.flush(ctx, batch)
+			}
+		case <-ticker.C:
+			batch = t.flush(ctx, batch)
+		case <-ctx.Done():
+			t.drain(batch)
+			return
+		}
+	}
+}
+
+// drain sends the current batch and everything still queued
+func (t *Tracker) drain(batch []Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case event := <-t.events:
+			batch = append(batch, event)
+			if len(batch) >= t.cfg.BatchSize {
+				batch = t.flush(ctx, batch)
+			}
+		default:
+			t.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+// flush sends a batch and returns it emptied for reuse. Failed batches are
+// dropped; analytics are best effort and never retried at this level.
+func (t *Tracker) flush(ctx context.Context, batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := t.sink.Send(ctx, batch); err != nil {
+		t.logger.WarnContext(ctx, "failed to send analytics events", "error", err, "events", len(batch))
+	}
+
+	return batch[:0]
+}
@@ -0,0 +1,210 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/httpclient"
+)
+
+// Sink names accepted by NewSink
+const (
+	SinkStdout  = "stdout"
+	SinkSegment = "segment"
+	SinkPostHog = "posthog"
+)
+
+// Default API hosts of the hosted sinks
+const (
+	DefaultSegmentHost = "https://api.segment.io"
+	DefaultPostHogHost = "https://us.i.posthog.com"
+)
+
+// NewSink creates the sink with the given name. host may be empty to use the
+// provider's default; apiKey is the Segment write key or PostHog project key.
+func NewSink(name, host, apiKey string, client *httpclient.Client, out io.Writer) (Sink, error) {
+	switch name {
+	case SinkStdout:
+		return NewWriterSink(out), nil
+	case SinkSegment:
+		if host == "" {
+			host = DefaultSegmentHost
+		}
+		return NewSegmentSink(client, host, apiKey), nil
+	case SinkPostHog:
+		if host == "" {
+			host = DefaultPostHogHost
+		}
+		return NewPostHogSink(client, host, apiKey), nil
+	default:
+		return nil, fmt.Errorf("unknown analytics sink: %s", name)
+	}
+}
+
+// WriterSink writes events as JSON lines, for local development and for log
+// pipelines that ship stdout to a warehouse
+type WriterSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriterSink creates a WriterSink writing to out
+func NewWriterSink(out io.Writer) *WriterSink {
+	return &WriterSink{enc: json.NewEncoder(out)}
+}
+
+// Send writes one line per event
+func (s *WriterSink) Send(_ context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		if err := s.enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SegmentSink sends events to the Segment HTTP tracking API
+type SegmentSink struct {
+	client   *httpclient.Client
+	url      string
+	writeKey string
+}
+
+// NewSegmentSink creates a SegmentSink for the Segment API at host
+func NewSegmentSink(client *httpclient.Client, host, writeKey string) *SegmentSink {
+	return &SegmentSink{
+		client:   client,
+		url:      strings.TrimRight(host, "/") + "/v1/batch",
+		writeKey: writeKey,
+	}
+}
+
+// segmentTrack is a Segment track call
+type segmentTrack struct {
+	Type        string         `json:"type"`
+	Event       string         `json:"event"`
+	AnonymousID string         `json:"anonymousId"`
+	Properties  map[string]any `json:"properties"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+// Send posts the events as one Segment batch
+func (s *SegmentSink) Send(ctx context.Context, events []Event) error {
+	batch := make([]segmentTrack, len(events))
+	for i, event := range events {
+		batch[i] = segmentTrack{
+			Type:        "track",
+			Event:       event.Name,
+			AnonymousID: event.AnonymousID,
+			Properties:  event.properties(),
+			Timestamp:   event.Timestamp,
+		}
+	}
+
+	req, err := newJSONRequest(ctx, s.url, map[string]any{"batch": batch})
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.writeKey, "")
+
+	return send(s.client, req)
+}
+
+// PostHogSink sends events to the PostHog capture API
+type PostHogSink struct {
+	client *httpclient.Client
+	url    string
+	apiKey string
+}
+
+// NewPostHogSink creates a PostHogSink for the PostHog instance at host
+func NewPostHogSink(client *httpclient.Client, host, apiKey string) *PostHogSink {
+	return &PostHogSink{
+		client: client,
+		url:    strings.TrimRight(host, "/") + "/batch/",
+		apiKey: apiKey,
+	}
+}
+
+// postHogCapture is a PostHog capture call
+type postHogCapture struct {
+	Event      string         `json:"event"`
+	DistinctID string         `json:"distinct_id"`
+	Properties map[string]any `json:"properties"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// Send posts the events as one PostHog batch
+func (s *PostHogSink) Send(ctx context.Context, events []Event) error {
+	batch := make([]postHogCapture, len(events))
+	for i, event := range events {
+		properties := event.properties()
+		// Anonymous IDs must not create person profiles
+		properties["$process_person_profile"] = false
+		batch[i] = postHogCapture{
+			Event:      event.Name,
+			DistinctID: event.AnonymousID,
+			Properties: properties,
+			Timestamp:  event.Timestamp,
+		}
+	}
+
+	req, err := newJSONRequest(ctx, s.url, map[string]any{"api_key": s.apiKey, "batch": batch})
+	if err != nil {
+		return err
+	}
+
+	return send(s.client, req)
+}
+
+// properties returns the event fields reported as provider properties
+func (e Event) properties() map[string]any {
+	return map[string]any{
+		"feature":    e.Feature,
+		"endpoint":   e.Endpoint,
+		"method":     e.Method,
+		"status":     e.Status,
+		"latency_ms": e.LatencyMS,
+	}
+}
+
+// newJSONRequest builds a POST request with body encoded as JSON
+func newJSONRequest(ctx context.Context, url string, body any) (*http.Request, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode analytics batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// send sends req and treats any non-2xx response as a failure
+func send(client *httpclient.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("analytics sink returned %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package apperror
+
+import "net/http"
+
+// CodeInfo documents an error code and the HTTP status it is returned with
+type CodeInfo struct {
+	Code        ErrorCode
+	Status      int
+	Description string
+}
+
+// codes is the single source of truth for error codes and their HTTP statuses.
+// Add new codes here so they are served by the error code listing endpoint.
+var codes = []CodeInfo{
+	{CodeBadRequest, http.StatusBadRequest, "The request is malformed, such as invalid JSON or an unknown query parameter value"},
+	{CodeValidation, http.StatusBadRequest, "One or more fields failed validation; see details"},
+	{CodeUnauthorized, http.StatusUnauthorized, "Authentication is missing, invalid, or expired"},
+	{CodeInvalidCredentials, http.StatusUnauthorized, "The email or password is incorrect"},
+	{CodeInvalidSignature, http.StatusUnauthorized, "The request signature is missing, invalid, expired, or was already used"},
+	{CodeForbidden, http.StatusForbidden, "The authenticated user may not access the resource"},
+	{CodeAccountSuspended, http.StatusForbidden, "The account is suspended or pending deletion and cannot be used"},
+	{CodeBotDetected, http.StatusForbidden, "The request was flagged as automated by a honeypot, timing or captcha check"},
+	{CodeNotFound, http.StatusNotFound, "The resource or route does not exist"},
+	{CodeMethodNotAllowed, http.StatusMethodNotAllowed, "The route does not support the HTTP method; see the Allow header"},
+	{CodeUserExists, http.StatusConflict, "A user with this email already exists"},
+	{CodeConflict, http.StatusConflict, "The request conflicts with the current state of the resource"},
+	{CodePreconditionFailed, http.StatusPreconditionFailed, "A conditional request header did not match the resource"},
+	{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body exceeds the size limit"},
+	{CodeUnsupportedMedia, http.StatusUnsupportedMediaType, "The request Content-Type is not supported by the endpoint"},
+	{CodeInvalidReference, http.StatusUnprocessableEntity, "The request refers to a related resource that does not exist"},
+	{CodeRateLimited, http.StatusTooManyRequests, "Too many requests; retry after the Retry-After delay"},
+	{CodeInternal, http.StatusInternalServerError, "An unexpected server error occurred"},
+	{CodeUnavailable, http.StatusServiceUnavailable, "The server is overloaded or a dependency is down; retry later"},
+}
+
+// statusByCode indexes codes for StatusFor
+var statusByCode = func() map[ErrorCode]int {
+	m := make(map[ErrorCode]int, len(codes))
+	for _, c := range codes {
+		m[c.Code] = c.Status
+	}
+	return m
+}()
+
+// Codes returns every registered error code in documentation order
+func Codes() []CodeInfo {
+	out := make([]CodeInfo, len(codes))
+	copy(out, codes)
+	return out
+}
+
+// StatusFor returns the HTTP status for an error code, or 500 for unknown codes
+func StatusFor(code ErrorCode) int {
+	if status, ok := statusByCode[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
@@ -1,9 +1,6 @@
 package apperror
 
-import (
-	"fmt"
-	"net/http"
-)
+import "fmt"
 
 // ErrorCode represents application error codes
 type ErrorCode string
@@ -17,6 +14,17 @@ const (
 	CodeUnauthorized       ErrorCode = "UNAUTHORIZED"
 	CodeInternal           ErrorCode = "INTERNAL_ERROR"
 	CodeBadRequest         ErrorCode = "BAD_REQUEST"
+	CodeConflict           ErrorCode = "CONFLICT"
+	CodeUnavailable        ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeRateLimited        ErrorCode = "RATE_LIMITED"
+	CodePayloadTooLarge    ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodePreconditionFailed ErrorCode = "PRECONDITION_FAILED"
+	CodeMethodNotAllowed   ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeUnsupportedMedia   ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodeAccountSuspended   ErrorCode = "ACCOUNT_SUSPENDED"
+	CodeInvalidReference   ErrorCode = "INVALID_REFERENCE"
+	CodeInvalidSignature   ErrorCode = "INVALID_SIGNATURE"
+	CodeBotDetected        ErrorCode = "BOT_DETECTED"
 )
 
 // AppError represents an application error
@@ -62,54 +70,132 @@ func (e *AppError) WithDetails(details ...string) *AppError {
 	}
 }
 
+// WithCause returns a copy of the error caused by err.
+// The cause is logged by the handler but never sent to the client.
+func (e *AppError) WithCause(err error) *AppError {
+	return &AppError{
+		Code:    e.Code,
+		Message: e.Message,
+		Status:  e.Status,
+		Details: e.Details,
+		Err:     err,
+	}
+}
+
 // Predefined errors
 var (
 	ErrInvalidCredentials = &AppError{
 		Code:    CodeInvalidCredentials,
 		Message: "Invalid email or password",
-		Status:  http.StatusUnauthorized,
+		Status:  StatusFor(CodeInvalidCredentials),
 	}
 
 	ErrUserExists = &AppError{
 		Code:    CodeUserExists,
 		Message: "User with this email already exists",
-		Status:  http.StatusConflict,
+		Status:  StatusFor(CodeUserExists),
 	}
 
 	ErrNotFound = &AppError{
 		Code:    CodeNotFound,
 		Message: "Resource not found",
-		Status:  http.StatusNotFound,
+		Status:  StatusFor(CodeNotFound),
 	}
 
 	ErrForbidden = &AppError{
 		Code:    CodeForbidden,
 		Message: "You don't have permission to access this resource",
-		Status:  http.StatusForbidden,
+		Status:  StatusFor(CodeForbidden),
 	}
 
 	ErrUnauthorized = &AppError{
 		Code:    CodeUnauthorized,
 		Message: "Authentication required",
-		Status:  http.StatusUnauthorized,
+		Status:  StatusFor(CodeUnauthorized),
 	}
 
 	ErrInternal = &AppError{
 		Code:    CodeInternal,
 		Message: "An unexpected error occurred",
-		Status:  http.StatusInternalServerError,
+		Status:  StatusFor(CodeInternal),
 	}
 
 	ErrValidation = &AppError{
 		Code:    CodeValidation,
 		Message: "Validation failed",
-		Status:  http.StatusBadRequest,
+		Status:  StatusFor(CodeValidation),
 	}
 
 	ErrBadRequest = &AppError{
 		Code:    CodeBadRequest,
 		Message: "Bad request",
-		Status:  http.StatusBadRequest,
+		Status:  StatusFor(CodeBadRequest),
+	}
+
+	ErrConflict = &AppError{
+		Code:    CodeConflict,
+		Message: "The resource conflicts with an existing resource",
+		Status:  StatusFor(CodeConflict),
+	}
+
+	ErrUnavailable = &AppError{
+		Code:    CodeUnavailable,
+		Message: "The server is temporarily overloaded, please retry later",
+		Status:  StatusFor(CodeUnavailable),
+	}
+
+	ErrRateLimited = &AppError{
+		Code:    CodeRateLimited,
+		Message: "Too many requests, please retry later",
+		Status:  StatusFor(CodeRateLimited),
+	}
+
+	ErrPayloadTooLarge = &AppError{
+		Code:    CodePayloadTooLarge,
+		Message: "Request body is too large",
+		Status:  StatusFor(CodePayloadTooLarge),
+	}
+
+	ErrPreconditionFailed = &AppError{
+		Code:    CodePreconditionFailed,
+		Message: "A request precondition was not met",
+		Status:  StatusFor(CodePreconditionFailed),
+	}
+
+	ErrMethodNotAllowed = &AppError{
+		Code:    CodeMethodNotAllowed,
+		Message: "Method not allowed",
+		Status:  StatusFor(CodeMethodNotAllowed),
+	}
+
+	ErrUnsupportedMedia = &AppError{
+		Code:    CodeUnsupportedMedia,
+		Message: "Unsupported content type",
+		Status:  StatusFor(CodeUnsupportedMedia),
+	}
+
+	ErrAccountSuspended = &AppError{
+		Code:    CodeAccountSuspended,
+		Message: "Account is suspended",
+		Status:  StatusFor(CodeAccountSuspended),
+	}
+
+	ErrInvalidReference = &AppError{
+		Code:    CodeInvalidReference,
+		Message: "Referenced resource does not exist",
+		Status:  StatusFor(CodeInvalidReference),
+	}
+
+	ErrInvalidSignature = &AppError{
+		Code:    CodeInvalidSignature,
+		Message: "Request signature is invalid",
+		Status:  StatusFor(CodeInvalidSignature),
+	}
+
+	ErrBotDetected = &AppError{
+		Code:    CodeBotDetected,
+		Message: "The request looks automated",
+		Status:  StatusFor(CodeBotDetected),
 	}
 )
 
@@ -0,0 +1,164 @@
+// Package dlock provides a mutex shared by every instance of the API, backed by
+// Postgres session-level advisory locks.
+//
+// A Lock holds a pooled connection for as long as it is held, because advisory
+// locks belong to the session that took them. If the lock cannot be released
+// cleanly the connection is closed, which makes Postgres release it.
+package dlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+)
+
+// KeySpace is the first key of every advisory lock taken by this package, with
+// the hashed lock name as the second. Two-key locks never conflict with the
+// single-key locks taken elsewhere. Code that locks outside a Locker uses the
+// same space, under a name no Locker uses.
+const KeySpace int32 = 0x746a646c // "tjdl"
+
+// Polling bounds for Acquire while another session holds the lock
+const (
+	minPollInterval = 50 * time.Millisecond
+	maxPollInterval = time.Second
+)
+
+// ErrLocked is returned by TryAcquire when another session holds the lock
+var ErrLocked = errors.New("dlock: lock is held by another session")
+
+// Locker acquires named locks. It is safe for concurrent use.
+type Locker struct {
+	pool    *pgxpool.Pool
+	metrics *metrics.Locks
+}
+
+// NewLocker creates a Locker on pool. m may be nil.
+func NewLocker(pool *pgxpool.Pool, m *metrics.Locks) *Locker {
+	return &Locker{
+		pool:    pool,
+		metrics: m,
+	}
+}
+
+// Lock is a held lock. Release must be called once the critical section ends.
+type Lock struct {
+	name string
+	conn *pgxpool.Conn
+	once sync.Once
+}
+
+// TryAcquire takes the named lock if it is free, or returns ErrLocked
+func (l *Locker) TryAcquire(ctx context.Context, name string) (*Lock, error) {
+	start := time.Now()
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		l.metrics.Observe(name, "error", time.Since(start))
+		return nil, fmt.Errorf("dlock: failed to acquire connection for %s: %w", name, err)
+	}
+
+	ok, err := tryLock(ctx, conn, name)
+	if err != nil {
+		conn.Release()
+		l.metrics.Observe(name, "error", time.Since(start))
+		return nil, err
+	}
+	if !ok {
+		conn.Release()
+		l.metrics.Observe(name, "contended", time.Since(start))
+		return nil, ErrLocked
+	}
+
+	l.metrics.Observe(name, "acquired", time.Since(start))
+	return &Lock{name: name, conn: conn}, nil
+}
+
+// Acquire takes the named lock, waiting while another session holds it until
+// ctx is done. Bound the wait with context.WithTimeout.
+func (l *Locker) Acquire(ctx context.Context, name string) (*Lock, error) {
+	start := time.Now()
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		l.metrics.Observe(name, "error", time.Since(start))
+		return nil, fmt.Errorf("dlock: failed to acquire connection for %s: %w", name, err)
+	}
+
+	outcome := "acquired"
+	interval := minPollInterval
+	for {
+		ok, err := tryLock(ctx, conn, name)
+		if err != nil {
+			conn.Release()
+			if ctx.Err() != nil {
+				l.metrics.Observe(name, "timeout", time.Since(start))
+				return nil, fmt.Errorf("dlock: gave up waiting for %s: %w", name, ctx.Err())
+			}
+			l.metrics.Observe(name, "error", time.Since(start))
+			return nil, err
+		}
+		if ok {
+			l.metrics.Observe(name, outcome, time.Since(start))
+			return &Lock{name: name, conn: conn}, nil
+		}
+		outcome = "waited"
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			conn.Release()
+			l.metrics.Observe(name, "timeout", time.Since(start))
+			return nil, fmt.Errorf("dlock: gave up waiting for %s: %w", name, ctx.Err())
+		case <-timer.C:
+		}
+		interval = min(interval*2, maxPollInterval)
+	}
+}
+
+// Do runs fn while holding the named lock, waiting for it as Acquire does
+func (l *Locker) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	lock, err := l.Acquire(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return fn(ctx)
+}
+
+// Release releases the lock and returns its connection to the pool. Releasing
+// a lock more than once is a no-op.
+func (k *Lock) Release(ctx context.Context) error {
+	var err error
+	k.once.Do(func() {
+		var released bool
+		err = k.conn.QueryRow(ctx, `SELECT pg_advisory_unlock($1, hashtext($2))`, KeySpace, k.name).Scan(&released)
+		if err == nil && !released {
+			err = fmt.Errorf("dlock: %s was not held by this session", k.name)
+		}
+		if err != nil {
+			// Ending the session is the only other way to release the lock;
+			// the pool discards closed connections
+			_ = k.conn.Conn().Close(context.WithoutCancel(ctx))
+			err = fmt.Errorf("dlock: failed to release %s: %w", k.name, err)
+		}
+		k.conn.Release()
+	})
+	return err
+}
+
+// tryLock takes the named lock on conn without waiting and reports whether it did
+func tryLock(ctx context.Context, conn *pgxpool.Conn, name string) (bool, error) {
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, KeySpace, name).Scan(&ok); err != nil {
+		return false, fmt.Errorf("dlock: failed to lock %s: %w", name, err)
+	}
+	return ok, nil
+}
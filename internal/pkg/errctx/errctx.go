@@ -0,0 +1,57 @@
+// Package errctx attaches an operation name and log attributes to errors as they
+// bubble up, so the layer that finally handles an error can log it once with full context.
+package errctx
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error is an error annotated with the operation that failed and key/value attributes
+type Error struct {
+	Op    string
+	Attrs []any
+	Err   error
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap implements the errors.Unwrap interface
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap annotates err with an operation name and slog-style key/value attributes.
+// It returns nil if err is nil.
+func Wrap(err error, op string, attrs ...any) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Op: op, Attrs: attrs, Err: err}
+}
+
+// LogAttrs collects the context attached to every Error in the chain of err,
+// outermost first, as slog key/value pairs. Operations are reported under "op",
+// joined with " > ". It returns nil if err carries no context.
+func LogAttrs(err error) []any {
+	var ops []string
+	var attrs []any
+
+	for err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			break
+		}
+		ops = append(ops, e.Op)
+		attrs = append(attrs, e.Attrs...)
+		err = e.Err
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+	return append([]any{"op", strings.Join(ops, " > ")}, attrs...)
+}
@@ -0,0 +1,89 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCircuits bounds the circuits kept in memory. Past it, circuits that are
+// not open are dropped when a new one is needed.
+const maxCircuits = 10000
+
+// breaker is the state of one circuit
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	// probing is set while the single trial request after a cooldown is in flight
+	probing bool
+}
+
+// breakers tracks a circuit per key, the host or the full URL of a request.
+// A circuit opens after threshold consecutive failures. Once the cooldown
+// passes, one trial request is let through: success closes the circuit and
+// failure reopens it for another cooldown.
+type breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*breaker
+}
+
+func newBreakers(threshold int, cooldown time.Duration) *breakers {
+	return &breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*breaker),
+	}
+}
+
+// allow reports whether a request on key's circuit may be sent now
+func (b *breakers) allow(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.circuits[key]
+	if !ok || br.failures < b.threshold {
+		return true
+	}
+	if br.probing || time.Now().Before(br.openUntil) {
+		return false
+	}
+	br.probing = true
+	return true
+}
+
+// record updates key's circuit with the outcome of a request
+func (b *breakers) record(key string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		delete(b.circuits, key)
+		return
+	}
+
+	br, ok := b.circuits[key]
+	if !ok {
+		if len(b.circuits) >= maxCircuits {
+			b.pruneLocked()
+		}
+		br = &breaker{}
+		b.circuits[key] = br
+	}
+	br.failures++
+	br.probing = false
+	if br.failures >= b.threshold {
+		br.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// pruneLocked drops the circuits that are not open or probing
+func (b *breakers) pruneLocked() {
+	now := time.Now()
+	for key, br := range b.circuits {
+		if !br.probing && now.After(br.openUntil) {
+			delete(b.circuits, key)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package httpclient is the HTTP client for calls the API makes to other
+// services. It bounds every attempt with a timeout, retries idempotent requests
+// with jittered backoff, stops calling hosts that keep failing, propagates the
+// trace context, and records latency metrics.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+	"github.com/whauzan/todo-api/internal/pkg/tracing"
+)
+
+// ErrCircuitOpen is returned without sending the request while its circuit is open
+var ErrCircuitOpen = errors.New("httpclient: circuit open")
+
+// Config tunes a Client. Zero fields take the values from DefaultConfig.
+type Config struct {
+	// Timeout bounds each attempt, including reading the response headers
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt for idempotent
+	// requests. A negative value disables retries.
+	MaxRetries int
+	// BaseBackoff and MaxBackoff bound the full-jitter backoff between attempts
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// FailureThreshold consecutive failures to a host open its circuit for Cooldown
+	FailureThreshold int
+	Cooldown         time.Duration
+	// CircuitPerURL keeps a circuit per request URL instead of per host, for
+	// URLs chosen by users, so one failing URL does not hold back the others
+	// on its host
+	CircuitPerURL bool
+	// PublicOnly refuses connections to loopback, private and link-local
+	// addresses and does not follow redirects, for URLs chosen by users
+	PublicOnly bool
+}
+
+// DefaultConfig returns settings suitable for third-party APIs
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          10 * time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// Client sends outbound requests. It is safe for concurrent use.
+type Client struct {
+	http     *http.Client
+	cfg      Config
+	breakers *breakers
+	metrics  *metrics.Outbound
+	logger   *slog.Logger
+}
+
+// New creates a Client. m may be nil to skip metrics.
+func New(cfg Config, m *metrics.Outbound, logger *slog.Logger) *Client {
+	def := DefaultConfig()
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = def.MaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = def.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = def.FailureThreshold
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = def.Cooldown
+	}
+
+	httpClient := &http.Client{Timeout: cfg.Timeout}
+	if cfg.PublicOnly {
+		httpClient = publicHTTPClient(cfg.Timeout)
+	}
+
+	return &Client{
+		http:     httpClient,
+		cfg:      cfg,
+		breakers: newBreakers(cfg.FailureThreshold, cfg.Cooldown),
+		metrics:  m,
+		logger:   logger,
+	}
+}
+
+// Do sends req, retrying it when it is idempotent and the failure is transient.
+// The caller must close the returned response body, as with http.Client.Do.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	circuit := host
+	if c.cfg.CircuitPerURL {
+		circuit = req.URL.String()
+	}
+	tracing.Inject(req.Context(), req.Header)
+
+	attempts := 1
+	if retryable(req) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.rewind(req); err != nil {
+				return nil, err
+			}
+			if err := sleep(req.Context(), c.backoff(attempt, lastErr)); err != nil {
+				return nil, errors.Join(lastErr, err)
+			}
+		}
+
+		if !c.breakers.allow(circuit) {
+			c.metrics.Observe(host, req.Method, "circuit_open", 0)
+			return nil, fmt.Errorf("%w for %s", ErrCircuitOpen, host)
+		}
+
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		elapsed := time.Since(start)
+
+		if err != nil {
+			c.metrics.Observe(host, req.Method, "error", elapsed)
+			// A refused address is the caller's mistake, not the host failing,
+			// and asking again would be refused too
+			if errors.Is(err, ErrForbiddenAddress) {
+				return nil, err
+			}
+			c.breakers.record(circuit, false)
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+		} else {
+			c.metrics.Observe(host, req.Method, strconv.Itoa(resp.StatusCode), elapsed)
+			c.breakers.record(circuit, resp.StatusCode < 500)
+			if !retryableStatus(resp.StatusCode) || attempt == attempts-1 {
+				return resp, nil
+			}
+			lastErr = &statusError{code: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+			resp.Body.Close()
+		}
+
+		if attempt < attempts-1 {
+			c.logger.Debug("retrying outbound request",
+				"host", host,
+				"method", req.Method,
+				"attempt", attempt+1,
+				"error", lastErr,
+			)
+		}
+	}
+	return nil, lastErr
+}
+
+// rewind resets the request body for another attempt
+func (c *Client) rewind(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("httpclient: failed to rewind body: %w", err)
+	}
+	req.Body = body
+	return nil
+}
+
+// backoff returns a full-jitter delay for the attempt, honoring a server's Retry-After
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	var se *statusError
+	if errors.As(lastErr, &se) && se.retryAfter > 0 {
+		return min(se.retryAfter, c.cfg.MaxBackoff)
+	}
+	ceiling := min(c.cfg.BaseBackoff<<(attempt-1), c.cfg.MaxBackoff)
+	return rand.N(ceiling + 1)
+}
+
+// retryable reports whether req can safely be sent more than once
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// Providers that deduplicate on Idempotency-Key make POST safe to retry
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// retryableStatus reports whether the status indicates a transient failure
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After value given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// statusError is the error reported when retries end on a retryable status
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("httpclient: upstream returned %d", e.code)
+}
@@ -0,0 +1,61 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name          string
+		perURL        bool
+		healthyOpened bool
+	}{
+		{name: "per host", perURL: false, healthyOpened: true},
+		{name: "per URL", perURL: true, healthyOpened: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(Config{MaxRetries: -1, FailureThreshold: 2, Cooldown: time.Minute, CircuitPerURL: tt.perURL},
+				nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			post := func(path string) error {
+				req, err := http.NewRequest(http.MethodPost, srv.URL+path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err := c.Do(req)
+				if err != nil {
+					return err
+				}
+				return resp.Body.Close()
+			}
+
+			for i := 0; i < 2; i++ {
+				if err := post("/broken"); err != nil {
+					t.Fatalf("request %d: %v", i, err)
+				}
+			}
+			if err := post("/broken"); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("after the threshold: error = %v, want ErrCircuitOpen", err)
+			}
+
+			err := post("/healthy")
+			if opened := errors.Is(err, ErrCircuitOpen); opened != tt.healthyOpened {
+				t.Fatalf("other URL on the host: error = %v, want circuit open %v", err, tt.healthyOpened)
+			}
+		})
+	}
+}
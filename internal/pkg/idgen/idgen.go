@@ -0,0 +1,41 @@
+package idgen
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// V4 generates random UUIDs
+	V4 = 4
+	// V7 generates time-ordered UUIDs for better index locality
+	V7 = 7
+)
+
+// Generator generates primary keys for new entities
+type Generator struct {
+	version int
+}
+
+// NewGenerator creates a new Generator for the given UUID version (4 or 7)
+func NewGenerator(version int) (*Generator, error) {
+	if version != V4 && version != V7 {
+		return nil, fmt.Errorf("unsupported UUID version: %d", version)
+	}
+	return &Generator{
+		version: version,
+	}, nil
+}
+
+// New returns a new ID.
+// If a time-ordered ID cannot be generated, a random one is returned instead;
+// both versions share the same column type and remain valid keys.
+func (g *Generator) New() uuid.UUID {
+	if g.version == V7 {
+		if id, err := uuid.NewV7(); err == nil {
+			return id
+		}
+	}
+	return uuid.New()
+}
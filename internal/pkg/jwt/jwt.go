@@ -2,6 +2,7 @@ package jwt
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,8 +11,11 @@ import (
 
 // Claims represents the JWT claims
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
-	Email  string    `json:"email"`
+	UserID       uuid.UUID `json:"user_id"`
+	Email        string    `json:"email"`
+	TokenVersion int       `json:"token_version"`    // Must match the user's current version, see UserRepository.RevokeTokens
+	Scope        string    `json:"scope,omitempty"`  // Space-separated scopes, see ScopesFor
+	Origin       string    `json:"origin,omitempty"` // Browser origin the token is bound to, see GenerateOriginToken
 	jwt.RegisteredClaims
 }
 
@@ -35,16 +39,32 @@ func NewTokenManager(secretKey string, expiryHours int) *TokenManager {
 type TokenResponse struct {
 	Token     string
 	ExpiresAt time.Time
+	Scopes    []string
 }
 
-// GenerateToken generates a new JWT token for the given user
-func (tm *TokenManager) GenerateToken(userID uuid.UUID, email string) (*TokenResponse, error) {
+// GenerateToken generates a new JWT token for the given user and token version,
+// granting scopes
+func (tm *TokenManager) GenerateToken(userID uuid.UUID, email string, tokenVersion int, scopes []string) (*TokenResponse, error) {
+	return tm.generate(userID, email, tokenVersion, scopes, "", time.Duration(tm.expiryHours)*time.Hour)
+}
+
+// GenerateOriginToken generates a token that is only accepted on requests
+// from pages on origin, valid for ttl. Origin-bound tokens cannot be refreshed.
+func (tm *TokenManager) GenerateOriginToken(userID uuid.UUID, email string, tokenVersion int, scopes []string, origin string, ttl time.Duration) (*TokenResponse, error) {
+	return tm.generate(userID, email, tokenVersion, scopes, origin, ttl)
+}
+
+// generate signs a token with the given claims expiring after ttl
+func (tm *TokenManager) generate(userID uuid.UUID, email string, tokenVersion int, scopes []string, origin string, ttl time.Duration) (*TokenResponse, error) {
 	now := time.Now()
-	expiresAt := now.Add(time.Duration(tm.expiryHours) * time.Hour)
+	expiresAt := now.Add(ttl)
 
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:       userID,
+		Email:        email,
+		TokenVersion: tokenVersion,
+		Scope:        strings.Join(scopes, " "),
+		Origin:       origin,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -62,6 +82,7 @@ func (tm *TokenManager) GenerateToken(userID uuid.UUID, email string) (*TokenRes
 	return &TokenResponse{
 		Token:     signedToken,
 		ExpiresAt: expiresAt,
+		Scopes:    scopes,
 	}, nil
 }
 
@@ -93,7 +114,10 @@ func (tm *TokenManager) RefreshToken(tokenString string) (*TokenResponse, error)
 	if err != nil {
 		return nil, err
 	}
+	if claims.Origin != "" {
+		return nil, fmt.Errorf("origin-bound tokens cannot be refreshed")
+	}
 
-	// Generate a new token with the same user info
-	return tm.GenerateToken(claims.UserID, claims.Email)
+	// Generate a new token with the same user info and scopes
+	return tm.GenerateToken(claims.UserID, claims.Email, claims.TokenVersion, claims.Scopes())
 }
@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"slices"
+	"strings"
+)
+
+// Scopes a token can grant
+const (
+	// ScopeTodosRead allows reading todos
+	ScopeTodosRead = "todos:read"
+	// ScopeTodosWrite allows creating, changing, deleting and syncing todos
+	ScopeTodosWrite = "todos:write"
+	// ScopeAccount allows managing the account and its settings, notifications and announcements
+	ScopeAccount = "account"
+	// ScopeWidget allows reading the todo list shown by an embedded widget
+	ScopeWidget = "widget"
+	// ScopeAgentRead allows calling the read-only agent tools
+	ScopeAgentRead = "agent:read"
+	// ScopeAgentWrite allows calling the agent tools that change todos
+	ScopeAgentWrite = "agent:write"
+)
+
+// Client types a token can be issued to
+const (
+	ClientWeb    = "web"
+	ClientMobile = "mobile"
+	ClientAPIKey = "api-key"
+	// Agent tokens only reach the agent tools, so an LLM agent holding one
+	// can do no more than the tools allow
+	ClientAgent         = "agent"
+	ClientAgentReadOnly = "agent-readonly"
+)
+
+// clientScopes are the scopes issued to each client type
+var clientScopes = map[string][]string{
+	ClientWeb:    {ScopeTodosRead, ScopeTodosWrite, ScopeAccount},
+	ClientMobile: {ScopeTodosRead, ScopeTodosWrite, ScopeAccount},
+	ClientAPIKey: {ScopeTodosRead},
+
+	ClientAgent:         {ScopeAgentRead, ScopeAgentWrite},
+	ClientAgentReadOnly: {ScopeAgentRead},
+}
+
+// ScopesFor returns the scopes issued to a client type, defaulting to the web client's
+func ScopesFor(client string) []string {
+	scopes, ok := clientScopes[client]
+	if !ok {
+		scopes = clientScopes[ClientWeb]
+	}
+	return slices.Clone(scopes)
+}
+
+// Scopes returns the scopes granted by the claims. Tokens issued before scopes
+// were introduced carry none and are treated as web client tokens until they expire.
+func (c *Claims) Scopes() []string {
+	if c.Scope == "" {
+		return ScopesFor(ClientWeb)
+	}
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the claims grant scope
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
@@ -0,0 +1,87 @@
+// Package mdtext turns Markdown into short plain text for previews
+package mdtext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis marks a truncated preview
+const Ellipsis = "…"
+
+var (
+	codeFence  = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	image      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	link       = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	autolink   = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	blockStart = regexp.MustCompile(`(?m)^\s{0,3}(?:#{1,6}\s+|>\s?|[-*+]\s+\[[ xX]\]\s+|[-*+]\s+|\d+[.)]\s+)`)
+	rule       = regexp.MustCompile(`(?m)^\s{0,3}(?:[-*_]\s*){3,}$`)
+	emphasis   = regexp.MustCompile("\\*+|~~|`+")
+	space      = regexp.MustCompile(`\s+`)
+)
+
+// Plain strips Markdown syntax from s and collapses whitespace, keeping the
+// text of links and images. It is meant for previews, not as a full parser:
+// asterisks and backticks are removed wherever they appear, and underscores
+// unless they are inside a word.
+func Plain(s string) string {
+	s = codeFence.ReplaceAllString(s, "")
+	s = image.ReplaceAllString(s, "$1")
+	s = link.ReplaceAllString(s, "$1")
+	s = autolink.ReplaceAllString(s, "$1")
+	s = rule.ReplaceAllString(s, "")
+	s = blockStart.ReplaceAllString(s, "")
+	s = emphasis.ReplaceAllString(s, "")
+	s = stripUnderscores(s)
+	return strings.TrimSpace(space.ReplaceAllString(s, " "))
+}
+
+// Preview returns the plain text of s cut to at most n characters, ending
+// with an ellipsis when it was cut. Cuts fall between words when possible.
+func Preview(s string, n int) string {
+	text := Plain(s)
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:n-1])
+	if i := strings.LastIndexByte(cut, ' '); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ") + Ellipsis
+}
+
+// stripUnderscores removes the underscores of emphasis, keeping those inside
+// words such as snake_case
+func stripUnderscores(s string) string {
+	runes := []rune(s)
+	wordAt := func(i int) bool {
+		return i >= 0 && i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if r != '_' {
+			b.WriteRune(r)
+			continue
+		}
+		// Look past the whole run of underscores
+		start, end := i, i
+		for start > 0 && runes[start-1] == '_' {
+			start--
+		}
+		for end < len(runes)-1 && runes[end+1] == '_' {
+			end++
+		}
+		if wordAt(start-1) && wordAt(end+1) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
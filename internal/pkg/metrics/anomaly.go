@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// DetectorConfig holds the thresholds for the anomaly detector
+type DetectorConfig struct {
+	// Interval is how often rates are computed and checked
+	Interval time.Duration
+	// LoginFailureRatio is the share of failed logins in one interval that raises an alert
+	LoginFailureRatio float64
+	// MinSamples is the number of login attempts an interval needs before it is checked
+	MinSamples int64
+}
+
+// Detector publishes per-minute rates for the KPIs and logs an alert
+// when the login failure ratio exceeds its threshold
+type Detector struct {
+	kpis   *KPIs
+	cfg    DetectorConfig
+	logger *slog.Logger
+
+	registrationsPerMinute  *Gauge
+	loginsPerMinute         *Gauge
+	loginFailuresPerMinute  *Gauge
+	todosCreatedPerMinute   *Gauge
+	todosCompletedPerMinute *Gauge
+	anomalies               *Counter
+}
+
+// NewDetector registers the rate gauges on reg and creates a Detector
+func NewDetector(reg *Registry, kpis *KPIs, cfg DetectorConfig, logger *slog.Logger) *Detector {
+	return &Detector{
+		kpis:                    kpis,
+		cfg:                     cfg,
+		logger:                  logger,
+		registrationsPerMinute:  reg.NewGauge("taskjoy_registrations_per_minute", "Registrations in the last interval, per minute."),
+		loginsPerMinute:         reg.NewGauge("taskjoy_logins_per_minute", "Successful logins in the last interval, per minute."),
+		loginFailuresPerMinute:  reg.NewGauge("taskjoy_login_failures_per_minute", "Failed logins in the last interval, per minute."),
+		todosCreatedPerMinute:   reg.NewGauge("taskjoy_todos_created_per_minute", "Todos created in the last interval, per minute."),
+		todosCompletedPerMinute: reg.NewGauge("taskjoy_todos_completed_per_minute", "Todos completed in the last interval, per minute."),
+		anomalies:               reg.NewCounter("taskjoy_anomalies_total", "Anomaly alerts raised."),
+	}
+}
+
+// snapshot is the counter values at the end of an interval
+type snapshot struct {
+	registrations, logins, loginFailures, todosCreated, todosCompleted int64
+}
+
+func (d *Detector) snapshot() snapshot {
+	return snapshot{
+		registrations:  d.kpis.registrations.Value(),
+		logins:         d.kpis.logins.Value(),
+		loginFailures:  d.kpis.loginFailures.Value(),
+		todosCreated:   d.kpis.todosCreated.Value(),
+		todosCompleted: d.kpis.todosCompleted.Value(),
+	}
+}
+
+// Run checks the counters every interval until ctx is cancelled
+func (d *Detector) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	prev := d.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cur := d.snapshot()
+			d.check(ctx, prev, cur)
+			prev = cur
+		}
+	}
+}
+
+// check publishes the rates between two snapshots and raises alerts
+func (d *Detector) check(ctx context.Context, prev, cur snapshot) {
+	perMinute := func(delta int64) float64 {
+		return float64(delta) / d.cfg.Interval.Minutes()
+	}
+
+	logins := cur.logins - prev.logins
+	failures := cur.loginFailures - prev.loginFailures
+
+	d.registrationsPerMinute.Set(perMinute(cur.registrations - prev.registrations))
+	d.loginsPerMinute.Set(perMinute(logins))
+	d.loginFailuresPerMinute.Set(perMinute(failures))
+	d.todosCreatedPerMinute.Set(perMinute(cur.todosCreated - prev.todosCreated))
+	d.todosCompletedPerMinute.Set(perMinute(cur.todosCompleted - prev.todosCompleted))
+
+	attempts := logins + failures
+	if attempts < d.cfg.MinSamples || attempts == 0 {
+		return
+	}
+
+	ratio := float64(failures) / float64(attempts)
+	if ratio > d.cfg.LoginFailureRatio {
+		d.anomalies.Inc()
+		d.logger.WarnContext(ctx, "anomaly detected: login failure ratio above threshold",
+			"alert", "login_failure_ratio",
+			"ratio", ratio,
+			"threshold", d.cfg.LoginFailureRatio,
+			"failures", failures,
+			"attempts", attempts,
+			"interval", d.cfg.Interval)
+	}
+}
@@ -0,0 +1,24 @@
+package metrics
+
+// Bots tracks the bot checks of public endpoints, per path and outcome.
+// A nil Bots ignores checks.
+type Bots struct {
+	checks *CounterVec
+}
+
+// NewBots registers the bot check metrics on reg
+func NewBots(reg *Registry) *Bots {
+	return &Bots{
+		checks: reg.NewCounterVec("taskjoy_bot_checks_total", "Bot checks of public endpoints by path and outcome.", "path", "outcome"),
+	}
+}
+
+// Observe records one checked request. outcome is "passed", the reason the
+// request was rejected, or "captcha_error" when the captcha provider could not
+// be asked and the request was let through.
+func (b *Bots) Observe(path, outcome string) {
+	if b == nil {
+		return
+	}
+	b.checks.With(path, outcome).Inc()
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTP tracks request counts and latencies per route. A nil HTTP ignores requests.
+type HTTP struct {
+	requests *CounterVec
+	duration *DurationVec
+}
+
+// NewHTTP registers the request metrics on reg
+func NewHTTP(reg *Registry) *HTTP {
+	return &HTTP{
+		requests: reg.NewCounterVec("taskjoy_http_requests_total", "HTTP requests by method, route pattern, and status.", "method", "route", "status"),
+		duration: reg.NewDurationVec("taskjoy_http_request_duration_seconds", "HTTP request latency by method and route pattern.", "method", "route"),
+	}
+}
+
+// Observe records a finished request. route must be a route pattern, not a raw
+// path, to keep the number of series bounded.
+func (h *HTTP) Observe(method, route string, status int, d time.Duration) {
+	if h == nil {
+		return
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		// Clients control the method, so unknown ones share a series
+		method = "OTHER"
+	}
+
+	h.requests.With(method, route, strconv.Itoa(status)).Inc()
+	h.duration.Observe(d, method, route)
+}
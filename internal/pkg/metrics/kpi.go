@@ -0,0 +1,62 @@
+package metrics
+
+// KPIs tracks business events. A nil KPIs ignores all events,
+// so callers such as one-off commands can skip metrics entirely.
+type KPIs struct {
+	registrations  *Counter
+	logins         *Counter
+	loginFailures  *Counter
+	todosCreated   *Counter
+	todosCompleted *Counter
+}
+
+// NewKPIs registers the business counters on reg
+func NewKPIs(reg *Registry) *KPIs {
+	return &KPIs{
+		registrations:  reg.NewCounter("taskjoy_registrations_total", "Users registered."),
+		logins:         reg.NewCounter("taskjoy_logins_total", "Successful logins."),
+		loginFailures:  reg.NewCounter("taskjoy_login_failures_total", "Logins rejected for invalid credentials."),
+		todosCreated:   reg.NewCounter("taskjoy_todos_created_total", "Todos created."),
+		todosCompleted: reg.NewCounter("taskjoy_todos_completed_total", "Todos marked completed."),
+	}
+}
+
+// RecordRegistration counts a new user
+func (k *KPIs) RecordRegistration() {
+	if k != nil {
+		k.registrations.Inc()
+	}
+}
+
+// RecordLogin counts a login attempt that either succeeded or had invalid credentials
+func (k *KPIs) RecordLogin(success bool) {
+	if k == nil {
+		return
+	}
+	if success {
+		k.logins.Inc()
+	} else {
+		k.loginFailures.Inc()
+	}
+}
+
+// RecordTodoCreated counts a new todo
+func (k *KPIs) RecordTodoCreated() {
+	if k != nil {
+		k.todosCreated.Inc()
+	}
+}
+
+// RecordTodoCompleted counts a todo changing from open to completed
+func (k *KPIs) RecordTodoCompleted() {
+	if k != nil {
+		k.todosCompleted.Inc()
+	}
+}
+
+// RecordTodosCompleted counts n todos changing from open to completed at once
+func (k *KPIs) RecordTodosCompleted(n int64) {
+	if k != nil {
+		k.todosCompleted.Add(n)
+	}
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "time"
+
+// Locks tracks distributed lock acquisitions, per lock name.
+// A nil Locks ignores acquisitions.
+type Locks struct {
+	acquisitions *CounterVec
+	wait         *DurationVec
+}
+
+// NewLocks registers the distributed lock metrics on reg
+func NewLocks(reg *Registry) *Locks {
+	return &Locks{
+		acquisitions: reg.NewCounterVec("taskjoy_lock_acquisitions_total", "Distributed lock acquisition attempts by lock name and outcome.", "name", "outcome"),
+		wait:         reg.NewDurationVec("taskjoy_lock_wait_seconds", "Time spent acquiring distributed locks by lock name.", "name"),
+	}
+}
+
+// Observe records one acquisition attempt. outcome is "acquired", "waited" when
+// the lock was acquired after another session released it, "contended" when
+// it was held and the caller did not wait, "timeout" or "error".
+func (l *Locks) Observe(name, outcome string, wait time.Duration) {
+	if l == nil {
+		return
+	}
+	l.acquisitions.With(name, outcome).Inc()
+	if outcome != "contended" && outcome != "error" {
+		l.wait.Observe(wait, name)
+	}
+}
@@ -0,0 +1,153 @@
+// Package metrics provides counters and gauges exposed in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value. A nil Counter ignores updates.
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc increments the counter by one
+func (c *Counter) Inc() {
+	if c == nil {
+		return
+	}
+	c.value.Add(1)
+}
+
+// Add increments the counter by n, which must not be negative
+func (c *Counter) Add(n int64) {
+	if c == nil || n < 0 {
+		return
+	}
+	c.value.Add(n)
+}
+
+// Value returns the current count
+func (c *Counter) Value() int64 {
+	if c == nil {
+		return 0
+	}
+	return c.value.Load()
+}
+
+// Gauge is a value that can go up and down. A nil Gauge ignores updates.
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) {
+	if g == nil {
+		return
+	}
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	if g == nil {
+		return 0
+	}
+	return math.Float64frombits(g.bits.Load())
+}
+
+// sample is one line of a metric's exposition
+type sample struct {
+	suffix string
+	labels string
+	value  string
+}
+
+// metric is a registered metric family
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	samples func() []sample
+}
+
+// Registry holds named metrics and renders them for scraping
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// NewCounter registers and returns a counter. It panics if the name is taken.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{}
+	r.register(&metric{name: name, help: help, kind: "counter", samples: func() []sample {
+		return []sample{{value: fmt.Sprint(c.Value())}}
+	}})
+	return c
+}
+
+// NewGauge registers and returns a gauge. It panics if the name is taken.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{}
+	r.register(&metric{name: name, help: help, kind: "gauge", samples: func() []sample {
+		return []sample{{value: fmt.Sprint(g.Value())}}
+	}})
+	return g
+}
+
+func (r *Registry) register(m *metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.metrics[m.name]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", m.name))
+	}
+	r.metrics[m.name] = m
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+
+	var total int64
+	for _, m := range metrics {
+		n, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+
+		for _, s := range m.samples() {
+			n, err := fmt.Fprintf(w, "%s%s%s %s\n", m.name, s.suffix, s.labels, s.value)
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
+
+// Handler serves the registry for scraping
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = r.WriteTo(w)
+	})
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import "time"
+
+// Outbound tracks requests the API makes to other services, per host.
+// A nil Outbound ignores requests.
+type Outbound struct {
+	requests *CounterVec
+	duration *DurationVec
+}
+
+// NewOutbound registers the outbound request metrics on reg
+func NewOutbound(reg *Registry) *Outbound {
+	return &Outbound{
+		requests: reg.NewCounterVec("taskjoy_outbound_requests_total", "Outbound HTTP attempts by host, method, and outcome.", "host", "method", "outcome"),
+		duration: reg.NewDurationVec("taskjoy_outbound_request_duration_seconds", "Outbound HTTP attempt latency by host and method.", "host", "method"),
+	}
+}
+
+// Observe records one attempt. outcome is the status code, "error" for a
+// transport failure, or "circuit_open" when the attempt was never sent.
+func (o *Outbound) Observe(host, method, outcome string, d time.Duration) {
+	if o == nil {
+		return
+	}
+	o.requests.With(host, method, outcome).Inc()
+	if outcome != "circuit_open" {
+		o.duration.Observe(d, host, method)
+	}
+}
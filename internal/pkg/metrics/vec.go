@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// labelSet renders label values in the exposition format, e.g. {method="GET",route="/todos"}
+func labelSet(names, values []string) string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + strconv.Quote(values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// vec holds one child per distinct combination of label values
+type vec[T any] struct {
+	labels   []string
+	newChild func() *T
+
+	mu       sync.Mutex
+	children map[string]*T
+}
+
+// with returns the child for the label values, creating it on first use
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(v.labels), len(values)))
+	}
+	key := labelSet(v.labels, values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	child, ok := v.children[key]
+	if !ok {
+		child = v.newChild()
+		v.children[key] = child
+	}
+	return child
+}
+
+// each calls fn for every child in label order
+func (v *vec[T]) each(fn func(labels string, child *T)) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.children))
+	for key := range v.children {
+		keys = append(keys, key)
+	}
+	v.mu.Unlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		v.mu.Lock()
+		child := v.children[key]
+		v.mu.Unlock()
+		fn(key, child)
+	}
+}
+
+// CounterVec is a family of counters partitioned by labels. A nil CounterVec ignores updates.
+type CounterVec struct {
+	vec vec[Counter]
+}
+
+// NewCounterVec registers and returns a labeled counter family
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	cv := &CounterVec{vec: vec[Counter]{
+		labels:   labels,
+		newChild: func() *Counter { return &Counter{} },
+		children: make(map[string]*Counter),
+	}}
+	r.register(&metric{name: name, help: help, kind: "counter", samples: func() []sample {
+		var samples []sample
+		cv.vec.each(func(labels string, c *Counter) {
+			samples = append(samples, sample{labels: labels, value: fmt.Sprint(c.Value())})
+		})
+		return samples
+	}})
+	return cv
+}
+
+// With returns the counter for the label values, in the order the labels were registered
+func (cv *CounterVec) With(values ...string) *Counter {
+	if cv == nil {
+		return nil
+	}
+	return cv.vec.with(values)
+}
+
+// summary accumulates a count and a sum of observations
+type summary struct {
+	mu    sync.Mutex
+	count int64
+	sum   float64
+}
+
+// DurationVec is a family of duration summaries, in seconds, partitioned by labels.
+// A nil DurationVec ignores observations.
+type DurationVec struct {
+	vec vec[summary]
+}
+
+// NewDurationVec registers and returns a labeled duration summary family.
+// Prometheus can derive the mean from the _sum and _count series.
+func (r *Registry) NewDurationVec(name, help string, labels ...string) *DurationVec {
+	dv := &DurationVec{vec: vec[summary]{
+		labels:   labels,
+		newChild: func() *summary { return &summary{} },
+		children: make(map[string]*summary),
+	}}
+	r.register(&metric{name: name, help: help, kind: "summary", samples: func() []sample {
+		var samples []sample
+		dv.vec.each(func(labels string, s *summary) {
+			s.mu.Lock()
+			count, sum := s.count, s.sum
+			s.mu.Unlock()
+			samples = append(samples,
+				sample{suffix: "_sum", labels: labels, value: fmt.Sprint(sum)},
+				sample{suffix: "_count", labels: labels, value: fmt.Sprint(count)},
+			)
+		})
+		return samples
+	}})
+	return dv
+}
+
+// Observe records a duration for the label values
+func (dv *DurationVec) Observe(d time.Duration, values ...string) {
+	if dv == nil {
+		return
+	}
+	s := dv.vec.with(values)
+	s.mu.Lock()
+	s.count++
+	s.sum += d.Seconds()
+	s.mu.Unlock()
+}
@@ -1,8 +1,13 @@
 package password
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -10,16 +15,30 @@ import (
 const (
 	// DefaultCost is the default bcrypt cost
 	DefaultCost = bcrypt.DefaultCost
+	// MinCost is the minimum allowed bcrypt cost
+	MinCost = bcrypt.MinCost
+	// MinPepperLength is the minimum length of a pepper secret
+	MinPepperLength = 32
 )
 
+// pepperPrefix starts the hashes of peppered passwords. The pepper version
+// and the bcrypt hash follow, as in $pepper$v=2$2a$10$...; hashes without the
+// prefix are plain bcrypt hashes of unpeppered passwords.
+const pepperPrefix = "$pepper$v="
+
 var (
 	// ErrMismatchedHashAndPassword is returned when password verification fails
 	ErrMismatchedHashAndPassword = errors.New("mismatched hash and password")
 )
 
-// Hasher handles password hashing operations
+// Hasher handles password hashing operations. Passwords can be peppered: an
+// HMAC with a secret kept outside the database is applied before bcrypt, so
+// a leaked database alone does not allow cracking them. Peppers are versioned
+// so the secret can be rotated while hashes made with older ones still verify.
 type Hasher struct {
-	cost int
+	cost    int
+	peppers map[int]string
+	current int
 }
 
 // NewHasher creates a new password hasher
@@ -36,18 +55,60 @@ func NewHasherWithCost(cost int) *Hasher {
 	}
 }
 
-// Hash hashes a plain text password
+// NewHasherWithPeppers creates a password hasher that peppers new hashes with
+// the pepper of version current, or leaves them unpeppered when current is 0.
+// peppers maps each version still in use to its secret.
+func NewHasherWithPeppers(cost int, peppers map[int]string, current int) (*Hasher, error) {
+	for version, secret := range peppers {
+		if version < 1 {
+			return nil, fmt.Errorf("invalid pepper version %d: must be at least 1", version)
+		}
+		if len(secret) < MinPepperLength {
+			return nil, fmt.Errorf("pepper version %d must be at least %d characters long", version, MinPepperLength)
+		}
+	}
+	if _, ok := peppers[current]; current != 0 && !ok {
+		return nil, fmt.Errorf("no pepper with current version %d", current)
+	}
+
+	return &Hasher{
+		cost:    cost,
+		peppers: peppers,
+		current: current,
+	}, nil
+}
+
+// Hash hashes a plain text password with the current pepper
 func (h *Hasher) Hash(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	peppered, err := h.pepper(password, h.current)
+	if err != nil {
+		return "", err
+	}
+
+	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(peppered), h.cost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedBytes), nil
+
+	if h.current == 0 {
+		return string(hashedBytes), nil
+	}
+	return pepperPrefix + strconv.Itoa(h.current) + string(hashedBytes), nil
 }
 
-// Verify verifies a plain text password against a hash
+// Verify verifies a plain text password against a hash made with any known pepper
 func (h *Hasher) Verify(password, hash string) error {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	version, bcryptHash, err := parseHash(hash)
+	if err != nil {
+		return err
+	}
+
+	peppered, err := h.pepper(password, version)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(bcryptHash), []byte(peppered))
 	if err != nil {
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return ErrMismatchedHashAndPassword
@@ -57,6 +118,56 @@ func (h *Hasher) Verify(password, hash string) error {
 	return nil
 }
 
+// NeedsRehash reports whether a hash was made with another pepper or bcrypt
+// cost than new hashes are, so it should be replaced after the next
+// successful verification
+func (h *Hasher) NeedsRehash(hash string) bool {
+	version, bcryptHash, err := parseHash(hash)
+	if err != nil {
+		return false
+	}
+	if version != h.current {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(bcryptHash))
+	return err == nil && cost != h.cost
+}
+
+// pepper applies the pepper of version to a password. Version 0 leaves it as
+// is. The HMAC is base64-encoded so it stays within bcrypt's 72-byte limit.
+func (h *Hasher) pepper(password string, version int) (string, error) {
+	if version == 0 {
+		return password, nil
+	}
+
+	secret, ok := h.peppers[version]
+	if !ok {
+		return "", fmt.Errorf("unknown pepper version %d", version)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(password))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// parseHash splits a stored hash into its pepper version and bcrypt hash
+func parseHash(hash string) (int, string, error) {
+	rest, ok := strings.CutPrefix(hash, pepperPrefix)
+	if !ok {
+		return 0, hash, nil
+	}
+
+	i := strings.IndexByte(rest, '$')
+	if i < 0 {
+		return 0, "", errors.New("malformed peppered password hash")
+	}
+	version, err := strconv.Atoi(rest[:i])
+	if err != nil || version < 1 {
+		return 0, "", errors.New("malformed peppered password hash")
+	}
+	return version, rest[i:], nil
+}
+
 // IsValidPassword checks if a password meets basic requirements
 func IsValidPassword(password string) bool {
 	// At least 8 characters
@@ -0,0 +1,84 @@
+// Package pgerror translates PostgreSQL constraint violations into AppErrors,
+// so a write rejected by the database is reported to the client as the
+// conflict or invalid input it is rather than as an internal error.
+package pgerror
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+)
+
+// SQLSTATE codes of the integrity constraint violations that are translated
+const (
+	NotNullViolation    = "23502"
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+	CheckViolation      = "23514"
+)
+
+// keyColumns extracts the column list from a violation detail such as
+// `Key (email)=(a@b.c) already exists.` The values are never reported.
+var keyColumns = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// Is reports whether err is a PostgreSQL error with the given SQLSTATE code
+func Is(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+// IsUniqueViolation reports whether err is a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	return Is(err, UniqueViolation)
+}
+
+// Translate returns the AppError for a constraint violation anywhere in the
+// chain of err, caused by err, or nil if err is not a constraint violation.
+//
+//   - unique violations become CONFLICT
+//   - foreign key violations become INVALID_REFERENCE when the referenced row
+//     is missing, and CONFLICT when the row is still referenced elsewhere
+//   - not-null and check violations become VALIDATION_ERROR
+func Translate(err error) *apperror.AppError {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return nil
+	}
+
+	field := violatedField(pgErr)
+
+	switch pgErr.Code {
+	case UniqueViolation:
+		return apperror.ErrConflict.WithDetails(field + ": already exists").WithCause(err)
+	case ForeignKeyViolation:
+		if strings.Contains(pgErr.Detail, "is still referenced") {
+			return apperror.ErrConflict.WithDetails(field + ": is still referenced").WithCause(err)
+		}
+		return apperror.ErrInvalidReference.WithDetails(field + ": references a record that does not exist").WithCause(err)
+	case NotNullViolation:
+		return apperror.ErrValidation.WithDetails(field + ": is required").WithCause(err)
+	case CheckViolation:
+		return apperror.ErrValidation.WithDetails(fmt.Sprintf("%s: failed check %s", field, pgErr.ConstraintName)).WithCause(err)
+	}
+
+	return nil
+}
+
+// violatedField names what a violation is about: the key columns when the
+// detail lists them, otherwise the column or, failing that, the constraint
+func violatedField(pgErr *pgconn.PgError) string {
+	if m := keyColumns.FindStringSubmatch(pgErr.Detail); m != nil {
+		return strings.ReplaceAll(m[1], ", ", ",")
+	}
+	if pgErr.ColumnName != "" {
+		return pgErr.ColumnName
+	}
+	if pgErr.TableName != "" {
+		return pgErr.TableName
+	}
+	return pgErr.ConstraintName
+}
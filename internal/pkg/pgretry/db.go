@@ -0,0 +1,80 @@
+package pgretry
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB runs single statements on a pool, retrying transient failures. It
+// implements the DBTX interface of the generated queries, so repositories
+// use it in place of the pool outside transactions. Each statement commits on
+// its own, so a failed one can be run again as a whole. Statements starting
+// with SELECT are treated as idempotent.
+//
+// Query only retries errors returned before rows are read; errors reported by
+// Rows.Err are returned as they are, since rows may already have been used.
+type DB struct {
+	pool   *pgxpool.Pool
+	policy Policy
+}
+
+// NewDB creates a DB running statements on pool with the given retry policy
+func NewDB(pool *pgxpool.Pool, policy Policy) *DB {
+	return &DB{
+		pool:   pool,
+		policy: policy,
+	}
+}
+
+// Exec runs a statement that returns no rows
+func (d *DB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	var tag pgconn.CommandTag
+	err := Do(ctx, d.policy, readOnly(sql), func(ctx context.Context) error {
+		var err error
+		tag, err = d.pool.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, err
+}
+
+// Query runs a statement that returns rows
+func (d *DB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	var rows pgx.Rows
+	err := Do(ctx, d.policy, readOnly(sql), func(ctx context.Context) error {
+		var err error
+		rows, err = d.pool.Query(ctx, sql, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRow runs a statement that returns at most one row. The statement runs,
+// and is retried, when the row is scanned.
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return &row{db: d, ctx: ctx, sql: sql, args: args}
+}
+
+// row defers running a QueryRow statement to Scan so its errors can be retried
+type row struct {
+	db   *DB
+	ctx  context.Context
+	sql  string
+	args []interface{}
+}
+
+// Scan runs the statement and scans its row into dest
+func (r *row) Scan(dest ...any) error {
+	return Do(r.ctx, r.db.policy, readOnly(r.sql), func(ctx context.Context) error {
+		return r.db.pool.QueryRow(ctx, r.sql, r.args...).Scan(dest...)
+	})
+}
+
+// readOnly reports whether a statement only reads, so running it twice is harmless
+func readOnly(sql string) bool {
+	sql = strings.TrimSpace(sql)
+	return len(sql) >= 6 && strings.EqualFold(sql[:6], "SELECT")
+}
@@ -0,0 +1,132 @@
+// Package pgretry retries PostgreSQL operations that failed for transient
+// reasons, so a deadlock or a dropped connection does not surface as a 500.
+//
+// Errors are retried only when running the operation again cannot apply it
+// twice: serialization failures and deadlocks roll the statement or
+// transaction back, and some connection errors happen before anything reaches
+// the server. Other connection errors, such as a reset while waiting for the
+// result, leave it unknown whether a write committed, so they are retried only
+// for operations the caller declares idempotent.
+package pgretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/whauzan/todo-api/internal/pkg/pgerror"
+)
+
+// SQLSTATE codes of the errors that roll back the failed statement or transaction
+const (
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+)
+
+// SQLSTATE codes of server-side connection failures, where the server went
+// away before or while running the statement
+const (
+	AdminShutdown    = "57P01"
+	CrashShutdown    = "57P02"
+	CannotConnectNow = "57P03"
+)
+
+// Policy bounds the retries of an operation
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// BaseBackoff and MaxBackoff bound the full-jitter backoff between attempts
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// DefaultPolicy returns settings that ride out a deadlock or a failover
+// without holding a request for long
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 3,
+		BaseBackoff: 20 * time.Millisecond,
+		MaxBackoff:  500 * time.Millisecond,
+	}
+}
+
+// Do runs fn until it succeeds, fails with an error that is not safe to retry,
+// attempts run out, or ctx is done. idempotent declares that fn can be applied
+// more than once, which allows retrying errors that leave its outcome unknown.
+func Do(ctx context.Context, policy Policy, idempotent bool, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !Retryable(err, idempotent) || ctx.Err() != nil {
+			return err
+		}
+
+		if sleepErr := sleep(ctx, policy.backoff(attempt)); sleepErr != nil {
+			return errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// Retryable reports whether an operation that failed with err can be run again
+func Retryable(err error, idempotent bool) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// The server rolled the work back, so nothing was applied
+	if pgerror.Is(err, SerializationFailure) || pgerror.Is(err, DeadlockDetected) {
+		return true
+	}
+
+	// Nothing reached the server
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || pgconn.SafeToRetry(err) || pgerror.Is(err, CannotConnectNow) {
+		return true
+	}
+
+	return idempotent && connectionLost(err)
+}
+
+// connectionLost reports whether err means the connection failed while the
+// operation was in flight
+func connectionLost(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions
+		return pgErr.Code == AdminShutdown || pgErr.Code == CrashShutdown || strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	var netErr net.Error
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+// backoff returns a full-jitter delay before the attempt after attempt
+func (p Policy) backoff(attempt int) time.Duration {
+	ceiling := min(p.BaseBackoff<<(attempt-1), p.MaxBackoff)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,140 @@
+package respcache
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a cached HTTP response
+type Entry struct {
+	Status    int
+	Header    http.Header
+	Body      []byte
+	expiresAt time.Time
+	keys      []string
+}
+
+// Store is an in-memory response cache with surrogate-key invalidation.
+// Each entry is tagged with surrogate keys; purging a key drops every entry tagged with it.
+type Store struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu         sync.Mutex
+	entries    map[string]*Entry
+	surrogates map[string]map[string]struct{}
+}
+
+// NewStore creates a new Store holding up to maxEntries responses for ttl each
+func NewStore(ttl time.Duration, maxEntries int) *Store {
+	return &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*Entry),
+		surrogates: make(map[string]map[string]struct{}),
+	}
+}
+
+// Get returns the cached response for key if it exists and has not expired
+func (s *Store) Get(key string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		s.removeLocked(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set caches a response under key, tagged with the given surrogate keys
+func (s *Store) Set(key string, status int, header http.Header, body []byte, surrogateKeys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= s.maxEntries {
+		s.evictLocked()
+	}
+
+	s.removeLocked(key)
+	s.entries[key] = &Entry{
+		Status:    status,
+		Header:    header.Clone(),
+		Body:      body,
+		expiresAt: time.Now().Add(s.ttl),
+		keys:      surrogateKeys,
+	}
+
+	for _, sk := range surrogateKeys {
+		tagged, ok := s.surrogates[sk]
+		if !ok {
+			tagged = make(map[string]struct{})
+			s.surrogates[sk] = tagged
+		}
+		tagged[key] = struct{}{}
+	}
+}
+
+// Purge removes every entry tagged with the surrogate key
+func (s *Store) Purge(surrogateKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.surrogates[surrogateKey] {
+		s.removeLocked(key)
+	}
+	delete(s.surrogates, surrogateKey)
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// removeLocked removes a single entry and its surrogate-key references
+func (s *Store) removeLocked(key string) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	delete(s.entries, key)
+
+	for _, sk := range entry.keys {
+		if tagged, ok := s.surrogates[sk]; ok {
+			delete(tagged, key)
+			if len(tagged) == 0 {
+				delete(s.surrogates, sk)
+			}
+		}
+	}
+}
+
+// evictLocked makes room by dropping expired entries, or the entry closest to expiry
+func (s *Store) evictLocked() {
+	now := time.Now()
+	var (
+		oldestKey string
+		oldestAt  time.Time
+	)
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			s.removeLocked(key)
+			continue
+		}
+		if oldestKey == "" || entry.expiresAt.Before(oldestAt) {
+			oldestKey, oldestAt = key, entry.expiresAt
+		}
+	}
+
+	if len(s.entries) >= s.maxEntries && oldestKey != "" {
+		s.removeLocked(oldestKey)
+	}
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogHandler is a slog.Handler that adds trace_id and span_id to every record
+// logged with a context carrying trace context
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps next so records include the trace context
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{Handler: next}
+}
+
+// Handle adds the trace attributes and passes the record on
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if sc, ok := FromContext(ctx); ok {
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID.String()),
+			slog.String("span_id", sc.SpanID.String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a LogHandler wrapping the handler with the attributes added
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a LogHandler wrapping the handler with the group added
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,201 @@
+// Package tracing propagates distributed trace context in W3C Trace Context
+// (traceparent) and Zipkin B3 headers without requiring a tracing backend.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Propagation headers
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+	HeaderB3          = "b3"
+	HeaderB3TraceID   = "X-B3-TraceId"
+	HeaderB3SpanID    = "X-B3-SpanId"
+	HeaderB3ParentID  = "X-B3-ParentSpanId"
+	HeaderB3Sampled   = "X-B3-Sampled"
+)
+
+// TraceID identifies a whole trace
+type TraceID [16]byte
+
+// SpanID identifies one span within a trace
+type SpanID [8]byte
+
+// String returns the lowercase hex form of the trace ID
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// String returns the lowercase hex form of the span ID
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the trace ID is not all zeros
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// IsValid reports whether the span ID is not all zeros
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the trace context of the span handling the current request
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// ParentSpanID is the caller's span, zero when this span started the trace
+	ParentSpanID SpanID
+	Sampled      bool
+	// TraceState is the vendor-specific W3C tracestate, passed through unchanged
+	TraceState string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying sc
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext returns the span context stored in ctx, if any
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Extract reads the caller's trace context from W3C or B3 headers and starts a
+// child span of it. traceparent takes precedence over B3. When neither is present
+// or valid, a new sampled trace is started.
+func Extract(h http.Header) SpanContext {
+	parent, ok := parseTraceparent(h.Get(HeaderTraceparent))
+	if ok {
+		parent.TraceState = h.Get(HeaderTracestate)
+	} else {
+		parent, ok = parseB3(h)
+	}
+
+	if !ok {
+		return SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	}
+
+	return SpanContext{
+		TraceID:      parent.TraceID,
+		SpanID:       newSpanID(),
+		ParentSpanID: parent.SpanID,
+		Sampled:      parent.Sampled,
+		TraceState:   parent.TraceState,
+	}
+}
+
+// Inject writes the trace context in ctx to outbound request headers in both
+// W3C and B3 multi-header form, so downstream services using either can join
+// the trace. It does nothing when ctx carries no trace context.
+func Inject(ctx context.Context, h http.Header) {
+	sc, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	flags := "00"
+	sampled := "0"
+	if sc.Sampled {
+		flags = "01"
+		sampled = "1"
+	}
+
+	h.Set(HeaderTraceparent, fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags))
+	if sc.TraceState != "" {
+		h.Set(HeaderTracestate, sc.TraceState)
+	}
+
+	h.Set(HeaderB3TraceID, sc.TraceID.String())
+	h.Set(HeaderB3SpanID, sc.SpanID.String())
+	h.Set(HeaderB3Sampled, sampled)
+	if sc.ParentSpanID.IsValid() {
+		h.Set(HeaderB3ParentID, sc.ParentSpanID.String())
+	}
+}
+
+// parseTraceparent parses a version 00 W3C traceparent header:
+// 00-<32 hex trace id>-<16 hex parent id>-<2 hex flags>
+func parseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	if !decodeHex(parts[1], sc.TraceID[:]) || !decodeHex(parts[2], sc.SpanID[:]) {
+		return SpanContext{}, false
+	}
+
+	var flags [1]byte
+	if !decodeHex(parts[3], flags[:]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 == 0x01
+
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// parseB3 parses the single b3 header (traceid-spanid[-sampled[-parentspanid]])
+// or, failing that, the X-B3-* multi-header form
+func parseB3(h http.Header) (SpanContext, bool) {
+	traceID, spanID, sampled := h.Get(HeaderB3TraceID), h.Get(HeaderB3SpanID), h.Get(HeaderB3Sampled)
+	if single := h.Get(HeaderB3); single != "" {
+		parts := strings.Split(single, "-")
+		if len(parts) < 2 {
+			return SpanContext{}, false
+		}
+		traceID, spanID, sampled = parts[0], parts[1], ""
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	}
+
+	// 64-bit B3 trace IDs are left-padded to 128 bits
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+
+	var sc SpanContext
+	if !decodeHex(traceID, sc.TraceID[:]) || !decodeHex(spanID, sc.SpanID[:]) {
+		return SpanContext{}, false
+	}
+	if !sc.TraceID.IsValid() || !sc.SpanID.IsValid() {
+		return SpanContext{}, false
+	}
+
+	// An absent sampling decision defers to us; we sample
+	sc.Sampled = sampled != "0" && sampled != "false"
+	return sc, true
+}
+
+// decodeHex decodes s into dst, requiring an exact length and lowercase or uppercase hex
+func decodeHex(s string, dst []byte) bool {
+	if len(s) != hex.EncodedLen(len(dst)) {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,139 @@
+package webhooksig
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Providers supported out of the box
+var (
+	// Stripe verifies the Stripe-Signature header: t=<unix>,v1=<hex>[,v1=<hex>...]
+	// over "<t>.<body>"
+	Stripe Scheme = stripeScheme{}
+	// Slack verifies X-Slack-Signature (v0=<hex>) over "v0:<X-Slack-Request-Timestamp>:<body>"
+	Slack Scheme = slackScheme{}
+	// Mailgun verifies the signature object of a JSON event body, or the
+	// timestamp, token and signature form fields, over "<timestamp><token>"
+	Mailgun Scheme = mailgunScheme{}
+)
+
+type stripeScheme struct{}
+
+func (stripeScheme) Extract(r *http.Request, body []byte) (*Signed, error) {
+	header := r.Header.Get("Stripe-Signature")
+	if header == "" {
+		return nil, ErrMissingSignature
+	}
+
+	signed := &Signed{}
+	var timestamp string
+	for _, item := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signed.Signatures = append(signed.Signatures, sig)
+			}
+		}
+	}
+
+	ts, err := parseUnix(timestamp)
+	if err != nil {
+		return nil, err
+	}
+	signed.Timestamp = ts
+	signed.Message = append([]byte(timestamp+"."), body...)
+	return signed, nil
+}
+
+type slackScheme struct{}
+
+func (slackScheme) Extract(r *http.Request, body []byte) (*Signed, error) {
+	header := r.Header.Get("X-Slack-Signature")
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	if header == "" {
+		return nil, ErrMissingSignature
+	}
+
+	ts, err := parseUnix(timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &Signed{
+		Timestamp: ts,
+		Message:   append([]byte("v0:"+timestamp+":"), body...),
+	}
+	if hexSig, ok := strings.CutPrefix(header, "v0="); ok {
+		if sig, err := hex.DecodeString(hexSig); err == nil {
+			signed.Signatures = append(signed.Signatures, sig)
+		}
+	}
+	return signed, nil
+}
+
+type mailgunScheme struct{}
+
+func (mailgunScheme) Extract(r *http.Request, body []byte) (*Signed, error) {
+	var fields struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var event struct {
+			Signature json.RawMessage `json:"signature"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil || len(event.Signature) == 0 {
+			return nil, ErrMissingSignature
+		}
+		if err := json.Unmarshal(event.Signature, &fields); err != nil {
+			return nil, ErrMissingSignature
+		}
+	} else {
+		if err := r.ParseForm(); err != nil {
+			return nil, ErrMissingSignature
+		}
+		fields.Timestamp = r.PostForm.Get("timestamp")
+		fields.Token = r.PostForm.Get("token")
+		fields.Signature = r.PostForm.Get("signature")
+	}
+
+	if fields.Signature == "" {
+		return nil, ErrMissingSignature
+	}
+
+	ts, err := parseUnix(fields.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := &Signed{
+		Timestamp: ts,
+		Message:   []byte(fields.Timestamp + fields.Token),
+	}
+	if sig, err := hex.DecodeString(fields.Signature); err == nil {
+		signed.Signatures = append(signed.Signatures, sig)
+	}
+	return signed, nil
+}
+
+// parseUnix parses a Unix timestamp in seconds
+func parseUnix(value string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid timestamp %q", ErrMissingSignature, value)
+	}
+	return time.Unix(seconds, 0), nil
+}
@@ -0,0 +1,127 @@
+// Package webhooksig verifies the signatures of inbound webhooks.
+//
+// A Verifier combines a provider Scheme, which knows where the provider puts the
+// timestamp and signature and what it signs, with a SecretFunc that looks up the
+// signing secrets for a request. Signatures are HMAC-SHA256, compared in constant
+// time, and requests whose timestamp is outside the tolerance are rejected as replays.
+package webhooksig
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultTolerance is the replay window used by providers' own SDKs
+const DefaultTolerance = 5 * time.Minute
+
+// maxBodyBytes bounds the body read for verification
+const maxBodyBytes = 1 << 20
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("webhooksig: missing signature")
+	ErrInvalidSignature = errors.New("webhooksig: signature mismatch")
+	ErrReplayed         = errors.New("webhooksig: timestamp outside tolerance")
+	ErrNoSecret         = errors.New("webhooksig: no secret configured")
+)
+
+// Signed is what a Scheme extracts from a request: the message the provider
+// signed, the time it was signed, and the candidate signatures
+type Signed struct {
+	Timestamp  time.Time
+	Message    []byte
+	Signatures [][]byte
+}
+
+// Scheme extracts the signed parts of a webhook request for one provider
+type Scheme interface {
+	Extract(r *http.Request, body []byte) (*Signed, error)
+}
+
+// SecretFunc returns the signing secrets for a request. Returning several secrets
+// supports rotation: a signature matching any of them is accepted.
+type SecretFunc func(ctx context.Context, r *http.Request) ([][]byte, error)
+
+// StaticSecrets returns a SecretFunc that always returns the given secrets
+func StaticSecrets(secrets ...string) SecretFunc {
+	keys := make([][]byte, len(secrets))
+	for i, s := range secrets {
+		keys[i] = []byte(s)
+	}
+	return func(context.Context, *http.Request) ([][]byte, error) {
+		return keys, nil
+	}
+}
+
+// Verifier checks webhook requests for one provider
+type Verifier struct {
+	scheme    Scheme
+	secrets   SecretFunc
+	tolerance time.Duration
+}
+
+// NewVerifier creates a Verifier. A zero tolerance uses DefaultTolerance.
+func NewVerifier(scheme Scheme, secrets SecretFunc, tolerance time.Duration) *Verifier {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	return &Verifier{
+		scheme:    scheme,
+		secrets:   secrets,
+		tolerance: tolerance,
+	}
+}
+
+// Verify checks the request's signature and timestamp and returns its body.
+// The request body is replaced so handlers can read it again.
+func (v *Verifier) Verify(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("webhooksig: failed to read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	signed, err := v.scheme.Extract(r, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(signed.Signatures) == 0 {
+		return nil, ErrMissingSignature
+	}
+
+	if age := time.Since(signed.Timestamp); age > v.tolerance || age < -v.tolerance {
+		return nil, ErrReplayed
+	}
+
+	secrets, err := v.secrets(r.Context(), r)
+	if err != nil {
+		return nil, fmt.Errorf("webhooksig: failed to look up secret: %w", err)
+	}
+	if len(secrets) == 0 {
+		return nil, ErrNoSecret
+	}
+
+	for _, secret := range secrets {
+		expected := Sign(secret, signed.Message)
+		for _, sig := range signed.Signatures {
+			if hmac.Equal(expected, sig) {
+				return body, nil
+			}
+		}
+	}
+	return nil, ErrInvalidSignature
+}
+
+// Sign returns the HMAC-SHA256 of message with secret
+func Sign(secret, message []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrNoRowsAffected is returned by writes to a record that does not exist,
+// such as updating a todo that was deleted after it was read
+var ErrNoRowsAffected = errors.New("no rows affected")
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/whauzan/todo-api/internal/domain"
@@ -9,18 +10,51 @@ import (
 
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
-	// Create creates a new user
+	// Create creates a new user, or returns apperror.ErrUserExists if the email is taken
 	Create(ctx context.Context, user *domain.User) error
 
 	// GetByID retrieves a user by ID
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 
-	// GetByEmail retrieves a user by email
+	// GetByEmail retrieves a user by email, ignoring case and surrounding whitespace
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 
-	// Update updates a user
+	// Update updates a user, or returns ErrNoRowsAffected if it does not exist
 	Update(ctx context.Context, user *domain.User) error
 
+	// SetE2EEnabled turns end-to-end encryption mode on or off for a user,
+	// or returns ErrNoRowsAffected if it does not exist
+	SetE2EEnabled(ctx context.Context, user *domain.User, enabled bool) error
+	// SetTelemetryOptOut records whether a user has opted out of product analytics,
+	// or returns ErrNoRowsAffected if it does not exist
+	SetTelemetryOptOut(ctx context.Context, user *domain.User, optOut bool) error
+
+	// SetStatus changes a user's account status and records the reason for it,
+	// or returns ErrNoRowsAffected if the user does not exist
+	SetStatus(ctx context.Context, user *domain.User, status domain.UserStatus, reason *string) error
+
+	// UpdatePassword replaces a user's password hash and revokes every token
+	// issued before the change, or returns ErrNoRowsAffected if the user does not exist
+	UpdatePassword(ctx context.Context, user *domain.User, passwordHash string) error
+
+	// RehashPassword replaces a user's password hash with a new hash of the same
+	// password, keeping their tokens. It returns ErrNoRowsAffected if the user
+	// does not exist or their hash changed since it was read.
+	RehashPassword(ctx context.Context, user *domain.User, passwordHash string) error
+
+	// RevokeTokens invalidates every token issued to a user so far,
+	// or returns ErrNoRowsAffected if the user does not exist
+	RevokeTokens(ctx context.Context, user *domain.User) error
+	// RequestDeletion marks a user pending deletion until purgeAfter and revokes
+	// their tokens, or returns ErrNoRowsAffected if the user does not exist
+	RequestDeletion(ctx context.Context, user *domain.User, purgeAfter time.Time) error
+	// Restore cancels a deletion the user requested, or returns ErrNoRowsAffected
+	// if the user does not exist or has no deletion pending
+	Restore(ctx context.Context, user *domain.User) error
+	// PurgeDeleted permanently deletes users whose deletion was due at or before
+	// now, along with their data, and returns how many were deleted
+	PurgeDeleted(ctx context.Context, now time.Time) (int64, error)
+
 	// Delete deletes a user
 	Delete(ctx context.Context, id uuid.UUID) error
 }
@@ -36,12 +70,183 @@ type TodoRepository interface {
 	// ListByUserID retrieves all todos for a user
 	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Todo, error)
 
+	// ListPageByUserID retrieves up to limit todos for a user, newest first,
+	// starting after the given cursor (or from the beginning if it is nil)
+	ListPageByUserID(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) ([]*domain.Todo, error)
+
+	// StreamByUserID calls fn for each todo of a user without buffering the result set
+	StreamByUserID(ctx context.Context, userID uuid.UUID, fn func(*domain.Todo) error) error
+
+	// ListSummariesByUserID retrieves summaries of all todos for a user
+	ListSummariesByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.TodoSummary, error)
+
+	// ListSummaryPageByUserID is ListPageByUserID for todo summaries
+	ListSummaryPageByUserID(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) ([]*domain.TodoSummary, error)
+
+	// StreamSummariesByUserID is StreamByUserID for todo summaries
+	StreamSummariesByUserID(ctx context.Context, userID uuid.UUID, fn func(*domain.TodoSummary) error) error
+
 	// ListByUserIDAndStatus retrieves todos for a user filtered by completion status
 	ListByUserIDAndStatus(ctx context.Context, userID uuid.UUID, completed bool) ([]*domain.Todo, error)
 
-	// Update updates a todo
+	// LockChanges locks a user's change sequence until the transaction ends,
+	// so no other change of the user commits in the meantime, and returns the
+	// last sequence number handed out. It only holds the lock inside InTx.
+	LockChanges(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// LastChangeSeq returns the last sequence number handed out to a user's
+	// changes, or 0 if the user has none
+	LastChangeSeq(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// ListChangesSince retrieves up to limit entries of a user's change feed
+	// with a sequence number above since, in sequence order
+	ListChangesSince(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*domain.TodoChange, error)
+
+	// Update updates a todo, or returns ErrNoRowsAffected if it does not exist
 	Update(ctx context.Context, todo *domain.Todo) error
 
-	// Delete deletes a todo
+	// CompleteByFilter completes the open todos of a user matching filter in
+	// one statement, records them under undoToken until undoExpiresAt, and
+	// returns how many were completed
+	CompleteByFilter(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, undoToken uuid.UUID, undoExpiresAt time.Time) (int64, error)
+
+	// UndoComplete consumes an undo token and reopens its todos that were not
+	// changed since, returning how many were reopened, or returns
+	// ErrNoRowsAffected if the user has no such token or it has expired
+	UndoComplete(ctx context.Context, userID, undoToken uuid.UUID) (int64, error)
+
+	// Delete deletes a todo and records a tombstone for it
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// InTx calls fn with a repository whose reads and writes share one
+	// transaction, and commits it if fn returns nil
+	InTx(ctx context.Context, fn func(TodoRepository) error) error
+}
+
+// AnnouncementRepository defines the interface for announcement data operations
+type AnnouncementRepository interface {
+	// Create creates a new announcement
+	Create(ctx context.Context, announcement *domain.Announcement) error
+
+	// GetByID retrieves an announcement by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Announcement, error)
+
+	// List retrieves every announcement, including scheduled and expired ones
+	List(ctx context.Context) ([]*domain.Announcement, error)
+
+	// ListActive retrieves the announcements currently active that the user has not dismissed
+	ListActive(ctx context.Context, userID uuid.UUID) ([]*domain.Announcement, error)
+
+	// Delete deletes an announcement
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Dismiss hides an announcement from a user; dismissing it again is a no-op
+	Dismiss(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// OnboardingRepository defines the interface for onboarding checklist data operations
+type OnboardingRepository interface {
+	// ListCompleted retrieves the completion time of each step the user has completed
+	ListCompleted(ctx context.Context, userID uuid.UUID) (map[domain.OnboardingStep]time.Time, error)
+
+	// Complete marks a step as completed; completing it again keeps the original time
+	Complete(ctx context.Context, userID uuid.UUID, step domain.OnboardingStep) error
+
+	// Reset marks a step as not completed
+	Reset(ctx context.Context, userID uuid.UUID, step domain.OnboardingStep) error
+}
+
+// NotificationRepository defines the interface for notification inbox data operations
+type NotificationRepository interface {
+	// Create creates a new notification
+	Create(ctx context.Context, notification *domain.Notification) error
+
+	// ListByUserID retrieves up to limit notifications of a user, newest first,
+	// optionally only the unread ones
+	ListByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]*domain.Notification, error)
+
+	// CountUnread counts the notifications a user has not read
+	CountUnread(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// MarkRead marks a notification of a user as read and returns it,
+	// or nil if the user has no such notification
+	MarkRead(ctx context.Context, id, userID uuid.UUID) (*domain.Notification, error)
+
+	// MarkAllRead marks every notification of a user as read and returns how many changed
+	MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// ReportRepository defines the interface for aggregate reporting queries
+type ReportRepository interface {
+	// Usage counts new, active and churned users and created and completed
+	// todos per period starting in [from, to), in order. Periods without
+	// any activity are omitted.
+	Usage(ctx context.Context, period domain.ReportPeriod, from, to time.Time) ([]*domain.UsagePeriod, error)
+}
+
+// CORSOriginRepository defines the interface for the CORS origin allowlist
+type CORSOriginRepository interface {
+	// List retrieves every allowed origin
+	List(ctx context.Context) ([]*domain.CORSOrigin, error)
+
+	// Add allows an origin; adding it again returns the existing entry
+	Add(ctx context.Context, origin string) (*domain.CORSOrigin, error)
+
+	// Remove disallows an origin, or returns ErrNoRowsAffected if it is not allowed
+	Remove(ctx context.Context, origin string) error
+}
+
+// HookRepository defines the interface for REST hook subscriptions
+type HookRepository interface {
+	// Create subscribes a hook from the current end of the user's change feed,
+	// setting its LastSeq and CreatedAt
+	Create(ctx context.Context, sub *domain.HookSubscription) error
+
+	// CountByUserID counts the subscriptions of a user
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// GetByID retrieves a subscription of a user, or nil if the user has no
+	// such subscription
+	GetByID(ctx context.Context, id, userID uuid.UUID) (*domain.HookSubscription, error)
+
+	// ListByUserID retrieves the subscriptions of a user, oldest first
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.HookSubscription, error)
+
+	// ListAll retrieves every subscription, oldest first
+	ListAll(ctx context.Context) ([]*domain.HookSubscription, error)
+
+	// Delete removes a subscription of a user, or returns ErrNoRowsAffected
+	// if the user has no such subscription
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+
+	// Advance records that changes up to lastSeq were delivered and clears
+	// the failure count
+	Advance(ctx context.Context, id uuid.UUID, lastSeq int64) error
+
+	// RecordFailure counts a failed delivery and returns the consecutive failures
+	RecordFailure(ctx context.Context, id uuid.UUID) (int, error)
+
+	// CreateDelivery records a delivery attempt, setting its CreatedAt
+	CreateDelivery(ctx context.Context, attempt *domain.HookDeliveryAttempt) error
+
+	// GetDelivery retrieves a delivery attempt of a user's subscription, or nil
+	// if there is no such attempt
+	GetDelivery(ctx context.Context, id, hookID, userID uuid.UUID) (*domain.HookDeliveryAttempt, error)
+
+	// ListDeliveries retrieves up to limit delivery attempts of a user's
+	// subscription, newest first, only the failed ones if failedOnly is set
+	ListDeliveries(ctx context.Context, hookID, userID uuid.UUID, failedOnly bool, limit int) ([]*domain.HookDeliveryAttempt, error)
+
+	// PruneDeliveries deletes the delivery attempts made before a time and
+	// returns how many were deleted
+	PruneDeliveries(ctx context.Context, before time.Time) (int64, error)
+}
+
+// AgentActionRepository defines the interface for the audit log of agent tool calls
+type AgentActionRepository interface {
+	// Create records an agent action, setting its CreatedAt
+	Create(ctx context.Context, action *domain.AgentAction) error
+
+	// ListByUserID retrieves up to limit actions taken for a user, newest first
+	ListByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.AgentAction, error)
 }
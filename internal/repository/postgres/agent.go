@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// AgentActionRepository implements the repository.AgentActionRepository interface
+type AgentActionRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewAgentActionRepository creates a new AgentActionRepository
+func NewAgentActionRepository(pool *pgxpool.Pool) *AgentActionRepository {
+	return &AgentActionRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// Create records an agent action
+func (r *AgentActionRepository) Create(ctx context.Context, action *domain.AgentAction) error {
+	var errorCode sql.NullString
+	if action.ErrorCode != nil {
+		errorCode = sql.NullString{String: *action.ErrorCode, Valid: true}
+	}
+
+	var todoID uuid.NullUUID
+	if action.TodoID != nil {
+		todoID = uuid.NullUUID{UUID: *action.TodoID, Valid: true}
+	}
+
+	var clientIP sql.NullString
+	if action.ClientIP != nil {
+		clientIP = sql.NullString{String: *action.ClientIP, Valid: true}
+	}
+
+	dbAction, err := r.queries.CreateAgentAction(ctx, db.CreateAgentActionParams{
+		ID:        action.ID,
+		UserID:    action.UserID,
+		Tool:      action.Tool,
+		Arguments: action.Arguments,
+		Outcome:   action.Outcome,
+		ErrorCode: errorCode,
+		TodoID:    todoID,
+		ClientIP:  clientIP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create agent action: %w", err)
+	}
+
+	// Update the action with generated values
+	action.CreatedAt = dbAction.CreatedAt
+
+	return nil
+}
+
+// ListByUserID retrieves up to limit actions taken for a user, newest first
+func (r *AgentActionRepository) ListByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.AgentAction, error) {
+	dbActions, err := r.queries.ListAgentActionsByUserID(ctx, db.ListAgentActionsByUserIDParams{
+		UserID: userID,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list agent actions by user ID: %w", err)
+	}
+
+	actions := make([]*domain.AgentAction, 0, len(dbActions))
+	for _, dbAction := range dbActions {
+		actions = append(actions, r.toDomainAgentAction(dbAction))
+	}
+
+	return actions, nil
+}
+
+// toDomainAgentAction converts a db.AgentAction to domain.AgentAction
+func (r *AgentActionRepository) toDomainAgentAction(dbAction db.AgentAction) *domain.AgentAction {
+	action := &domain.AgentAction{
+		ID:        dbAction.ID,
+		UserID:    dbAction.UserID,
+		Tool:      dbAction.Tool,
+		Arguments: dbAction.Arguments,
+		Outcome:   dbAction.Outcome,
+		CreatedAt: dbAction.CreatedAt,
+	}
+
+	if dbAction.ErrorCode.Valid {
+		action.ErrorCode = &dbAction.ErrorCode.String
+	}
+
+	if dbAction.TodoID.Valid {
+		action.TodoID = &dbAction.TodoID.UUID
+	}
+
+	if dbAction.ClientIP.Valid {
+		action.ClientIP = &dbAction.ClientIP.String
+	}
+
+	return action
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// AnnouncementRepository implements the repository.AnnouncementRepository interface
+type AnnouncementRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewAnnouncementRepository creates a new AnnouncementRepository
+func NewAnnouncementRepository(pool *pgxpool.Pool) *AnnouncementRepository {
+	return &AnnouncementRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// Create creates a new announcement
+func (r *AnnouncementRepository) Create(ctx context.Context, announcement *domain.Announcement) error {
+	var endsAt sql.NullTime
+	if announcement.EndsAt != nil {
+		endsAt = sql.NullTime{Time: *announcement.EndsAt, Valid: true}
+	}
+
+	dbAnnouncement, err := r.queries.CreateAnnouncement(ctx, db.CreateAnnouncementParams{
+		ID:       announcement.ID,
+		Message:  announcement.Message,
+		Severity: string(announcement.Severity),
+		StartsAt: announcement.StartsAt,
+		EndsAt:   endsAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	// Update the announcement with generated values
+	announcement.CreatedAt = dbAnnouncement.CreatedAt
+
+	return nil
+}
+
+// GetByID retrieves an announcement by ID
+func (r *AnnouncementRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	dbAnnouncement, err := r.queries.GetAnnouncementByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get announcement by ID: %w", err)
+	}
+
+	return r.toDomainAnnouncement(dbAnnouncement), nil
+}
+
+// List retrieves every announcement, including scheduled and expired ones
+func (r *AnnouncementRepository) List(ctx context.Context) ([]*domain.Announcement, error) {
+	dbAnnouncements, err := r.queries.ListAnnouncements(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcements: %w", err)
+	}
+
+	return r.toDomainAnnouncements(dbAnnouncements), nil
+}
+
+// ListActive retrieves the announcements currently active that the user has not dismissed
+func (r *AnnouncementRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]*domain.Announcement, error) {
+	dbAnnouncements, err := r.queries.ListActiveAnnouncementsForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active announcements: %w", err)
+	}
+
+	return r.toDomainAnnouncements(dbAnnouncements), nil
+}
+
+// Delete deletes an announcement along with its dismissals
+func (r *AnnouncementRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.queries.DeleteAnnouncement(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+	return nil
+}
+
+// Dismiss hides an announcement from a user; dismissing it again is a no-op
+func (r *AnnouncementRepository) Dismiss(ctx context.Context, id, userID uuid.UUID) error {
+	err := r.queries.DismissAnnouncement(ctx, db.DismissAnnouncementParams{
+		AnnouncementID: id,
+		UserID:         userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dismiss announcement: %w", err)
+	}
+	return nil
+}
+
+// toDomainAnnouncements converts db.Announcements to domain.Announcements
+func (r *AnnouncementRepository) toDomainAnnouncements(dbAnnouncements []db.Announcement) []*domain.Announcement {
+	announcements := make([]*domain.Announcement, 0, len(dbAnnouncements))
+	for _, dbAnnouncement := range dbAnnouncements {
+		announcements = append(announcements, r.toDomainAnnouncement(dbAnnouncement))
+	}
+	return announcements
+}
+
+// toDomainAnnouncement converts a db.Announcement to domain.Announcement
+func (r *AnnouncementRepository) toDomainAnnouncement(dbAnnouncement db.Announcement) *domain.Announcement {
+	var endsAt *time.Time
+	if dbAnnouncement.EndsAt.Valid {
+		endsAt = &dbAnnouncement.EndsAt.Time
+	}
+
+	return &domain.Announcement{
+		ID:        dbAnnouncement.ID,
+		Message:   dbAnnouncement.Message,
+		Severity:  domain.AnnouncementSeverity(dbAnnouncement.Severity),
+		StartsAt:  dbAnnouncement.StartsAt,
+		EndsAt:    endsAt,
+		CreatedAt: dbAnnouncement.CreatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// CORSOriginRepository implements the repository.CORSOriginRepository interface
+type CORSOriginRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewCORSOriginRepository creates a new CORSOriginRepository
+func NewCORSOriginRepository(pool *pgxpool.Pool) *CORSOriginRepository {
+	return &CORSOriginRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// List retrieves every allowed origin
+func (r *CORSOriginRepository) List(ctx context.Context) ([]*domain.CORSOrigin, error) {
+	dbOrigins, err := r.queries.ListCORSOrigins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CORS origins: %w", err)
+	}
+
+	origins := make([]*domain.CORSOrigin, 0, len(dbOrigins))
+	for _, dbOrigin := range dbOrigins {
+		origins = append(origins, r.toDomainCORSOrigin(dbOrigin))
+	}
+
+	return origins, nil
+}
+
+// Add allows an origin
+func (r *CORSOriginRepository) Add(ctx context.Context, origin string) (*domain.CORSOrigin, error) {
+	dbOrigin, err := r.queries.AddCORSOrigin(ctx, origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add CORS origin: %w", err)
+	}
+	return r.toDomainCORSOrigin(dbOrigin), nil
+}
+
+// Remove disallows an origin
+func (r *CORSOriginRepository) Remove(ctx context.Context, origin string) error {
+	count, err := r.queries.DeleteCORSOrigin(ctx, origin)
+	if err != nil {
+		return fmt.Errorf("failed to remove CORS origin: %w", err)
+	}
+	if count == 0 {
+		return repository.ErrNoRowsAffected
+	}
+	return nil
+}
+
+// toDomainCORSOrigin converts a db.CorsOrigin to domain.CORSOrigin
+func (r *CORSOriginRepository) toDomainCORSOrigin(dbOrigin db.CorsOrigin) *domain.CORSOrigin {
+	return &domain.CORSOrigin{
+		Origin:    dbOrigin.Origin,
+		CreatedAt: dbOrigin.CreatedAt,
+	}
+}
@@ -11,21 +11,114 @@ import (
 	"github.com/google/uuid"
 )
 
-type Todo struct {
-	ID          uuid.UUID
+type AgentAction struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Tool      string
+	Arguments []byte
+	Outcome   string
+	ErrorCode sql.NullString
+	TodoID    uuid.NullUUID
+	CreatedAt time.Time
+	ClientIP  sql.NullString
+}
+
+type Announcement struct {
+	ID        uuid.UUID
+	Message   string
+	Severity  string
+	StartsAt  time.Time
+	EndsAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type CorsOrigin struct {
+	Origin    string
+	CreatedAt time.Time
+}
+
+type HookDelivery struct {
+	ID             uuid.UUID
+	HookID         uuid.UUID
+	UserID         uuid.UUID
+	Event          string
+	Seq            int64
+	PayloadVersion int32
+	RequestBody    []byte
+	StatusCode     sql.NullInt32
+	Error          sql.NullString
+	Succeeded      bool
+	DurationMs     int32
+	Redelivery     bool
+	CreatedAt      time.Time
+}
+
+type HookSubscription struct {
+	ID             uuid.UUID
+	UserID         uuid.UUID
+	TargetUrl      string
+	LastSeq        int64
+	Failures       int32
+	CreatedAt      time.Time
+	Events         []string
+	PayloadVersion int32
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Kind      string
+	Title     string
+	Body      sql.NullString
+	TodoID    uuid.NullUUID
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type OnboardingStep struct {
 	UserID      uuid.UUID
-	Title       string
-	Description sql.NullString
-	Completed   bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Step        string
+	CompletedAt time.Time
+}
+
+type Todo struct {
+	ID                    uuid.UUID
+	UserID                uuid.UUID
+	Title                 string
+	Description           sql.NullString
+	Completed             bool
+	Encrypted             bool
+	TitleCiphertext       []byte
+	DescriptionCiphertext []byte
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+type TodoChange struct {
+	UserID    uuid.UUID
+	Seq       int64
+	TodoID    uuid.UUID
+	Op        string
+	ChangedAt time.Time
+}
+
+type TodoTombstone struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	DeletedAt time.Time
 }
 
 type User struct {
-	ID           uuid.UUID
-	Email        string
-	PasswordHash string
-	Name         string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID              uuid.UUID
+	Email           string
+	PasswordHash    string
+	Name            string
+	E2EEnabled      bool
+	Status          string
+	StatusReason    sql.NullString
+	TokenVersion    int32
+	TelemetryOptOut bool
+	PurgeAfter      sql.NullTime
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
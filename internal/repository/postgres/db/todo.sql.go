@@ -8,25 +8,32 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type CreateTodoParams struct {
-	ID          uuid.UUID
-	UserID      uuid.UUID
-	Title       string
-	Description sql.NullString
-	Completed   bool
+	ID                    uuid.UUID
+	UserID                uuid.UUID
+	Title                 string
+	Description           sql.NullString
+	Completed             bool
+	Encrypted             bool
+	TitleCiphertext       []byte
+	DescriptionCiphertext []byte
 }
 
 func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, error) {
 	const query = `
-		INSERT INTO todos (id, user_id, title, description, completed)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, title, description, completed, created_at, updated_at
+		WITH cleared AS (
+			DELETE FROM todo_tombstones WHERE todo_tombstones.id = $1 AND todo_tombstones.user_id = $2
+		)
+		INSERT INTO todos (id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
 	`
-	row := q.db.QueryRow(ctx, query, arg.ID, arg.UserID, arg.Title, arg.Description, arg.Completed)
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.UserID, arg.Title, arg.Description, arg.Completed, arg.Encrypted, arg.TitleCiphertext, arg.DescriptionCiphertext)
 
 	var i Todo
 	err := row.Scan(
@@ -35,6 +42,9 @@ func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, e
 		&i.Title,
 		&i.Description,
 		&i.Completed,
+		&i.Encrypted,
+		&i.TitleCiphertext,
+		&i.DescriptionCiphertext,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -43,7 +53,7 @@ func (q *Queries) CreateTodo(ctx context.Context, arg CreateTodoParams) (Todo, e
 
 func (q *Queries) GetTodoByID(ctx context.Context, id uuid.UUID) (Todo, error) {
 	const query = `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
 		FROM todos
 		WHERE id = $1
 		LIMIT 1
@@ -57,6 +67,9 @@ func (q *Queries) GetTodoByID(ctx context.Context, id uuid.UUID) (Todo, error) {
 		&i.Title,
 		&i.Description,
 		&i.Completed,
+		&i.Encrypted,
+		&i.TitleCiphertext,
+		&i.DescriptionCiphertext,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -65,7 +78,7 @@ func (q *Queries) GetTodoByID(ctx context.Context, id uuid.UUID) (Todo, error) {
 
 func (q *Queries) ListTodosByUserID(ctx context.Context, userID uuid.UUID) ([]Todo, error) {
 	const query = `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
 		FROM todos
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -85,6 +98,100 @@ func (q *Queries) ListTodosByUserID(ctx context.Context, userID uuid.UUID) ([]To
 			&i.Title,
 			&i.Description,
 			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.DescriptionCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ListTodosByUserIDFirstPageParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+func (q *Queries) ListTodosByUserIDFirstPage(ctx context.Context, arg ListTodosByUserIDFirstPageParams) ([]Todo, error) {
+	const query = `
+		SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
+		FROM todos
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := q.db.Query(ctx, query, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.DescriptionCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ListTodosByUserIDAfterCursorParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	Limit           int32
+}
+
+func (q *Queries) ListTodosByUserIDAfterCursor(ctx context.Context, arg ListTodosByUserIDAfterCursorParams) ([]Todo, error) {
+	const query = `
+		SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
+		FROM todos
+		WHERE user_id = $1
+		  AND (created_at, id) < ($2::timestamp, $3::uuid)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+	rows, err := q.db.Query(ctx, query, arg.UserID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Todo
+	for rows.Next() {
+		var i Todo
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Description,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.DescriptionCiphertext,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -105,7 +212,7 @@ type ListTodosByUserIDAndStatusParams struct {
 
 func (q *Queries) ListTodosByUserIDAndStatus(ctx context.Context, arg ListTodosByUserIDAndStatusParams) ([]Todo, error) {
 	const query = `
-		SELECT id, user_id, title, description, completed, created_at, updated_at
+		SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
 		FROM todos
 		WHERE user_id = $1 AND completed = $2
 		ORDER BY created_at DESC
@@ -125,6 +232,9 @@ func (q *Queries) ListTodosByUserIDAndStatus(ctx context.Context, arg ListTodosB
 			&i.Title,
 			&i.Description,
 			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.DescriptionCiphertext,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -139,10 +249,13 @@ func (q *Queries) ListTodosByUserIDAndStatus(ctx context.Context, arg ListTodosB
 }
 
 type UpdateTodoParams struct {
-	ID          uuid.UUID
-	Title       sql.NullString
-	Description sql.NullString
-	Completed   sql.NullBool
+	ID                    uuid.UUID
+	Title                 sql.NullString
+	Description           sql.NullString
+	Completed             sql.NullBool
+	Encrypted             sql.NullBool
+	TitleCiphertext       []byte
+	DescriptionCiphertext []byte
 }
 
 func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, error) {
@@ -150,13 +263,16 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 		UPDATE todos
 		SET
 			title = COALESCE($2, title),
-			description = COALESCE($3, description),
+			description = $3,
 			completed = COALESCE($4, completed),
+			encrypted = COALESCE($5, encrypted),
+			title_ciphertext = $6,
+			description_ciphertext = $7,
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, user_id, title, description, completed, created_at, updated_at
+		RETURNING id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
 	`
-	row := q.db.QueryRow(ctx, query, arg.ID, arg.Title, arg.Description, arg.Completed)
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.Title, arg.Description, arg.Completed, arg.Encrypted, arg.TitleCiphertext, arg.DescriptionCiphertext)
 
 	var i Todo
 	err := row.Scan(
@@ -165,6 +281,9 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 		&i.Title,
 		&i.Description,
 		&i.Completed,
+		&i.Encrypted,
+		&i.TitleCiphertext,
+		&i.DescriptionCiphertext,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -172,7 +291,16 @@ func (q *Queries) UpdateTodo(ctx context.Context, arg UpdateTodoParams) (Todo, e
 }
 
 func (q *Queries) DeleteTodo(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, `DELETE FROM todos WHERE id = $1`, id)
+	const query = `
+		WITH deleted AS (
+			DELETE FROM todos WHERE todos.id = $1
+			RETURNING id, user_id
+		)
+		INSERT INTO todo_tombstones (id, user_id)
+		SELECT id, user_id FROM deleted
+		ON CONFLICT (id) DO UPDATE SET user_id = EXCLUDED.user_id, deleted_at = NOW()
+	`
+	_, err := q.db.Exec(ctx, query, id)
 	return err
 }
 
@@ -191,3 +319,337 @@ func (q *Queries) CountCompletedTodosByUserID(ctx context.Context, userID uuid.U
 	err := row.Scan(&count)
 	return count, err
 }
+
+type CompleteTodosByFilterParams struct {
+	UserID        uuid.UUID
+	IDs           []uuid.UUID
+	CreatedAfter  sql.NullTime
+	CreatedBefore sql.NullTime
+	UpdatedBefore sql.NullTime
+	Token         uuid.UUID
+	ExpiresAt     time.Time
+}
+
+func (q *Queries) CompleteTodosByFilter(ctx context.Context, arg CompleteTodosByFilterParams) (int64, error) {
+	const query = `
+		WITH completed AS (
+			UPDATE todos
+			SET completed = TRUE, updated_at = NOW()
+			WHERE user_id = $1
+			  AND completed = FALSE
+			  AND ($2::uuid[] IS NULL OR id = ANY($2::uuid[]))
+			  AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+			  AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+			  AND ($5::timestamp IS NULL OR updated_at < $5::timestamp)
+			RETURNING id
+		), expired AS (
+			DELETE FROM todo_undo_tokens
+			WHERE user_id = $1 AND expires_at <= NOW()
+		), undo AS (
+			INSERT INTO todo_undo_tokens (token, user_id, todo_ids, completed_at, expires_at)
+			SELECT $6::uuid, $1::uuid, array_agg(id), NOW(), $7::timestamp
+			FROM completed
+			HAVING COUNT(*) > 0
+		)
+		SELECT COUNT(*) FROM completed
+	`
+	row := q.db.QueryRow(ctx, query,
+		arg.UserID,
+		arg.IDs,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.UpdatedBefore,
+		arg.Token,
+		arg.ExpiresAt,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+type UndoCompleteTodosParams struct {
+	Token  uuid.UUID
+	UserID uuid.UUID
+}
+
+type UndoCompleteTodosRow struct {
+	Found    int64
+	Reopened int64
+}
+
+func (q *Queries) UndoCompleteTodos(ctx context.Context, arg UndoCompleteTodosParams) (UndoCompleteTodosRow, error) {
+	const query = `
+		WITH consumed AS (
+			DELETE FROM todo_undo_tokens
+			WHERE token = $1 AND user_id = $2 AND expires_at > NOW()
+			RETURNING todo_ids, completed_at
+		), reopened AS (
+			UPDATE todos
+			SET completed = FALSE, updated_at = NOW()
+			FROM consumed
+			WHERE todos.user_id = $2
+			  AND todos.id = ANY(consumed.todo_ids)
+			  AND todos.completed = TRUE
+			  AND todos.updated_at = consumed.completed_at
+			RETURNING todos.id
+		)
+		SELECT
+			(SELECT COUNT(*) FROM consumed) AS found,
+			(SELECT COUNT(*) FROM reopened) AS reopened
+	`
+	row := q.db.QueryRow(ctx, query, arg.Token, arg.UserID)
+	var i UndoCompleteTodosRow
+	err := row.Scan(&i.Found, &i.Reopened)
+	return i, err
+}
+
+func (q *Queries) LockUserChangeSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const query = `
+		INSERT INTO user_change_seqs (user_id, last_seq) VALUES ($1, 0)
+		ON CONFLICT (user_id) DO UPDATE SET last_seq = user_change_seqs.last_seq
+		RETURNING last_seq
+	`
+	row := q.db.QueryRow(ctx, query, userID)
+	var lastSeq int64
+	err := row.Scan(&lastSeq)
+	return lastSeq, err
+}
+
+func (q *Queries) GetUserChangeSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	const query = `SELECT COALESCE((SELECT last_seq FROM user_change_seqs WHERE user_id = $1), 0)::BIGINT`
+	row := q.db.QueryRow(ctx, query, userID)
+	var lastSeq int64
+	err := row.Scan(&lastSeq)
+	return lastSeq, err
+}
+
+type ListTodoChangesSinceParams struct {
+	UserID uuid.UUID
+	Seq    int64
+	Limit  int32
+}
+
+type ListTodoChangesSinceRow struct {
+	Seq                   int64
+	TodoID                uuid.UUID
+	Op                    string
+	ChangedAt             time.Time
+	Title                 sql.NullString
+	Description           sql.NullString
+	Completed             sql.NullBool
+	Encrypted             sql.NullBool
+	TitleCiphertext       []byte
+	DescriptionCiphertext []byte
+	CreatedAt             sql.NullTime
+	UpdatedAt             sql.NullTime
+}
+
+func (q *Queries) ListTodoChangesSince(ctx context.Context, arg ListTodoChangesSinceParams) ([]ListTodoChangesSinceRow, error) {
+	const query = `
+		SELECT
+			todo_changes.seq,
+			todo_changes.todo_id,
+			todo_changes.op,
+			todo_changes.changed_at,
+			todos.title,
+			todos.description,
+			todos.completed,
+			todos.encrypted,
+			todos.title_ciphertext,
+			todos.description_ciphertext,
+			todos.created_at,
+			todos.updated_at
+		FROM todo_changes
+		LEFT JOIN todos ON todos.id = todo_changes.todo_id AND todo_changes.op = 'upsert'
+		WHERE todo_changes.user_id = $1 AND todo_changes.seq > $2
+		ORDER BY todo_changes.seq ASC
+		LIMIT $3
+	`
+	rows, err := q.db.Query(ctx, query, arg.UserID, arg.Seq, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ListTodoChangesSinceRow
+	for rows.Next() {
+		var i ListTodoChangesSinceRow
+		if err := rows.Scan(
+			&i.Seq,
+			&i.TodoID,
+			&i.Op,
+			&i.ChangedAt,
+			&i.Title,
+			&i.Description,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.DescriptionCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ListTodoSummariesByUserIDRow struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Title           string
+	Completed       bool
+	Encrypted       bool
+	TitleCiphertext []byte
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) ListTodoSummariesByUserID(ctx context.Context, userID uuid.UUID) ([]ListTodoSummariesByUserIDRow, error) {
+	const query = `
+		SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+		FROM todos
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := q.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ListTodoSummariesByUserIDRow
+	for rows.Next() {
+		var i ListTodoSummariesByUserIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ListTodoSummariesByUserIDFirstPageParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type ListTodoSummariesByUserIDFirstPageRow struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Title           string
+	Completed       bool
+	Encrypted       bool
+	TitleCiphertext []byte
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) ListTodoSummariesByUserIDFirstPage(ctx context.Context, arg ListTodoSummariesByUserIDFirstPageParams) ([]ListTodoSummariesByUserIDFirstPageRow, error) {
+	const query = `
+		SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+		FROM todos
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+	rows, err := q.db.Query(ctx, query, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ListTodoSummariesByUserIDFirstPageRow
+	for rows.Next() {
+		var i ListTodoSummariesByUserIDFirstPageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type ListTodoSummariesByUserIDAfterCursorParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	Limit           int32
+}
+
+type ListTodoSummariesByUserIDAfterCursorRow struct {
+	ID              uuid.UUID
+	UserID          uuid.UUID
+	Title           string
+	Completed       bool
+	Encrypted       bool
+	TitleCiphertext []byte
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) ListTodoSummariesByUserIDAfterCursor(ctx context.Context, arg ListTodoSummariesByUserIDAfterCursorParams) ([]ListTodoSummariesByUserIDAfterCursorRow, error) {
+	const query = `
+		SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+		FROM todos
+		WHERE user_id = $1
+		  AND (created_at, id) < ($2::timestamp, $3::uuid)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+	rows, err := q.db.Query(ctx, query, arg.UserID, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []ListTodoSummariesByUserIDAfterCursorRow
+	for rows.Next() {
+		var i ListTodoSummariesByUserIDAfterCursorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Completed,
+			&i.Encrypted,
+			&i.TitleCiphertext,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
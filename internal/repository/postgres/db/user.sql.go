@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -23,7 +24,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	const query = `
 		INSERT INTO users (id, email, password_hash, name)
 		VALUES ($1, $2, $3, $4)
-		RETURNING id, email, password_hash, name, created_at, updated_at
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
 	`
 	row := q.db.QueryRow(ctx, query, arg.ID, arg.Email, arg.PasswordHash, arg.Name)
 
@@ -33,17 +34,30 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordHash,
 		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+// LockUserEmail serializes transactions working on the same email until the
+// surrounding transaction ends
+func (q *Queries) LockUserEmail(ctx context.Context, email string) error {
+	_, err := q.db.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, email)
+	return err
+}
+
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
 	const query = `
-		SELECT id, email, password_hash, name, created_at, updated_at
+		SELECT id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
 		FROM users
-		WHERE email = $1
+		WHERE lower(email) = lower($1)
 		LIMIT 1
 	`
 	row := q.db.QueryRow(ctx, query, email)
@@ -54,6 +68,12 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.PasswordHash,
 		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -62,7 +82,7 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 	const query = `
-		SELECT id, email, password_hash, name, created_at, updated_at
+		SELECT id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
 		FROM users
 		WHERE id = $1
 		LIMIT 1
@@ -75,6 +95,12 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.PasswordHash,
 		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -95,7 +121,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 			email = COALESCE($3, email),
 			updated_at = NOW()
 		WHERE id = $1
-		RETURNING id, email, password_hash, name, created_at, updated_at
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
 	`
 	row := q.db.QueryRow(ctx, query, arg.ID, arg.Name, arg.Email)
 
@@ -105,12 +131,285 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.PasswordHash,
 		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type SetUserE2EEnabledParams struct {
+	ID         uuid.UUID
+	E2EEnabled bool
+}
+
+func (q *Queries) SetUserE2EEnabled(ctx context.Context, arg SetUserE2EEnabledParams) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			e2e_enabled = $2,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.E2EEnabled)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+type SetUserTelemetryOptOutParams struct {
+	ID              uuid.UUID
+	TelemetryOptOut bool
+}
+
+func (q *Queries) SetUserTelemetryOptOut(ctx context.Context, arg SetUserTelemetryOptOutParams) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			telemetry_opt_out = $2,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.TelemetryOptOut)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type SetUserStatusParams struct {
+	ID           uuid.UUID
+	Status       string
+	StatusReason sql.NullString
+}
+
+func (q *Queries) SetUserStatus(ctx context.Context, arg SetUserStatusParams) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			status = $2,
+			status_reason = $3,
+			purge_after = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.Status, arg.StatusReason)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID
+	PasswordHash string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			password_hash = $2,
+			token_version = token_version + 1,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.PasswordHash)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type RehashUserPasswordParams struct {
+	ID              uuid.UUID
+	PasswordHash    string
+	NewPasswordHash string
+}
+
+func (q *Queries) RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) (int64, error) {
+	const query = `
+		UPDATE users
+		SET password_hash = $3
+		WHERE id = $1 AND password_hash = $2
+	`
+	result, err := q.db.Exec(ctx, query, arg.ID, arg.PasswordHash, arg.NewPasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+func (q *Queries) IncrementUserTokenVersion(ctx context.Context, id uuid.UUID) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			token_version = token_version + 1,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, id)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+type RequestUserDeletionParams struct {
+	ID         uuid.UUID
+	PurgeAfter sql.NullTime
+}
+
+func (q *Queries) RequestUserDeletion(ctx context.Context, arg RequestUserDeletionParams) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			status = 'pending_deletion',
+			status_reason = NULL,
+			purge_after = $2,
+			token_version = token_version + 1,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, arg.ID, arg.PurgeAfter)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+func (q *Queries) RestoreUser(ctx context.Context, id uuid.UUID) (User, error) {
+	const query = `
+		UPDATE users
+		SET
+			status = 'active',
+			purge_after = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'pending_deletion' AND purge_after IS NOT NULL
+		RETURNING id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
+	`
+	row := q.db.QueryRow(ctx, query, id)
+
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.PasswordHash,
+		&i.Name,
+		&i.E2EEnabled,
+		&i.Status,
+		&i.StatusReason,
+		&i.TokenVersion,
+		&i.TelemetryOptOut,
+		&i.PurgeAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+func (q *Queries) PurgeDeletedUsers(ctx context.Context, purgeAfter time.Time) (int64, error) {
+	const query = `
+		DELETE FROM users
+		WHERE status = 'pending_deletion' AND purge_after <= $1
+	`
+	result, err := q.db.Exec(ctx, query, purgeAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
 	return err
@@ -123,7 +422,7 @@ type ListUsersParams struct {
 
 func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
 	const query = `
-		SELECT id, email, password_hash, name, created_at, updated_at
+		SELECT id, email, password_hash, name, e2e_enabled, status, status_reason, token_version, telemetry_opt_out, purge_after, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -142,6 +441,13 @@ func (q *Queries) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, e
 			&i.Email,
 			&i.PasswordHash,
 			&i.Name,
+			&i.E2EEnabled,
+			&i.Status,
+			&i.StatusReason,
+			&i.TokenVersion,
+			&i.TelemetryOptOut,
+			&i.PurgeAfter,
+			&i.PurgeAfter,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
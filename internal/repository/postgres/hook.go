@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// HookRepository implements the repository.HookRepository interface
+type HookRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewHookRepository creates a new HookRepository
+func NewHookRepository(pool *pgxpool.Pool) *HookRepository {
+	return &HookRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// Create subscribes a hook from the current end of the user's change feed
+func (r *HookRepository) Create(ctx context.Context, sub *domain.HookSubscription) error {
+	events := make([]string, len(sub.Events))
+	for i, event := range sub.Events {
+		events[i] = string(event)
+	}
+
+	dbSub, err := r.queries.CreateHookSubscription(ctx, db.CreateHookSubscriptionParams{
+		ID:             sub.ID,
+		UserID:         sub.UserID,
+		Events:         events,
+		PayloadVersion: int32(sub.PayloadVersion),
+		TargetUrl:      sub.TargetURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create hook subscription: %w", err)
+	}
+
+	// Update the subscription with generated values
+	sub.LastSeq = dbSub.LastSeq
+	sub.CreatedAt = dbSub.CreatedAt
+
+	return nil
+}
+
+// CountByUserID counts the subscriptions of a user
+func (r *HookRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := r.queries.CountHookSubscriptionsByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count hook subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// GetByID retrieves a subscription of a user
+func (r *HookRepository) GetByID(ctx context.Context, id, userID uuid.UUID) (*domain.HookSubscription, error) {
+	dbSub, err := r.queries.GetHookSubscription(ctx, db.GetHookSubscriptionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get hook subscription: %w", err)
+	}
+	return r.toDomainHookSubscription(dbSub), nil
+}
+
+// ListByUserID retrieves the subscriptions of a user, oldest first
+func (r *HookRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.HookSubscription, error) {
+	dbSubs, err := r.queries.ListHookSubscriptionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hook subscriptions by user ID: %w", err)
+	}
+	return r.toDomainHookSubscriptions(dbSubs), nil
+}
+
+// ListAll retrieves every subscription, oldest first
+func (r *HookRepository) ListAll(ctx context.Context) ([]*domain.HookSubscription, error) {
+	dbSubs, err := r.queries.ListHookSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hook subscriptions: %w", err)
+	}
+	return r.toDomainHookSubscriptions(dbSubs), nil
+}
+
+// Delete removes a subscription of a user
+func (r *HookRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	count, err := r.queries.DeleteHookSubscription(ctx, db.DeleteHookSubscriptionParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete hook subscription: %w", err)
+	}
+	if count == 0 {
+		return repository.ErrNoRowsAffected
+	}
+	return nil
+}
+
+// Advance records that changes up to lastSeq were delivered
+func (r *HookRepository) Advance(ctx context.Context, id uuid.UUID, lastSeq int64) error {
+	err := r.queries.AdvanceHookSubscription(ctx, db.AdvanceHookSubscriptionParams{
+		ID:      id,
+		LastSeq: lastSeq,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to advance hook subscription: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure counts a failed delivery and returns the consecutive failures
+func (r *HookRepository) RecordFailure(ctx context.Context, id uuid.UUID) (int, error) {
+	failures, err := r.queries.RecordHookSubscriptionFailure(ctx, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to record hook subscription failure: %w", err)
+	}
+	return int(failures), nil
+}
+
+// CreateDelivery records a delivery attempt
+func (r *HookRepository) CreateDelivery(ctx context.Context, attempt *domain.HookDeliveryAttempt) error {
+	var statusCode sql.NullInt32
+	if attempt.StatusCode != nil {
+		statusCode = sql.NullInt32{Int32: int32(*attempt.StatusCode), Valid: true}
+	}
+
+	var deliveryErr sql.NullString
+	if attempt.Error != nil {
+		deliveryErr = sql.NullString{String: *attempt.Error, Valid: true}
+	}
+
+	dbAttempt, err := r.queries.CreateHookDelivery(ctx, db.CreateHookDeliveryParams{
+		ID:             attempt.ID,
+		HookID:         attempt.HookID,
+		UserID:         attempt.UserID,
+		Event:          string(attempt.Event),
+		Seq:            attempt.Seq,
+		PayloadVersion: int32(attempt.PayloadVersion),
+		RequestBody:    attempt.Request,
+		StatusCode:     statusCode,
+		Error:          deliveryErr,
+		Succeeded:      attempt.Succeeded,
+		DurationMs:     int32(attempt.DurationMS),
+		Redelivery:     attempt.Redelivery,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create hook delivery: %w", err)
+	}
+
+	// Update the attempt with generated values
+	attempt.CreatedAt = dbAttempt.CreatedAt
+
+	return nil
+}
+
+// GetDelivery retrieves a delivery attempt of a user's subscription
+func (r *HookRepository) GetDelivery(ctx context.Context, id, hookID, userID uuid.UUID) (*domain.HookDeliveryAttempt, error) {
+	dbAttempt, err := r.queries.GetHookDelivery(ctx, db.GetHookDeliveryParams{
+		ID:     id,
+		HookID: hookID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get hook delivery: %w", err)
+	}
+	return r.toDomainHookDeliveryAttempt(dbAttempt), nil
+}
+
+// ListDeliveries retrieves delivery attempts of a user's subscription, newest first
+func (r *HookRepository) ListDeliveries(ctx context.Context, hookID, userID uuid.UUID, failedOnly bool, limit int) ([]*domain.HookDeliveryAttempt, error) {
+	dbAttempts, err := r.queries.ListHookDeliveries(ctx, db.ListHookDeliveriesParams{
+		HookID:     hookID,
+		UserID:     userID,
+		FailedOnly: failedOnly,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hook deliveries: %w", err)
+	}
+
+	attempts := make([]*domain.HookDeliveryAttempt, 0, len(dbAttempts))
+	for _, dbAttempt := range dbAttempts {
+		attempts = append(attempts, r.toDomainHookDeliveryAttempt(dbAttempt))
+	}
+	return attempts, nil
+}
+
+// PruneDeliveries deletes the delivery attempts made before a time
+func (r *HookRepository) PruneDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	count, err := r.queries.PruneHookDeliveries(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune hook deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// toDomainHookDeliveryAttempt converts a db.HookDelivery to domain.HookDeliveryAttempt
+func (r *HookRepository) toDomainHookDeliveryAttempt(dbAttempt db.HookDelivery) *domain.HookDeliveryAttempt {
+	attempt := &domain.HookDeliveryAttempt{
+		ID:             dbAttempt.ID,
+		HookID:         dbAttempt.HookID,
+		UserID:         dbAttempt.UserID,
+		Event:          domain.HookEvent(dbAttempt.Event),
+		Seq:            dbAttempt.Seq,
+		PayloadVersion: int(dbAttempt.PayloadVersion),
+		Request:        dbAttempt.RequestBody,
+		Succeeded:      dbAttempt.Succeeded,
+		DurationMS:     int64(dbAttempt.DurationMs),
+		Redelivery:     dbAttempt.Redelivery,
+		CreatedAt:      dbAttempt.CreatedAt,
+	}
+
+	if dbAttempt.StatusCode.Valid {
+		statusCode := int(dbAttempt.StatusCode.Int32)
+		attempt.StatusCode = &statusCode
+	}
+
+	if dbAttempt.Error.Valid {
+		attempt.Error = &dbAttempt.Error.String
+	}
+
+	return attempt
+}
+
+// toDomainHookSubscriptions converts db.HookSubscription rows to domain.HookSubscription
+func (r *HookRepository) toDomainHookSubscriptions(dbSubs []db.HookSubscription) []*domain.HookSubscription {
+	subs := make([]*domain.HookSubscription, 0, len(dbSubs))
+	for _, dbSub := range dbSubs {
+		subs = append(subs, r.toDomainHookSubscription(dbSub))
+	}
+	return subs
+}
+
+// toDomainHookSubscription converts a db.HookSubscription to domain.HookSubscription
+func (r *HookRepository) toDomainHookSubscription(dbSub db.HookSubscription) *domain.HookSubscription {
+	events := make([]domain.HookEvent, len(dbSub.Events))
+	for i, event := range dbSub.Events {
+		events[i] = domain.HookEvent(event)
+	}
+
+	return &domain.HookSubscription{
+		ID:             dbSub.ID,
+		UserID:         dbSub.UserID,
+		Events:         events,
+		PayloadVersion: int(dbSub.PayloadVersion),
+		TargetURL:      dbSub.TargetUrl,
+		LastSeq:        dbSub.LastSeq,
+		Failures:       int(dbSub.Failures),
+		CreatedAt:      dbSub.CreatedAt,
+	}
+}
@@ -0,0 +1,155 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// NotificationRepository implements the repository.NotificationRepository interface
+type NotificationRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(pool *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// Create creates a new notification
+func (r *NotificationRepository) Create(ctx context.Context, notification *domain.Notification) error {
+	var body sql.NullString
+	if notification.Body != nil {
+		body = sql.NullString{String: *notification.Body, Valid: true}
+	}
+
+	var todoID uuid.NullUUID
+	if notification.TodoID != nil {
+		todoID = uuid.NullUUID{UUID: *notification.TodoID, Valid: true}
+	}
+
+	dbNotification, err := r.queries.CreateNotification(ctx, db.CreateNotificationParams{
+		ID:     notification.ID,
+		UserID: notification.UserID,
+		Kind:   string(notification.Kind),
+		Title:  notification.Title,
+		Body:   body,
+		TodoID: todoID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	// Update the notification with generated values
+	notification.CreatedAt = dbNotification.CreatedAt
+
+	return nil
+}
+
+// ListByUserID retrieves up to limit notifications of a user, newest first,
+// optionally only the unread ones
+func (r *NotificationRepository) ListByUserID(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	var (
+		dbNotifications []db.Notification
+		err             error
+	)
+
+	if unreadOnly {
+		dbNotifications, err = r.queries.ListUnreadNotificationsByUserID(ctx, db.ListUnreadNotificationsByUserIDParams{
+			UserID: userID,
+			Limit:  int32(limit),
+		})
+	} else {
+		dbNotifications, err = r.queries.ListNotificationsByUserID(ctx, db.ListNotificationsByUserIDParams{
+			UserID: userID,
+			Limit:  int32(limit),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications by user ID: %w", err)
+	}
+
+	notifications := make([]*domain.Notification, 0, len(dbNotifications))
+	for _, dbNotification := range dbNotifications {
+		notifications = append(notifications, r.toDomainNotification(dbNotification))
+	}
+
+	return notifications, nil
+}
+
+// CountUnread counts the notifications a user has not read
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := r.queries.CountUnreadNotificationsByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a notification of a user as read and returns it,
+// or nil if the user has no such notification
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) (*domain.Notification, error) {
+	dbNotification, err := r.queries.MarkNotificationRead(ctx, db.MarkNotificationReadParams{
+		ID:     id,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	return r.toDomainNotification(dbNotification), nil
+}
+
+// MarkAllRead marks every notification of a user as read and returns how many changed
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := r.queries.MarkAllNotificationsRead(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark all notifications read: %w", err)
+	}
+	return count, nil
+}
+
+// toDomainNotification converts a db.Notification to domain.Notification
+func (r *NotificationRepository) toDomainNotification(dbNotification db.Notification) *domain.Notification {
+	var body *string
+	if dbNotification.Body.Valid {
+		body = &dbNotification.Body.String
+	}
+
+	var todoID *uuid.UUID
+	if dbNotification.TodoID.Valid {
+		todoID = &dbNotification.TodoID.UUID
+	}
+
+	var readAt *time.Time
+	if dbNotification.ReadAt.Valid {
+		readAt = &dbNotification.ReadAt.Time
+	}
+
+	return &domain.Notification{
+		ID:        dbNotification.ID,
+		UserID:    dbNotification.UserID,
+		Kind:      domain.NotificationKind(dbNotification.Kind),
+		Title:     dbNotification.Title,
+		Body:      body,
+		TodoID:    todoID,
+		ReadAt:    readAt,
+		CreatedAt: dbNotification.CreatedAt,
+	}
+}
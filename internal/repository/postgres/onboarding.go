@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// OnboardingRepository implements the repository.OnboardingRepository interface
+type OnboardingRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewOnboardingRepository creates a new OnboardingRepository
+func NewOnboardingRepository(pool *pgxpool.Pool) *OnboardingRepository {
+	return &OnboardingRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// ListCompleted retrieves the completion time of each step the user has completed
+func (r *OnboardingRepository) ListCompleted(ctx context.Context, userID uuid.UUID) (map[domain.OnboardingStep]time.Time, error) {
+	dbSteps, err := r.queries.ListOnboardingSteps(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list onboarding steps: %w", err)
+	}
+
+	completed := make(map[domain.OnboardingStep]time.Time, len(dbSteps))
+	for _, dbStep := range dbSteps {
+		completed[domain.OnboardingStep(dbStep.Step)] = dbStep.CompletedAt
+	}
+
+	return completed, nil
+}
+
+// Complete marks a step as completed; completing it again keeps the original time
+func (r *OnboardingRepository) Complete(ctx context.Context, userID uuid.UUID, step domain.OnboardingStep) error {
+	err := r.queries.CompleteOnboardingStep(ctx, db.CompleteOnboardingStepParams{
+		UserID: userID,
+		Step:   string(step),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete onboarding step: %w", err)
+	}
+	return nil
+}
+
+// Reset marks a step as not completed
+func (r *OnboardingRepository) Reset(ctx context.Context, userID uuid.UUID, step domain.OnboardingStep) error {
+	err := r.queries.ResetOnboardingStep(ctx, db.ResetOnboardingStepParams{
+		UserID: userID,
+		Step:   string(step),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to reset onboarding step: %w", err)
+	}
+	return nil
+}
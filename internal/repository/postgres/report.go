@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository/postgres/db"
+)
+
+// ReportRepository implements the repository.ReportRepository interface
+type ReportRepository struct {
+	pool    *pgxpool.Pool
+	queries *db.Queries
+}
+
+// NewReportRepository creates a new ReportRepository
+func NewReportRepository(pool *pgxpool.Pool) *ReportRepository {
+	return &ReportRepository{
+		pool:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
+	}
+}
+
+// Usage counts usage per period starting in [from, to)
+func (r *ReportRepository) Usage(ctx context.Context, period domain.ReportPeriod, from, to time.Time) ([]*domain.UsagePeriod, error) {
+	unit := "day"
+	if period == domain.ReportPeriodWeekly {
+		unit = "week"
+	}
+
+	rows, err := r.queries.UsageReport(ctx, db.UsageReportParams{
+		Unit: unit,
+		From: from,
+		To:   to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage report: %w", err)
+	}
+
+	// Each row is one metric of one period; merge them into one entry per period
+	byStart := make(map[time.Time]*domain.UsagePeriod)
+	for _, row := range rows {
+		start := row.PeriodStart.UTC()
+		p, ok := byStart[start]
+		if !ok {
+			p = &domain.UsagePeriod{Start: start}
+			byStart[start] = p
+		}
+
+		switch row.Metric {
+		case "new_users":
+			p.NewUsers = row.Total
+		case "active_users":
+			p.ActiveUsers = row.Total
+		case "todos_created":
+			p.TodosCreated = row.Total
+		case "todos_completed":
+			p.TodosCompleted = row.Total
+		case "churned_users":
+			p.ChurnedUsers = row.Total
+		}
+	}
+
+	periods := make([]*domain.UsagePeriod, 0, len(byStart))
+	for _, p := range byStart {
+		periods = append(periods, p)
+	}
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+
+	return periods, nil
+}
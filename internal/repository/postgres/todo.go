@@ -5,28 +5,59 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository"
 	"github.com/whauzan/todo-api/internal/repository/postgres/db"
 )
 
 // TodoRepository implements the repository.TodoRepository interface
 type TodoRepository struct {
 	pool    *pgxpool.Pool
+	conn    db.DBTX // The pool, or the transaction of a repository passed to InTx
 	queries *db.Queries
+	inTx    bool
 }
 
 // NewTodoRepository creates a new TodoRepository
 func NewTodoRepository(pool *pgxpool.Pool) *TodoRepository {
 	return &TodoRepository{
 		pool:    pool,
-		queries: db.New(pool),
+		conn:    pool,
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
 	}
 }
 
+// InTx calls fn with a repository whose reads and writes share one
+// transaction, and commits it if fn returns nil. Statements in the
+// transaction are not retried. Calling InTx on that repository runs fn in the
+// same transaction.
+func (r *TodoRepository) InTx(ctx context.Context, fn func(repository.TodoRepository) error) error {
+	if r.inTx {
+		return fn(r)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&TodoRepository{pool: r.pool, conn: tx, queries: db.New(tx), inTx: true}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Create creates a new todo
 func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) error {
 	var description sql.NullString
@@ -40,6 +71,10 @@ func (r *TodoRepository) Create(ctx context.Context, todo *domain.Todo) error {
 		Title:       todo.Title,
 		Description: description,
 		Completed:   todo.Completed,
+		Encrypted:   todo.Encrypted,
+
+		TitleCiphertext:       todo.TitleCiphertext,
+		DescriptionCiphertext: todo.DescriptionCiphertext,
 	}
 
 	dbTodo, err := r.queries.CreateTodo(ctx, params)
@@ -82,6 +117,181 @@ func (r *TodoRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]
 	return todos, nil
 }
 
+// ListPageByUserID retrieves up to limit todos for a user, newest first,
+// starting after the given cursor (or from the beginning if it is nil)
+func (r *TodoRepository) ListPageByUserID(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) ([]*domain.Todo, error) {
+	var (
+		dbTodos []db.Todo
+		err     error
+	)
+
+	if after == nil {
+		dbTodos, err = r.queries.ListTodosByUserIDFirstPage(ctx, db.ListTodosByUserIDFirstPageParams{
+			UserID: userID,
+			Limit:  int32(limit),
+		})
+	} else {
+		dbTodos, err = r.queries.ListTodosByUserIDAfterCursor(ctx, db.ListTodosByUserIDAfterCursorParams{
+			UserID:          userID,
+			CursorCreatedAt: after.CreatedAt,
+			CursorID:        after.ID,
+			Limit:           int32(limit),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo page by user ID: %w", err)
+	}
+
+	todos := make([]*domain.Todo, 0, len(dbTodos))
+	for _, dbTodo := range dbTodos {
+		todos = append(todos, r.toDomainTodo(dbTodo))
+	}
+
+	return todos, nil
+}
+
+// streamTodosByUserIDQuery matches ListTodosByUserID but is consumed row by row
+const streamTodosByUserIDQuery = `
+	SELECT id, user_id, title, description, completed, encrypted, title_ciphertext, description_ciphertext, created_at, updated_at
+	FROM todos
+	WHERE user_id = $1
+	ORDER BY created_at DESC
+`
+
+// StreamByUserID calls fn for each todo of a user without buffering the result set
+func (r *TodoRepository) StreamByUserID(ctx context.Context, userID uuid.UUID, fn func(*domain.Todo) error) error {
+	rows, err := r.conn.Query(ctx, streamTodosByUserIDQuery, userID)
+	if err != nil {
+		return fmt.Errorf("failed to stream todos by user ID: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var dbTodo db.Todo
+		if err := rows.Scan(
+			&dbTodo.ID,
+			&dbTodo.UserID,
+			&dbTodo.Title,
+			&dbTodo.Description,
+			&dbTodo.Completed,
+			&dbTodo.Encrypted,
+			&dbTodo.TitleCiphertext,
+			&dbTodo.DescriptionCiphertext,
+			&dbTodo.CreatedAt,
+			&dbTodo.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan streamed todo: %w", err)
+		}
+
+		if err := fn(r.toDomainTodo(dbTodo)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream todos by user ID: %w", err)
+	}
+
+	return nil
+}
+
+// ListSummariesByUserID retrieves summaries of all todos for a user
+func (r *TodoRepository) ListSummariesByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.TodoSummary, error) {
+	rows, err := r.queries.ListTodoSummariesByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo summaries by user ID: %w", err)
+	}
+
+	summaries := make([]*domain.TodoSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, r.toDomainTodoSummary(row))
+	}
+
+	return summaries, nil
+}
+
+// ListSummaryPageByUserID retrieves up to limit todo summaries for a user,
+// newest first, starting after the given cursor (or from the beginning if it is nil)
+func (r *TodoRepository) ListSummaryPageByUserID(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) ([]*domain.TodoSummary, error) {
+	var rows []db.ListTodoSummariesByUserIDRow
+
+	if after == nil {
+		page, err := r.queries.ListTodoSummariesByUserIDFirstPage(ctx, db.ListTodoSummariesByUserIDFirstPageParams{
+			UserID: userID,
+			Limit:  int32(limit),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list todo summary page by user ID: %w", err)
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListTodoSummariesByUserIDRow(row))
+		}
+	} else {
+		page, err := r.queries.ListTodoSummariesByUserIDAfterCursor(ctx, db.ListTodoSummariesByUserIDAfterCursorParams{
+			UserID:          userID,
+			CursorCreatedAt: after.CreatedAt,
+			CursorID:        after.ID,
+			Limit:           int32(limit),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list todo summary page by user ID: %w", err)
+		}
+		for _, row := range page {
+			rows = append(rows, db.ListTodoSummariesByUserIDRow(row))
+		}
+	}
+
+	summaries := make([]*domain.TodoSummary, 0, len(rows))
+	for _, row := range rows {
+		summaries = append(summaries, r.toDomainTodoSummary(row))
+	}
+
+	return summaries, nil
+}
+
+// streamTodoSummariesByUserIDQuery matches ListTodoSummariesByUserID but is consumed row by row
+const streamTodoSummariesByUserIDQuery = `
+	SELECT id, user_id, title, completed, encrypted, title_ciphertext, created_at, updated_at
+	FROM todos
+	WHERE user_id = $1
+	ORDER BY created_at DESC
+`
+
+// StreamSummariesByUserID calls fn for each todo summary of a user without buffering the result set
+func (r *TodoRepository) StreamSummariesByUserID(ctx context.Context, userID uuid.UUID, fn func(*domain.TodoSummary) error) error {
+	rows, err := r.conn.Query(ctx, streamTodoSummariesByUserIDQuery, userID)
+	if err != nil {
+		return fmt.Errorf("failed to stream todo summaries by user ID: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row db.ListTodoSummariesByUserIDRow
+		if err := rows.Scan(
+			&row.ID,
+			&row.UserID,
+			&row.Title,
+			&row.Completed,
+			&row.Encrypted,
+			&row.TitleCiphertext,
+			&row.CreatedAt,
+			&row.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to scan streamed todo summary: %w", err)
+		}
+
+		if err := fn(r.toDomainTodoSummary(row)); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream todo summaries by user ID: %w", err)
+	}
+
+	return nil
+}
+
 // ListByUserIDAndStatus retrieves todos for a user filtered by completion status
 func (r *TodoRepository) ListByUserIDAndStatus(ctx context.Context, userID uuid.UUID, completed bool) ([]*domain.Todo, error) {
 	params := db.ListTodosByUserIDAndStatusParams{
@@ -102,7 +312,48 @@ func (r *TodoRepository) ListByUserIDAndStatus(ctx context.Context, userID uuid.
 	return todos, nil
 }
 
-// Update updates a todo
+// LockChanges locks the user's change sequence until the transaction ends and
+// returns the last sequence number handed out
+func (r *TodoRepository) LockChanges(ctx context.Context, userID uuid.UUID) (int64, error) {
+	seq, err := r.queries.LockUserChangeSeq(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock todo changes: %w", err)
+	}
+	return seq, nil
+}
+
+// LastChangeSeq returns the last sequence number handed out to a user's changes
+func (r *TodoRepository) LastChangeSeq(ctx context.Context, userID uuid.UUID) (int64, error) {
+	seq, err := r.queries.GetUserChangeSeq(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last todo change: %w", err)
+	}
+	return seq, nil
+}
+
+// ListChangesSince retrieves up to limit entries of a user's change feed after since
+func (r *TodoRepository) ListChangesSince(ctx context.Context, userID uuid.UUID, since int64, limit int) ([]*domain.TodoChange, error) {
+	params := db.ListTodoChangesSinceParams{
+		UserID: userID,
+		Seq:    since,
+		Limit:  int32(limit),
+	}
+
+	rows, err := r.queries.ListTodoChangesSince(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo changes since: %w", err)
+	}
+
+	changes := make([]*domain.TodoChange, 0, len(rows))
+	for _, row := range rows {
+		changes = append(changes, r.toDomainTodoChange(userID, row))
+	}
+
+	return changes, nil
+}
+
+// Update updates a todo.
+// The todo is written as a whole, so a nil description clears the stored one.
 func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
 	var description sql.NullString
 	if todo.Description != nil {
@@ -114,12 +365,16 @@ func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
 		Title:       sql.NullString{String: todo.Title, Valid: true},
 		Description: description,
 		Completed:   sql.NullBool{Bool: todo.Completed, Valid: true},
+		Encrypted:   sql.NullBool{Bool: todo.Encrypted, Valid: true},
+
+		TitleCiphertext:       todo.TitleCiphertext,
+		DescriptionCiphertext: todo.DescriptionCiphertext,
 	}
 
 	dbTodo, err := r.queries.UpdateTodo(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil
+			return repository.ErrNoRowsAffected
 		}
 		return fmt.Errorf("failed to update todo: %w", err)
 	}
@@ -130,7 +385,42 @@ func (r *TodoRepository) Update(ctx context.Context, todo *domain.Todo) error {
 	return nil
 }
 
-// Delete deletes a todo
+// CompleteByFilter completes the open todos of a user matching filter and
+// records them under undoToken in a single statement
+func (r *TodoRepository) CompleteByFilter(ctx context.Context, userID uuid.UUID, filter domain.TodoFilter, undoToken uuid.UUID, undoExpiresAt time.Time) (int64, error) {
+	params := db.CompleteTodosByFilterParams{
+		UserID:        userID,
+		IDs:           filter.IDs,
+		CreatedAfter:  nullTime(filter.CreatedAfter),
+		CreatedBefore: nullTime(filter.CreatedBefore),
+		UpdatedBefore: nullTime(filter.UpdatedBefore),
+		Token:         undoToken,
+		ExpiresAt:     undoExpiresAt,
+	}
+
+	count, err := r.queries.CompleteTodosByFilter(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to complete todos by filter: %w", err)
+	}
+	return count, nil
+}
+
+// UndoComplete consumes an undo token and reopens its unchanged todos
+func (r *TodoRepository) UndoComplete(ctx context.Context, userID, undoToken uuid.UUID) (int64, error) {
+	row, err := r.queries.UndoCompleteTodos(ctx, db.UndoCompleteTodosParams{
+		Token:  undoToken,
+		UserID: userID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to undo todo completion: %w", err)
+	}
+	if row.Found == 0 {
+		return 0, repository.ErrNoRowsAffected
+	}
+	return row.Reopened, nil
+}
+
+// Delete deletes a todo and records a tombstone for it
 func (r *TodoRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	err := r.queries.DeleteTodo(ctx, id)
 	if err != nil {
@@ -152,7 +442,60 @@ func (r *TodoRepository) toDomainTodo(dbTodo db.Todo) *domain.Todo {
 		Title:       dbTodo.Title,
 		Description: description,
 		Completed:   dbTodo.Completed,
+		Encrypted:   dbTodo.Encrypted,
 		CreatedAt:   dbTodo.CreatedAt,
 		UpdatedAt:   dbTodo.UpdatedAt,
+
+		TitleCiphertext:       dbTodo.TitleCiphertext,
+		DescriptionCiphertext: dbTodo.DescriptionCiphertext,
+	}
+}
+
+// toDomainTodoSummary converts a summary row to a domain todo summary
+func (r *TodoRepository) toDomainTodoSummary(row db.ListTodoSummariesByUserIDRow) *domain.TodoSummary {
+	return &domain.TodoSummary{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Title:     row.Title,
+		Completed: row.Completed,
+		Encrypted: row.Encrypted,
+		CreatedAt: row.CreatedAt,
+		UpdatedAt: row.UpdatedAt,
+
+		TitleCiphertext: row.TitleCiphertext,
+	}
+}
+
+// toDomainTodoChange converts a change feed row to a domain change. The todo
+// is only joined for upserts.
+func (r *TodoRepository) toDomainTodoChange(userID uuid.UUID, row db.ListTodoChangesSinceRow) *domain.TodoChange {
+	change := &domain.TodoChange{
+		Seq:       row.Seq,
+		Op:        domain.SyncOp(row.Op),
+		TodoID:    row.TodoID,
+		ChangedAt: row.ChangedAt,
+	}
+	if row.Title.Valid {
+		change.Todo = r.toDomainTodo(db.Todo{
+			ID:                    row.TodoID,
+			UserID:                userID,
+			Title:                 row.Title.String,
+			Description:           row.Description,
+			Completed:             row.Completed.Bool,
+			Encrypted:             row.Encrypted.Bool,
+			TitleCiphertext:       row.TitleCiphertext,
+			DescriptionCiphertext: row.DescriptionCiphertext,
+			CreatedAt:             row.CreatedAt.Time,
+			UpdatedAt:             row.UpdatedAt.Time,
+		})
+	}
+	return change
+}
+
+// nullTime converts an optional time to a nullable UTC timestamp
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
 	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
 }
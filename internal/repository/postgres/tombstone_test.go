@@ -0,0 +1,53 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/testutil"
+)
+
+func TestTombstonesStayWithTheirUser(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	repo := postgres.NewTodoRepository(db.Pool)
+	ctx := context.Background()
+
+	owner := testutil.SeedUser(t, db, "tombstone-owner")
+	other := testutil.SeedUser(t, db, "tombstone-other")
+	todo := testutil.SeedTodos(t, db, owner.ID, "Deleted by its owner")[0]
+
+	tombstoneOwner := func() uuid.UUID {
+		t.Helper()
+		var userID uuid.UUID
+		if err := db.Pool.QueryRow(ctx, "SELECT user_id FROM todo_tombstones WHERE id = $1", todo.ID).Scan(&userID); err != nil {
+			t.Fatalf("failed to read tombstone: %v", err)
+		}
+		return userID
+	}
+
+	if err := repo.Delete(ctx, todo.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	// Another user creates a todo with the deleted todo's client-supplied ID
+	reused := &domain.Todo{ID: todo.ID, UserID: other.ID, Title: "Same ID"}
+	if err := repo.Create(ctx, reused); err != nil {
+		t.Fatalf("Create with a reused ID: %v", err)
+	}
+	if got := tombstoneOwner(); got != owner.ID {
+		t.Fatalf("tombstone belongs to %s after another user's create, want the owner %s", got, owner.ID)
+	}
+
+	// Deleting it again records the deletion for the user who deleted it
+	if err := repo.Delete(ctx, todo.ID); err != nil {
+		t.Fatalf("Delete of the reused ID: %v", err)
+	}
+	if got := tombstoneOwner(); got != other.ID {
+		t.Fatalf("tombstone belongs to %s, want the deleting user %s", got, other.ID)
+	}
+}
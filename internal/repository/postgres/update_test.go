@@ -0,0 +1,99 @@
+//go:build integration
+
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/repository"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/testutil"
+)
+
+func TestTodoUpdateAfterDelete(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	repo := postgres.NewTodoRepository(db.Pool)
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "todo-update")
+	todo := testutil.SeedTodos(t, db, user.ID, "Deleted while open")[0]
+
+	// Another request deletes the todo after this one read it
+	if err := repo.Delete(ctx, todo.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	todo.Completed = true
+	if err := repo.Update(ctx, todo); !errors.Is(err, repository.ErrNoRowsAffected) {
+		t.Fatalf("Update after delete = %v, want ErrNoRowsAffected", err)
+	}
+
+	got, err := repo.GetByID(ctx, todo.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got != nil {
+		t.Fatalf("update recreated the deleted todo: %+v", got)
+	}
+
+	// Updating a todo that never existed is reported the same way
+	missing := &domain.Todo{ID: uuid.New(), UserID: user.ID, Title: "Missing"}
+	if err := repo.Update(ctx, missing); !errors.Is(err, repository.ErrNoRowsAffected) {
+		t.Fatalf("Update of a missing todo = %v, want ErrNoRowsAffected", err)
+	}
+}
+
+func TestUserWritesAfterDelete(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	repo := postgres.NewUserRepository(db.Pool)
+	ctx := context.Background()
+
+	reason := "abuse"
+	writes := []struct {
+		name  string
+		write func(*domain.User) error
+	}{
+		{"Update", func(u *domain.User) error { return repo.Update(ctx, u) }},
+		{"SetE2EEnabled", func(u *domain.User) error { return repo.SetE2EEnabled(ctx, u, true) }},
+		{"SetTelemetryOptOut", func(u *domain.User) error { return repo.SetTelemetryOptOut(ctx, u, true) }},
+		{"SetStatus", func(u *domain.User) error { return repo.SetStatus(ctx, u, domain.UserStatusSuspended, &reason) }},
+		{"UpdatePassword", func(u *domain.User) error { return repo.UpdatePassword(ctx, u, u.PasswordHash) }},
+		{"RehashPassword", func(u *domain.User) error { return repo.RehashPassword(ctx, u, u.PasswordHash) }},
+		{"RevokeTokens", func(u *domain.User) error { return repo.RevokeTokens(ctx, u) }},
+		{"RequestDeletion", func(u *domain.User) error { return repo.RequestDeletion(ctx, u, time.Now().Add(time.Hour)) }},
+		{"Restore", func(u *domain.User) error { return repo.Restore(ctx, u) }},
+	}
+
+	for _, tt := range writes {
+		t.Run(tt.name, func(t *testing.T) {
+			seeded := testutil.SeedUser(t, db, "user-write")
+			user, err := repo.GetByID(ctx, seeded.ID)
+			if err != nil || user == nil {
+				t.Fatalf("GetByID = %v, %v", user, err)
+			}
+
+			// Another request deletes the account after this one read it
+			if err := repo.Delete(ctx, user.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+
+			user.Name = "Renamed"
+			if err := tt.write(user); !errors.Is(err, repository.ErrNoRowsAffected) {
+				t.Fatalf("%s after delete = %v, want ErrNoRowsAffected", tt.name, err)
+			}
+
+			got, err := repo.GetByID(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("GetByID after write: %v", err)
+			}
+			if got != nil {
+				t.Fatalf("%s recreated the deleted user", tt.name)
+			}
+		})
+	}
+}
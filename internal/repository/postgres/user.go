@@ -5,11 +5,16 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/pgerror"
+	"github.com/whauzan/todo-api/internal/pkg/pgretry"
+	"github.com/whauzan/todo-api/internal/repository"
 	"github.com/whauzan/todo-api/internal/repository/postgres/db"
 )
 
@@ -23,12 +28,42 @@ type UserRepository struct {
 func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 	return &UserRepository{
 		pool:    pool,
-		queries: db.New(pool),
+		queries: db.New(pgretry.NewDB(pool, pgretry.DefaultPolicy())),
 	}
 }
 
-// Create creates a new user
+// Create creates a new user. The email check and the insert run in one
+// transaction holding a lock on the email, so concurrent registrations with the
+// same email cannot both pass the check; it returns apperror.ErrUserExists if
+// the email is taken. The transaction is retried as a whole if it deadlocks.
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	return pgretry.Do(ctx, pgretry.DefaultPolicy(), false, func(ctx context.Context) error {
+		return r.create(ctx, user)
+	})
+}
+
+// create runs one attempt of Create
+func (r *UserRepository) create(ctx context.Context, user *domain.User) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	user.Email = domain.NormalizeEmail(user.Email)
+
+	qtx := r.queries.WithTx(tx)
+
+	if err := qtx.LockUserEmail(ctx, user.Email); err != nil {
+		return fmt.Errorf("failed to lock email: %w", err)
+	}
+
+	if _, err := qtx.GetUserByEmail(ctx, user.Email); err == nil {
+		return apperror.ErrUserExists
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check existing user: %w", err)
+	}
+
 	params := db.CreateUserParams{
 		ID:           user.ID,
 		Email:        user.Email,
@@ -36,12 +71,21 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 		Name:         user.Name,
 	}
 
-	dbUser, err := r.queries.CreateUser(ctx, params)
+	dbUser, err := qtx.CreateUser(ctx, params)
 	if err != nil {
+		// The unique index still guards writers that do not take the lock
+		if pgerror.IsUniqueViolation(err) {
+			return apperror.ErrUserExists
+		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit user: %w", err)
+	}
+
 	// Update the user with generated values
+	user.Status = domain.UserStatus(dbUser.Status)
 	user.CreatedAt = dbUser.CreatedAt
 	user.UpdatedAt = dbUser.UpdatedAt
 
@@ -61,9 +105,9 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	return r.toDomainUser(dbUser), nil
 }
 
-// GetByEmail retrieves a user by email
+// GetByEmail retrieves a user by email, ignoring case and surrounding whitespace
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	dbUser, err := r.queries.GetUserByEmail(ctx, email)
+	dbUser, err := r.queries.GetUserByEmail(ctx, domain.NormalizeEmail(email))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -79,13 +123,13 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	params := db.UpdateUserParams{
 		ID:    user.ID,
 		Name:  sql.NullString{String: user.Name, Valid: true},
-		Email: sql.NullString{String: user.Email, Valid: true},
+		Email: sql.NullString{String: domain.NormalizeEmail(user.Email), Valid: true},
 	}
 
 	dbUser, err := r.queries.UpdateUser(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil
+			return repository.ErrNoRowsAffected
 		}
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -96,6 +140,172 @@ func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// SetE2EEnabled turns end-to-end encryption mode on or off for a user
+func (r *UserRepository) SetE2EEnabled(ctx context.Context, user *domain.User, enabled bool) error {
+	dbUser, err := r.queries.SetUserE2EEnabled(ctx, db.SetUserE2EEnabledParams{
+		ID:         user.ID,
+		E2EEnabled: enabled,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to set user e2e mode: %w", err)
+	}
+
+	user.E2EEnabled = dbUser.E2EEnabled
+	user.UpdatedAt = dbUser.UpdatedAt
+
+	return nil
+}
+
+// SetTelemetryOptOut records whether a user has opted out of product analytics
+func (r *UserRepository) SetTelemetryOptOut(ctx context.Context, user *domain.User, optOut bool) error {
+	dbUser, err := r.queries.SetUserTelemetryOptOut(ctx, db.SetUserTelemetryOptOutParams{
+		ID:              user.ID,
+		TelemetryOptOut: optOut,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to set user telemetry opt-out: %w", err)
+	}
+
+	user.TelemetryOptOut = dbUser.TelemetryOptOut
+	user.UpdatedAt = dbUser.UpdatedAt
+
+	return nil
+}
+
+// SetStatus changes a user's account status and records the reason for it
+func (r *UserRepository) SetStatus(ctx context.Context, user *domain.User, status domain.UserStatus, reason *string) error {
+	var statusReason sql.NullString
+	if reason != nil {
+		statusReason = sql.NullString{String: *reason, Valid: true}
+	}
+
+	dbUser, err := r.queries.SetUserStatus(ctx, db.SetUserStatusParams{
+		ID:           user.ID,
+		Status:       string(status),
+		StatusReason: statusReason,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to set user status: %w", err)
+	}
+
+	updated := r.toDomainUser(dbUser)
+	user.Status = updated.Status
+	user.StatusReason = updated.StatusReason
+	user.PurgeAfter = updated.PurgeAfter
+	user.UpdatedAt = updated.UpdatedAt
+
+	return nil
+}
+
+// UpdatePassword replaces a user's password hash and revokes every token
+// issued before the change
+func (r *UserRepository) UpdatePassword(ctx context.Context, user *domain.User, passwordHash string) error {
+	dbUser, err := r.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to update user password: %w", err)
+	}
+
+	user.PasswordHash = dbUser.PasswordHash
+	user.TokenVersion = int(dbUser.TokenVersion)
+	user.UpdatedAt = dbUser.UpdatedAt
+
+	return nil
+}
+
+// RehashPassword replaces a user's password hash with a new hash of the same password
+func (r *UserRepository) RehashPassword(ctx context.Context, user *domain.User, passwordHash string) error {
+	count, err := r.queries.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		ID:              user.ID,
+		PasswordHash:    user.PasswordHash,
+		NewPasswordHash: passwordHash,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rehash user password: %w", err)
+	}
+	if count == 0 {
+		return repository.ErrNoRowsAffected
+	}
+
+	user.PasswordHash = passwordHash
+
+	return nil
+}
+
+// RevokeTokens invalidates every token issued to a user so far
+func (r *UserRepository) RevokeTokens(ctx context.Context, user *domain.User) error {
+	dbUser, err := r.queries.IncrementUserTokenVersion(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	user.TokenVersion = int(dbUser.TokenVersion)
+	user.UpdatedAt = dbUser.UpdatedAt
+
+	return nil
+}
+
+// RequestDeletion marks a user pending deletion until purgeAfter and revokes
+// their tokens
+func (r *UserRepository) RequestDeletion(ctx context.Context, user *domain.User, purgeAfter time.Time) error {
+	dbUser, err := r.queries.RequestUserDeletion(ctx, db.RequestUserDeletionParams{
+		ID:         user.ID,
+		PurgeAfter: sql.NullTime{Time: purgeAfter, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to request user deletion: %w", err)
+	}
+
+	*user = *r.toDomainUser(dbUser)
+
+	return nil
+}
+
+// Restore cancels a deletion the user requested
+func (r *UserRepository) Restore(ctx context.Context, user *domain.User) error {
+	dbUser, err := r.queries.RestoreUser(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.ErrNoRowsAffected
+		}
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	*user = *r.toDomainUser(dbUser)
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes users whose deletion was due at or before now,
+// along with their data, and returns how many were deleted
+func (r *UserRepository) PurgeDeleted(ctx context.Context, now time.Time) (int64, error) {
+	count, err := r.queries.PurgeDeletedUsers(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+	return count, nil
+}
+
 // Delete deletes a user
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	err := r.queries.DeleteUser(ctx, id)
@@ -107,12 +317,28 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 // toDomainUser converts a db.User to domain.User
 func (r *UserRepository) toDomainUser(dbUser db.User) *domain.User {
+	var statusReason *string
+	if dbUser.StatusReason.Valid {
+		statusReason = &dbUser.StatusReason.String
+	}
+
+	var purgeAfter *time.Time
+	if dbUser.PurgeAfter.Valid {
+		purgeAfter = &dbUser.PurgeAfter.Time
+	}
+
 	return &domain.User{
-		ID:           dbUser.ID,
-		Email:        dbUser.Email,
-		PasswordHash: dbUser.PasswordHash,
-		Name:         dbUser.Name,
-		CreatedAt:    dbUser.CreatedAt,
-		UpdatedAt:    dbUser.UpdatedAt,
+		ID:              dbUser.ID,
+		Email:           dbUser.Email,
+		PasswordHash:    dbUser.PasswordHash,
+		Name:            dbUser.Name,
+		E2EEnabled:      dbUser.E2EEnabled,
+		Status:          domain.UserStatus(dbUser.Status),
+		StatusReason:    statusReason,
+		TokenVersion:    int(dbUser.TokenVersion),
+		TelemetryOptOut: dbUser.TelemetryOptOut,
+		PurgeAfter:      purgeAfter,
+		CreatedAt:       dbUser.CreatedAt,
+		UpdatedAt:       dbUser.UpdatedAt,
 	}
 }
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/dlock"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/password"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// purgeLockName is the distributed lock held while an instance purges accounts
+const purgeLockName = "account-purge"
+
+// abuseCount counts a user's abuse signals in the current fixed window
+type abuseCount struct {
+	start time.Time
+	count int
+}
+
+// AccountService manages account status: administrative suspension and
+// reactivation, automatic suspension of accounts that keep tripping abuse
+// signals, and deletion requested by the account owner
+type AccountService struct {
+	userRepo       repository.UserRepository
+	hasher         *password.Hasher
+	abuseThreshold int
+	abuseWindow    time.Duration
+	deletionGrace  time.Duration
+	logger         *slog.Logger
+
+	mu     sync.Mutex
+	abuses map[uuid.UUID]*abuseCount
+}
+
+// NewAccountService creates a new AccountService. An account is suspended
+// automatically after abuseThreshold abuse signals within abuseWindow;
+// a threshold of 0 disables automatic suspension. Accounts whose owner asks
+// for deletion are purged once deletionGrace has passed.
+func NewAccountService(
+	userRepo repository.UserRepository,
+	hasher *password.Hasher,
+	abuseThreshold int,
+	abuseWindow time.Duration,
+	deletionGrace time.Duration,
+	logger *slog.Logger,
+) *AccountService {
+	return &AccountService{
+		userRepo:       userRepo,
+		hasher:         hasher,
+		abuseThreshold: abuseThreshold,
+		abuseWindow:    abuseWindow,
+		deletionGrace:  deletionGrace,
+		logger:         logger,
+		abuses:         make(map[uuid.UUID]*abuseCount),
+	}
+}
+
+// Get retrieves a user with their account status
+func (s *AccountService) Get(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user by ID", "user_id", userID))
+	}
+
+	if user == nil {
+		return nil, userNotFound(userID)
+	}
+
+	return user, nil
+}
+
+// Suspend locks an account. Its existing tokens are rejected from the next request on.
+func (s *AccountService) Suspend(ctx context.Context, userID uuid.UUID, reason string) (*domain.User, error) {
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status == domain.UserStatusPendingDeletion {
+		return nil, apperror.ErrConflict.WithDetails("status: account is pending deletion")
+	}
+
+	var statusReason *string
+	if reason != "" {
+		statusReason = &reason
+	}
+
+	if err := s.userRepo.SetStatus(ctx, user, domain.UserStatusSuspended, statusReason); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "suspend user", "user_id", userID))
+	}
+
+	s.logger.WarnContext(ctx, "account suspended", "user_id", userID, "reason", reason)
+
+	return user, nil
+}
+
+// Reactivate restores a suspended or pending-deletion account to active
+func (s *AccountService) Reactivate(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsActive() {
+		return user, nil
+	}
+
+	if err := s.userRepo.SetStatus(ctx, user, domain.UserStatusActive, nil); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "reactivate user", "user_id", userID))
+	}
+
+	s.mu.Lock()
+	delete(s.abuses, userID)
+	s.mu.Unlock()
+
+	s.logger.InfoContext(ctx, "account reactivated", "user_id", userID)
+
+	return user, nil
+}
+
+// RequestDeletion verifies the password and schedules the account for deletion
+// after the grace period. The account is signed out everywhere at once, and
+// signing in again before the deletion is carried out restores it.
+func (s *AccountService) RequestDeletion(ctx context.Context, userID uuid.UUID, req *domain.DeleteAccountRequest) (*domain.AccountDeletion, error) {
+	user, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.hasher.Verify(req.Password, user.PasswordHash); err != nil {
+		if errors.Is(err, password.ErrMismatchedHashAndPassword) {
+			return nil, apperror.ErrInvalidCredentials
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "verify password", "user_id", userID))
+	}
+
+	purgeAfter := time.Now().UTC().Add(s.deletionGrace)
+	if err := s.userRepo.RequestDeletion(ctx, user, purgeAfter); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "request account deletion", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "account deletion requested", "user_id", userID, "purge_after", purgeAfter)
+
+	return &domain.AccountDeletion{Status: user.Status, PurgeAfter: purgeAfter}, nil
+}
+
+// PurgeDeleted permanently deletes accounts whose grace period has ended
+func (s *AccountService) PurgeDeleted(ctx context.Context) (int64, error) {
+	count, err := s.userRepo.PurgeDeleted(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, apperror.ErrInternal.WithCause(errctx.Wrap(err, "purge deleted accounts"))
+	}
+
+	if count > 0 {
+		s.logger.InfoContext(ctx, "deleted accounts purged", "count", count)
+	}
+
+	return count, nil
+}
+
+// RunPurge purges deleted accounts every interval until ctx is cancelled.
+// When locker is not nil, only one instance purges at a time and the others
+// skip the tick.
+func (s *AccountService) RunPurge(ctx context.Context, interval time.Duration, locker *dlock.Locker) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purgeTick(ctx, locker)
+		}
+	}
+}
+
+// purgeTick runs one scheduled purge
+func (s *AccountService) purgeTick(ctx context.Context, locker *dlock.Locker) {
+	if locker != nil {
+		lock, err := locker.TryAcquire(ctx, purgeLockName)
+		if errors.Is(err, dlock.ErrLocked) {
+			s.logger.DebugContext(ctx, "account purge skipped: running on another instance")
+			return
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to lock account purge", "error", err)
+			return
+		}
+		defer func() {
+			if err := lock.Release(ctx); err != nil {
+				s.logger.WarnContext(ctx, "failed to unlock account purge", "error", err)
+			}
+		}()
+	}
+
+	if _, err := s.PurgeDeleted(ctx); err != nil {
+		s.logger.ErrorContext(ctx, "failed to purge deleted accounts",
+			append([]any{"error", err}, errctx.LogAttrs(err)...)...)
+	}
+}
+
+// RecordAbuse counts an abuse signal for a user and suspends the account once
+// the threshold is reached within the window. Counts are kept per instance.
+func (s *AccountService) RecordAbuse(ctx context.Context, userID uuid.UUID, signal string) {
+	if s.abuseThreshold <= 0 {
+		return
+	}
+
+	if !s.countAbuse(userID) {
+		return
+	}
+
+	reason := fmt.Sprintf("automatic: %d %s signals within %s", s.abuseThreshold, signal, s.abuseWindow)
+	if _, err := s.Suspend(ctx, userID, reason); err != nil {
+		s.logger.ErrorContext(ctx, "failed to suspend abusive account",
+			append([]any{"error", err, "user_id", userID, "signal", signal}, errctx.LogAttrs(err)...)...)
+	}
+}
+
+// countAbuse counts a signal and reports whether it reached the threshold
+func (s *AccountService) countAbuse(userID uuid.UUID) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired windows so the map does not grow forever
+	for id, w := range s.abuses {
+		if now.Sub(w.start) >= s.abuseWindow {
+			delete(s.abuses, id)
+		}
+	}
+
+	w, ok := s.abuses[userID]
+	if !ok {
+		w = &abuseCount{start: now}
+		s.abuses[userID] = w
+	}
+	w.count++
+
+	if w.count < s.abuseThreshold {
+		return false
+	}
+	delete(s.abuses, userID)
+	return true
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// maxAgentToolNameLength is the longest tool name kept in the audit log, so
+// calls to made-up tools are recorded too
+const maxAgentToolNameLength = 100
+
+// agentTools describes every agent tool. The schemas forbid arguments they
+// do not list, as the handler does when it decodes them.
+var agentTools = []*domain.AgentTool{
+	{
+		Name:        domain.AgentToolListTodos,
+		Description: "List the user's todos, newest first. Pass next_cursor from the previous result as cursor to get the next page.",
+		Scope:       jwt.ScopeAgentRead,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"limit": {"type": "integer", "minimum": 1, "maximum": 100, "description": "Todos per page (default 50)"},
+				"cursor": {"type": "string", "description": "next_cursor of the previous page"}
+			},
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        domain.AgentToolCreateTodo,
+		Description: "Create an open todo for the user.",
+		Scope:       jwt.ScopeAgentWrite,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"title": {"type": "string", "minLength": 1, "maxLength": 255},
+				"description": {"type": "string", "maxLength": 2000}
+			},
+			"required": ["title"],
+			"additionalProperties": false
+		}`),
+	},
+	{
+		Name:        domain.AgentToolCompleteTodo,
+		Description: "Mark one of the user's todos as completed.",
+		Scope:       jwt.ScopeAgentWrite,
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "format": "uuid", "description": "ID of the todo"}
+			},
+			"required": ["id"],
+			"additionalProperties": false
+		}`),
+	},
+}
+
+// AgentService exposes a fixed set of todo operations as tools for LLM agents
+// and MCP servers. Only the tools on the configured allowlist can be called,
+// each needs the scope it declares, and every call is written to an audit log
+// the user can review.
+type AgentService struct {
+	todoService *TodoService
+	actionRepo  repository.AgentActionRepository
+	idGen       *idgen.Generator
+	tools       []*domain.AgentTool
+	logger      *slog.Logger
+}
+
+// NewAgentService creates a new AgentService. allowed lists the names of the
+// tools agents may call.
+func NewAgentService(
+	todoService *TodoService,
+	actionRepo repository.AgentActionRepository,
+	idGen *idgen.Generator,
+	allowed []string,
+	logger *slog.Logger,
+) *AgentService {
+	var tools []*domain.AgentTool
+	for _, tool := range agentTools {
+		if slices.Contains(allowed, tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
+
+	return &AgentService{
+		todoService: todoService,
+		actionRepo:  actionRepo,
+		idGen:       idGen,
+		tools:       tools,
+		logger:      logger,
+	}
+}
+
+// Tools returns the allowed tools a token with scopes can call
+func (s *AgentService) Tools(scopes []string) []*domain.AgentTool {
+	tools := make([]*domain.AgentTool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		if slices.Contains(scopes, tool.Scope) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// Authorize returns the named tool if it is allowed and scopes grant it
+func (s *AgentService) Authorize(name string, scopes []string) (*domain.AgentTool, error) {
+	i := slices.IndexFunc(s.tools, func(tool *domain.AgentTool) bool { return tool.Name == name })
+	if i < 0 {
+		return nil, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Tool not found",
+			http.StatusNotFound,
+			fmt.Errorf("agent tool %q is not allowed", name),
+		)
+	}
+
+	tool := s.tools[i]
+	if !slices.Contains(scopes, tool.Scope) {
+		return nil, apperror.NewAppError(
+			apperror.CodeForbidden,
+			fmt.Sprintf("Token does not grant the %s scope", tool.Scope),
+			http.StatusForbidden,
+			nil,
+		)
+	}
+
+	return tool, nil
+}
+
+// ListTodos runs the list_todos tool
+func (s *AgentService) ListTodos(ctx context.Context, userID uuid.UUID, in *domain.ListTodosToolInput) (*domain.ListTodosToolOutput, error) {
+	limit := DefaultPageLimit
+	if in.Limit != nil {
+		limit = *in.Limit
+	}
+
+	var after *domain.TodoCursor
+	if in.Cursor != "" {
+		cursor, err := domain.DecodeTodoCursor(in.Cursor)
+		if err != nil {
+			return nil, apperror.ErrValidation.WithDetails("cursor: is invalid")
+		}
+		after = cursor
+	}
+
+	page, err := s.todoService.ListPage(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &domain.ListTodosToolOutput{Todos: page.Todos}
+	if page.NextCursor != nil {
+		out.NextCursor = page.NextCursor.Encode()
+	}
+	return out, nil
+}
+
+// CreateTodo runs the create_todo tool
+func (s *AgentService) CreateTodo(ctx context.Context, userID uuid.UUID, in *domain.CreateTodoToolInput) (*domain.Todo, error) {
+	todo, _, err := s.todoService.Create(ctx, userID, &domain.CreateTodoRequest{
+		Title:       in.Title,
+		Description: in.Description,
+	})
+	return todo, err
+}
+
+// CompleteTodo runs the complete_todo tool
+func (s *AgentService) CompleteTodo(ctx context.Context, userID uuid.UUID, in *domain.CompleteTodoToolInput) (*domain.Todo, error) {
+	return s.todoService.Update(ctx, userID, in.ID, &domain.UpdateTodoRequest{
+		Completed: domain.Some(true),
+	})
+}
+
+// Record writes a tool call to the audit log. clientIP is the address the
+// call came from, if known, arguments the raw request body, todoID the todo
+// the call touched, if any, and callErr its error. A call is never undone
+// when recording it fails; the failure is logged.
+func (s *AgentService) Record(ctx context.Context, userID uuid.UUID, clientIP, tool string, arguments []byte, todoID *uuid.UUID, callErr error) {
+	if utf8.RuneCountInString(tool) > maxAgentToolNameLength {
+		tool = string([]rune(tool)[:maxAgentToolNameLength])
+	}
+
+	action := &domain.AgentAction{
+		ID:      s.idGen.New(),
+		UserID:  userID,
+		Tool:    tool,
+		Outcome: domain.AgentOutcomeOK,
+		TodoID:  todoID,
+	}
+	if clientIP != "" {
+		action.ClientIP = &clientIP
+	}
+	if json.Valid(arguments) {
+		action.Arguments = arguments
+	}
+	if callErr != nil {
+		code := string(apperror.CodeInternal)
+		var appErr *apperror.AppError
+		if errors.As(callErr, &appErr) {
+			code = string(appErr.Code)
+		}
+		action.Outcome = domain.AgentOutcomeError
+		action.ErrorCode = &code
+	}
+
+	s.logger.InfoContext(ctx, "agent action", "user_id", userID, "tool", tool, "outcome", action.Outcome, "todo_id", todoID, "client_ip", clientIP)
+
+	// The call already happened, so it is recorded even if the client went away
+	if err := s.actionRepo.Create(context.WithoutCancel(ctx), action); err != nil {
+		s.logger.ErrorContext(ctx, "failed to record agent action", "error", err, "user_id", userID, "tool", tool)
+	}
+}
+
+// ListActions retrieves up to limit agent actions taken for a user, newest first
+func (s *AgentService) ListActions(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.AgentAction, error) {
+	actions, err := s.actionRepo.ListByUserID(ctx, userID, limit)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list agent actions", "user_id", userID))
+	}
+	return actions, nil
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// AnnouncementService handles announcement banners: admins schedule them and
+// users see the active ones until they dismiss them
+type AnnouncementService struct {
+	announcementRepo repository.AnnouncementRepository
+	idGen            *idgen.Generator
+	logger           *slog.Logger
+}
+
+// NewAnnouncementService creates a new AnnouncementService
+func NewAnnouncementService(
+	announcementRepo repository.AnnouncementRepository,
+	idGen *idgen.Generator,
+	logger *slog.Logger,
+) *AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		idGen:            idGen,
+		logger:           logger,
+	}
+}
+
+// Create schedules a new announcement
+func (s *AnnouncementService) Create(ctx context.Context, req *domain.CreateAnnouncementRequest) (*domain.Announcement, error) {
+	announcement := &domain.Announcement{
+		ID:       s.idGen.New(),
+		Message:  req.Message,
+		Severity: req.Severity,
+		StartsAt: time.Now().UTC(),
+	}
+	if announcement.Severity == "" {
+		announcement.Severity = domain.AnnouncementSeverityInfo
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = req.StartsAt.UTC()
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		if !endsAt.After(announcement.StartsAt) {
+			return nil, apperror.ErrValidation.WithDetails("ends_at: must be after starts_at")
+		}
+		announcement.EndsAt = &endsAt
+	}
+
+	if err := s.announcementRepo.Create(ctx, announcement); err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create announcement"))
+	}
+
+	s.logger.InfoContext(ctx, "announcement created",
+		"announcement_id", announcement.ID, "severity", announcement.Severity)
+
+	return announcement, nil
+}
+
+// List retrieves every announcement, including scheduled and expired ones
+func (s *AnnouncementService) List(ctx context.Context) ([]*domain.Announcement, error) {
+	announcements, err := s.announcementRepo.List(ctx)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list announcements"))
+	}
+	return announcements, nil
+}
+
+// ListActive retrieves the active announcements the user has not dismissed
+func (s *AnnouncementService) ListActive(ctx context.Context, userID uuid.UUID) ([]*domain.Announcement, error) {
+	announcements, err := s.announcementRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list active announcements", "user_id", userID))
+	}
+	return announcements, nil
+}
+
+// Delete removes an announcement for every user
+func (s *AnnouncementService) Delete(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.announcementRepo.Delete(ctx, id); err != nil {
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "delete announcement", "announcement_id", id))
+	}
+
+	s.logger.InfoContext(ctx, "announcement deleted", "announcement_id", id)
+
+	return nil
+}
+
+// Dismiss hides an announcement from a user
+func (s *AnnouncementService) Dismiss(ctx context.Context, userID, id uuid.UUID) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
+
+	if err := s.announcementRepo.Dismiss(ctx, id, userID); err != nil {
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "dismiss announcement", "announcement_id", id, "user_id", userID))
+	}
+
+	return nil
+}
+
+// get retrieves an announcement, returning a not found error if it does not exist
+func (s *AnnouncementService) get(ctx context.Context, id uuid.UUID) (*domain.Announcement, error) {
+	announcement, err := s.announcementRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get announcement by ID", "announcement_id", id))
+	}
+
+	if announcement == nil {
+		return nil, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Announcement not found",
+			http.StatusNotFound,
+			fmt.Errorf("announcement with ID %s not found", id),
+		)
+	}
+
+	return announcement, nil
+}
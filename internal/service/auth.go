@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/whauzan/todo-api/internal/domain"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
 	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
 	"github.com/whauzan/todo-api/internal/pkg/password"
 	"github.com/whauzan/todo-api/internal/repository"
 )
@@ -19,6 +23,8 @@ type AuthService struct {
 	userRepo     repository.UserRepository
 	tokenManager *jwt.TokenManager
 	hasher       *password.Hasher
+	idGen        *idgen.Generator
+	kpis         *metrics.KPIs
 	logger       *slog.Logger
 }
 
@@ -27,49 +33,45 @@ func NewAuthService(
 	userRepo repository.UserRepository,
 	tokenManager *jwt.TokenManager,
 	hasher *password.Hasher,
+	idGen *idgen.Generator,
+	kpis *metrics.KPIs,
 	logger *slog.Logger,
 ) *AuthService {
 	return &AuthService{
 		userRepo:     userRepo,
 		tokenManager: tokenManager,
 		hasher:       hasher,
+		idGen:        idGen,
+		kpis:         kpis,
 		logger:       logger,
 	}
 }
 
 // Register registers a new user
 func (s *AuthService) Register(ctx context.Context, req *domain.RegisterRequest) (*domain.UserInfo, error) {
-	// Check if user already exists
-	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
-	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to check existing user", "error", err)
-		return nil, apperror.ErrInternal
-	}
-
-	if existingUser != nil {
-		return nil, apperror.ErrUserExists
-	}
-
-	// Hash password
+	// Hash password; the email is checked atomically with the insert below
+	// so concurrent registrations cannot both succeed
 	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to hash password", "error", err)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "hash password"))
 	}
 
 	// Create user
 	user := &domain.User{
-		ID:           uuid.New(),
+		ID:           s.idGen.New(),
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
 		Name:         req.Name,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		s.logger.ErrorContext(ctx, "failed to create user", "error", err)
-		return nil, apperror.ErrInternal
+		if errors.Is(err, apperror.ErrUserExists) {
+			return nil, apperror.ErrUserExists
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create user"))
 	}
 
+	s.kpis.RecordRegistration()
 	s.logger.InfoContext(ctx, "user registered successfully", "user_id", user.ID, "email", user.Email)
 
 	return user.ToUserInfo(), nil
@@ -80,35 +82,58 @@ func (s *AuthService) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to get user by email", "error", err)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user by email"))
 	}
 
 	if user == nil {
+		s.kpis.RecordLogin(false)
 		return nil, apperror.ErrInvalidCredentials
 	}
 
 	// Verify password
 	if err := s.hasher.Verify(req.Password, user.PasswordHash); err != nil {
 		if errors.Is(err, password.ErrMismatchedHashAndPassword) {
+			s.kpis.RecordLogin(false)
 			return nil, apperror.ErrInvalidCredentials
 		}
-		s.logger.ErrorContext(ctx, "failed to verify password", "error", err)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "verify password"))
+	}
+
+	// Signing in during the grace period cancels a requested deletion
+	if user.CanRestore(time.Now().UTC()) {
+		err := s.userRepo.Restore(ctx, user)
+		switch {
+		case err == nil:
+			s.logger.InfoContext(ctx, "account deletion cancelled by sign-in", "user_id", user.ID)
+		case !errors.Is(err, repository.ErrNoRowsAffected):
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "restore account", "user_id", user.ID))
+		}
+	}
+
+	// Only reveal the account status to callers who know the password
+	if !user.IsActive() {
+		s.logger.WarnContext(ctx, "login rejected: account not active", "user_id", user.ID, "status", user.Status)
+		return nil, apperror.ErrAccountSuspended
+	}
+
+	// Move the hash to the current pepper and cost while the password is known
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		s.rehashPassword(ctx, user, req.Password)
 	}
 
 	// Generate JWT token
-	tokenResp, err := s.tokenManager.GenerateToken(user.ID, user.Email)
+	tokenResp, err := s.tokenManager.GenerateToken(user.ID, user.Email, user.TokenVersion, jwt.ScopesFor(req.Client))
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to generate token", "error", err)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "generate token"))
 	}
 
+	s.kpis.RecordLogin(true)
 	s.logger.InfoContext(ctx, "user logged in successfully", "user_id", user.ID, "email", user.Email)
 
 	return &domain.LoginResponse{
 		Token:     tokenResp.Token,
 		ExpiresAt: tokenResp.ExpiresAt,
+		Scopes:    tokenResp.Scopes,
 		User:      user.ToUserInfo(),
 	}, nil
 }
@@ -130,23 +155,30 @@ func (s *AuthService) Refresh(ctx context.Context, tokenString string) (*domain.
 	// Validate the token to get user claims
 	claims, err := s.tokenManager.ValidateToken(tokenResp.Token)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to validate refreshed token", "error", err)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "validate refreshed token"))
 	}
 
 	// Get user info
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to get user by ID", "error", err, "user_id", claims.UserID)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user by ID", "user_id", claims.UserID))
 	}
 
 	if user == nil {
+		return nil, userNotFound(claims.UserID)
+	}
+
+	if !user.IsActive() {
+		return nil, apperror.ErrAccountSuspended
+	}
+
+	// A refreshed token keeps its version, so revoked tokens cannot be renewed
+	if claims.TokenVersion != user.TokenVersion {
 		return nil, apperror.NewAppError(
-			apperror.CodeNotFound,
-			"User not found",
-			404,
-			fmt.Errorf("user with ID %s not found", claims.UserID),
+			apperror.CodeUnauthorized,
+			"Token has been revoked",
+			401,
+			nil,
 		)
 	}
 
@@ -155,26 +187,154 @@ func (s *AuthService) Refresh(ctx context.Context, tokenString string) (*domain.
 	return &domain.LoginResponse{
 		Token:     tokenResp.Token,
 		ExpiresAt: tokenResp.ExpiresAt,
+		Scopes:    tokenResp.Scopes,
 		User:      user.ToUserInfo(),
 	}, nil
 }
 
+// userNotFound is returned when a user does not exist or was deleted while being changed
+func userNotFound(userID uuid.UUID) *apperror.AppError {
+	return apperror.NewAppError(
+		apperror.CodeNotFound,
+		"User not found",
+		404,
+		fmt.Errorf("user with ID %s not found", userID),
+	)
+}
+
 // GetUserByID retrieves a user by ID
 func (s *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to get user by ID", "error", err, "user_id", userID)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user by ID", "user_id", userID))
 	}
 
 	if user == nil {
-		return nil, apperror.NewAppError(
-			apperror.CodeNotFound,
-			"User not found",
-			404,
-			fmt.Errorf("user with ID %s not found", userID),
-		)
+		return nil, userNotFound(userID)
 	}
 
 	return user, nil
 }
+
+// SetEncryption turns end-to-end encryption mode on or off for a user.
+// Turning it on rejects plaintext content in later writes; existing todos are
+// left as they are until the client re-saves them encrypted.
+func (s *AuthService) SetEncryption(ctx context.Context, userID uuid.UUID, enabled bool) (*domain.UserInfo, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetE2EEnabled(ctx, user, enabled); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "set encryption mode", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "encryption mode changed", "user_id", userID, "e2e_enabled", enabled)
+
+	return user.ToUserInfo(), nil
+}
+
+// SetTelemetry turns product analytics on or off for a user. It takes effect
+// from the user's next request.
+func (s *AuthService) SetTelemetry(ctx context.Context, userID uuid.UUID, enabled bool) (*domain.UserInfo, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.SetTelemetryOptOut(ctx, user, !enabled); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "set telemetry opt-out", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "telemetry preference changed", "user_id", userID, "telemetry_opt_out", !enabled)
+
+	return user.ToUserInfo(), nil
+}
+
+// rehashPassword replaces a user's password hash with one made by the current
+// hasher settings. Failures only delay the rehash to a later sign-in.
+func (s *AuthService) rehashPassword(ctx context.Context, user *domain.User, plaintext string) {
+	hashedPassword, err := s.hasher.Hash(plaintext)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to rehash password", "error", err, "user_id", user.ID)
+		return
+	}
+
+	err = s.userRepo.RehashPassword(ctx, user, hashedPassword)
+	switch {
+	case err == nil:
+		s.logger.InfoContext(ctx, "password rehashed", "user_id", user.ID)
+	case errors.Is(err, repository.ErrNoRowsAffected):
+		// The password changed concurrently; its new hash is already current
+	default:
+		s.logger.WarnContext(ctx, "failed to rehash password", "error", err, "user_id", user.ID)
+	}
+}
+
+// ChangePassword verifies the current password, stores the new one and revokes
+// every token issued before the change. The returned token, granting the same
+// scopes as the caller's, keeps the caller signed in on the device that made the change.
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, scopes []string, req *domain.ChangePasswordRequest) (*domain.LoginResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.hasher.Verify(req.CurrentPassword, user.PasswordHash); err != nil {
+		if errors.Is(err, password.ErrMismatchedHashAndPassword) {
+			return nil, apperror.ErrInvalidCredentials
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "verify password", "user_id", userID))
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "hash password"))
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user, hashedPassword); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, userNotFound(userID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "update password", "user_id", userID))
+	}
+
+	tokenResp, err := s.tokenManager.GenerateToken(user.ID, user.Email, user.TokenVersion, scopes)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "generate token"))
+	}
+
+	s.logger.InfoContext(ctx, "password changed", "user_id", user.ID)
+
+	return &domain.LoginResponse{
+		Token:     tokenResp.Token,
+		ExpiresAt: tokenResp.ExpiresAt,
+		Scopes:    tokenResp.Scopes,
+		User:      user.ToUserInfo(),
+	}, nil
+}
+
+// LogoutAll revokes every token issued to a user, signing them out on all devices
+func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.RevokeTokens(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return userNotFound(userID)
+		}
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "revoke tokens", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "signed out everywhere", "user_id", userID)
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// CORSOriginService manages the origins of sites allowed to call the widget
+// routes from browsers. Allowed answers from an in-memory copy
+// of the allowlist, so CORS checks never wait for the database; changes made
+// on other instances are picked up by Run.
+type CORSOriginService struct {
+	originRepo repository.CORSOriginRepository
+	logger     *slog.Logger
+
+	allowed atomic.Pointer[map[string]struct{}]
+}
+
+// NewCORSOriginService creates a new CORSOriginService with an empty allowlist
+// until the first Refresh
+func NewCORSOriginService(originRepo repository.CORSOriginRepository, logger *slog.Logger) *CORSOriginService {
+	s := &CORSOriginService{
+		originRepo: originRepo,
+		logger:     logger,
+	}
+	s.allowed.Store(&map[string]struct{}{})
+	return s
+}
+
+// Allowed reports whether an origin is on the allowlist
+func (s *CORSOriginService) Allowed(origin string) bool {
+	_, ok := (*s.allowed.Load())[strings.ToLower(origin)]
+	return ok
+}
+
+// List retrieves every allowed origin
+func (s *CORSOriginService) List(ctx context.Context) ([]*domain.CORSOrigin, error) {
+	origins, err := s.originRepo.List(ctx)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list CORS origins"))
+	}
+	return origins, nil
+}
+
+// Add allows an origin such as https://example.com
+func (s *CORSOriginService) Add(ctx context.Context, req *domain.AddCORSOriginRequest) (*domain.CORSOrigin, error) {
+	origin, err := normalizeOrigin(req.Origin)
+	if err != nil {
+		return nil, apperror.ErrValidation.WithDetails("origin: " + err.Error())
+	}
+
+	added, err := s.originRepo.Add(ctx, origin)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "add CORS origin", "origin", origin))
+	}
+
+	s.refreshAfterChange(ctx)
+	s.logger.InfoContext(ctx, "CORS origin allowed", "origin", origin)
+
+	return added, nil
+}
+
+// Remove disallows an origin
+func (s *CORSOriginService) Remove(ctx context.Context, origin string) error {
+	normalized, err := normalizeOrigin(origin)
+	if err != nil {
+		return apperror.ErrValidation.WithDetails("origin: " + err.Error())
+	}
+
+	if err := s.originRepo.Remove(ctx, normalized); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return apperror.NewAppError(
+				apperror.CodeNotFound,
+				"Origin not found",
+				404,
+				fmt.Errorf("CORS origin %s not found", normalized),
+			)
+		}
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "remove CORS origin", "origin", normalized))
+	}
+
+	s.refreshAfterChange(ctx)
+	s.logger.InfoContext(ctx, "CORS origin removed", "origin", normalized)
+
+	return nil
+}
+
+// Refresh reloads the in-memory allowlist from the database
+func (s *CORSOriginService) Refresh(ctx context.Context) error {
+	origins, err := s.originRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]struct{}, len(origins))
+	for _, o := range origins {
+		allowed[o.Origin] = struct{}{}
+	}
+	s.allowed.Store(&allowed)
+	return nil
+}
+
+// Run refreshes the allowlist every interval until ctx is cancelled
+func (s *CORSOriginService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.logger.ErrorContext(ctx, "failed to refresh CORS origins", "error", err)
+			}
+		}
+	}
+}
+
+// refreshAfterChange applies a change to this instance right away; on failure
+// the next scheduled refresh applies it
+func (s *CORSOriginService) refreshAfterChange(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		s.logger.WarnContext(ctx, "failed to refresh CORS origins", "error", err)
+	}
+}
+
+// normalizeOrigin checks that s is a bare http or https origin and returns it
+// in the lowercase form browsers send
+func normalizeOrigin(s string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("must be an http or https origin such as https://example.com")
+	}
+	if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return "", errors.New("must not have a path, query or credentials")
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
@@ -0,0 +1,555 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/dlock"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/httpclient"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// hookDispatchLockName is the distributed lock held while an instance delivers hooks
+const hookDispatchLockName = "hook-dispatch"
+
+// hookBatchSize is the most change feed entries delivered to a subscription per tick
+const hookBatchSize = 100
+
+// hookSampleCount is the most sample deliveries returned for a subscription
+const hookSampleCount = 3
+
+// hookMaxResponseBytes bounds how much of a hook response is read before the
+// connection is reused
+const hookMaxResponseBytes = 64 << 10
+
+// hookPruneInterval is how often delivery attempts past their retention are deleted
+const hookPruneInterval = time.Hour
+
+// errHookGone is returned when a hook target answers 410 Gone, which asks
+// for the subscription to be removed
+var errHookGone = errors.New("hook target is gone")
+
+// HookService manages REST hook subscriptions and delivers a user's todo
+// changes to them. Deliveries follow the change feed, so a target that is
+// down receives the changes it missed once it recovers, up to maxFailures
+// consecutive failed attempts, after which it is unsubscribed. Every attempt
+// is kept for the retention period so users can inspect and redeliver it.
+type HookService struct {
+	hookRepo    repository.HookRepository
+	todoRepo    repository.TodoRepository
+	client      *httpclient.Client
+	idGen       *idgen.Generator
+	maxFailures int
+	retention   time.Duration
+	logger      *slog.Logger
+
+	// lastPrune is only touched by the dispatch loop
+	lastPrune time.Time
+}
+
+// NewHookService creates a new HookService. retention is how long delivery
+// attempts are kept.
+func NewHookService(
+	hookRepo repository.HookRepository,
+	todoRepo repository.TodoRepository,
+	client *httpclient.Client,
+	idGen *idgen.Generator,
+	maxFailures int,
+	retention time.Duration,
+	logger *slog.Logger,
+) *HookService {
+	return &HookService{
+		hookRepo:    hookRepo,
+		todoRepo:    todoRepo,
+		client:      client,
+		idGen:       idGen,
+		maxFailures: maxFailures,
+		retention:   retention,
+		logger:      logger,
+	}
+}
+
+// Subscribe subscribes a hook to a user's changes from now on
+func (s *HookService) Subscribe(ctx context.Context, userID uuid.UUID, req *domain.CreateHookRequest) (*domain.HookSubscription, error) {
+	events := req.SubscribedEvents()
+	if len(events) == 0 {
+		return nil, apperror.ErrValidation.WithDetails("events: at least one event is required")
+	}
+
+	targetURL, err := normalizeHookURL(req.TargetURL)
+	if err != nil {
+		return nil, apperror.ErrValidation.WithDetails("target_url: " + err.Error())
+	}
+
+	payloadVersion := req.PayloadVersion
+	if payloadVersion == 0 {
+		payloadVersion = domain.HookPayloadV1
+	}
+
+	count, err := s.hookRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "count hook subscriptions", "user_id", userID))
+	}
+	if count >= domain.MaxHookSubscriptions {
+		return nil, apperror.ErrConflict.WithDetails(
+			fmt.Sprintf("hooks: at most %d subscriptions are allowed per user", domain.MaxHookSubscriptions))
+	}
+
+	sub := &domain.HookSubscription{
+		ID:             s.idGen.New(),
+		UserID:         userID,
+		Events:         events,
+		PayloadVersion: payloadVersion,
+		TargetURL:      targetURL,
+	}
+	if err := s.hookRepo.Create(ctx, sub); err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create hook subscription", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "hook subscribed", "user_id", userID, "hook_id", sub.ID, "events", sub.Events, "payload_version", sub.PayloadVersion)
+
+	return sub, nil
+}
+
+// List retrieves the hook subscriptions of a user
+func (s *HookService) List(ctx context.Context, userID uuid.UUID) ([]*domain.HookSubscription, error) {
+	subs, err := s.hookRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list hook subscriptions", "user_id", userID))
+	}
+	return subs, nil
+}
+
+// Unsubscribe removes a hook subscription of a user
+func (s *HookService) Unsubscribe(ctx context.Context, userID, hookID uuid.UUID) error {
+	if err := s.hookRepo.Delete(ctx, hookID, userID); err != nil {
+		// Other users' subscriptions are reported as missing rather than forbidden
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return apperror.NewAppError(
+				apperror.CodeNotFound,
+				"Hook not found",
+				http.StatusNotFound,
+				fmt.Errorf("hook with ID %s not found", hookID),
+			)
+		}
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "delete hook subscription", "hook_id", hookID))
+	}
+
+	s.logger.InfoContext(ctx, "hook unsubscribed", "user_id", userID, "hook_id", hookID)
+
+	return nil
+}
+
+// ListDeliveries retrieves up to limit delivery attempts of a user's
+// subscription, newest first, only the failed ones if failedOnly is set
+func (s *HookService) ListDeliveries(ctx context.Context, userID, hookID uuid.UUID, failedOnly bool, limit int) ([]*domain.HookDeliveryAttempt, error) {
+	if limit < 1 || limit > MaxPageLimit {
+		return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("limit: must be between 1 and %d", MaxPageLimit))
+	}
+
+	if _, err := s.getSubscription(ctx, userID, hookID); err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.hookRepo.ListDeliveries(ctx, hookID, userID, failedOnly, limit)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list hook deliveries", "hook_id", hookID))
+	}
+	return attempts, nil
+}
+
+// Redeliver posts the body of an earlier delivery attempt to the subscription's
+// target again and returns the new attempt. It does not count toward the
+// subscription's failures, and a 410 Gone response does not unsubscribe it.
+func (s *HookService) Redeliver(ctx context.Context, userID, hookID, deliveryID uuid.UUID) (*domain.HookDeliveryAttempt, error) {
+	sub, err := s.getSubscription(ctx, userID, hookID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := s.hookRepo.GetDelivery(ctx, deliveryID, hookID, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get hook delivery", "delivery_id", deliveryID))
+	}
+	if original == nil {
+		return nil, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Delivery not found",
+			http.StatusNotFound,
+			fmt.Errorf("hook delivery with ID %s not found", deliveryID),
+		)
+	}
+
+	attempt := &domain.HookDeliveryAttempt{
+		HookID:         sub.ID,
+		UserID:         userID,
+		Event:          original.Event,
+		Seq:            original.Seq,
+		PayloadVersion: original.PayloadVersion,
+		Request:        original.Request,
+		Redelivery:     true,
+	}
+	err = s.send(ctx, sub, attempt)
+	if err != nil && attempt.ID == uuid.Nil {
+		if errors.Is(err, httpclient.ErrCircuitOpen) {
+			return nil, apperror.NewAppError(
+				apperror.CodeUnavailable,
+				"Hook target is failing, please retry later",
+				http.StatusServiceUnavailable,
+				err,
+			)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "redeliver hook delivery", "delivery_id", deliveryID))
+	}
+
+	// The attempt holds a generic description; the cause is only logged
+	s.logger.InfoContext(ctx, "hook delivery redelivered", "user_id", userID, "hook_id", hookID, "delivery_id", deliveryID, "succeeded", attempt.Succeeded, "error", err)
+
+	return attempt, nil
+}
+
+// getSubscription retrieves a subscription of a user, reporting other users'
+// subscriptions as missing
+func (s *HookService) getSubscription(ctx context.Context, userID, hookID uuid.UUID) (*domain.HookSubscription, error) {
+	sub, err := s.hookRepo.GetByID(ctx, hookID, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get hook subscription", "hook_id", hookID))
+	}
+	if sub == nil {
+		return nil, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Hook not found",
+			http.StatusNotFound,
+			fmt.Errorf("hook with ID %s not found", hookID),
+		)
+	}
+	return sub, nil
+}
+
+// Samples returns example deliveries of an event in a payload version, built
+// from the user's most recent todos, or from a made-up todo if they have none,
+// so integrations can map fields before any change happens
+func (s *HookService) Samples(ctx context.Context, userID uuid.UUID, event domain.HookEvent, version int) ([]any, error) {
+	todos, err := s.todoRepo.ListPageByUserID(ctx, userID, nil, hookSampleCount)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todos for hook samples", "user_id", userID))
+	}
+	if len(todos) == 0 {
+		todos = []*domain.Todo{sampleHookTodo(userID)}
+	}
+
+	samples := make([]any, 0, len(todos))
+	for _, todo := range todos {
+		sample := &domain.HookDelivery{
+			Event:      event,
+			TodoID:     todo.ID,
+			OccurredAt: todo.UpdatedAt,
+		}
+		if event == domain.HookEventTodoChanged {
+			sample.Todo = todo
+		}
+		samples = append(samples, sample.Payload(version))
+	}
+
+	return samples, nil
+}
+
+// RunDispatch delivers new changes to every subscription each interval until
+// ctx is cancelled. When locker is not nil, only one instance delivers at a
+// time and the others skip the tick.
+func (s *HookService) RunDispatch(ctx context.Context, interval time.Duration, locker *dlock.Locker) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.dispatchTick(ctx, locker)
+		}
+	}
+}
+
+// dispatchTick runs one scheduled delivery
+func (s *HookService) dispatchTick(ctx context.Context, locker *dlock.Locker) {
+	if locker != nil {
+		lock, err := locker.TryAcquire(ctx, hookDispatchLockName)
+		if errors.Is(err, dlock.ErrLocked) {
+			s.logger.DebugContext(ctx, "hook dispatch skipped: running on another instance")
+			return
+		}
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to lock hook dispatch", "error", err)
+			return
+		}
+		defer func() {
+			if err := lock.Release(ctx); err != nil {
+				s.logger.WarnContext(ctx, "failed to unlock hook dispatch", "error", err)
+			}
+		}()
+	}
+
+	subs, err := s.hookRepo.ListAll(ctx)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to list hook subscriptions", "error", err)
+		return
+	}
+
+	for _, sub := range subs {
+		if ctx.Err() != nil {
+			return
+		}
+		s.dispatch(ctx, sub)
+	}
+
+	if time.Since(s.lastPrune) >= hookPruneInterval {
+		s.prune(ctx)
+	}
+}
+
+// prune deletes the delivery attempts past their retention
+func (s *HookService) prune(ctx context.Context) {
+	count, err := s.hookRepo.PruneDeliveries(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to prune hook deliveries", "error", err)
+		return
+	}
+	s.lastPrune = time.Now()
+	if count > 0 {
+		s.logger.InfoContext(ctx, "hook deliveries pruned", "count", count)
+	}
+}
+
+// dispatch delivers the changes a subscription has not received yet, in
+// order, stopping at the first failure so it is retried on the next tick
+func (s *HookService) dispatch(ctx context.Context, sub *domain.HookSubscription) {
+	changes, err := s.todoRepo.ListChangesSince(ctx, sub.UserID, sub.LastSeq, hookBatchSize)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to list changes for hook", "error", err, "hook_id", sub.ID)
+		return
+	}
+
+	delivered := sub.LastSeq
+	posted := false
+	var failure error
+	for _, change := range changes {
+		if sub.Subscribes(domain.HookEventFor(change.Op)) {
+			if failure = s.post(ctx, sub, change); failure != nil {
+				break
+			}
+			posted = true
+		}
+		delivered = change.Seq
+	}
+
+	// Changes skipped before a failed delivery are scanned again next tick,
+	// so the failure count only resets when a delivery succeeds
+	if delivered > sub.LastSeq && (failure == nil || posted) {
+		if err := s.hookRepo.Advance(ctx, sub.ID, delivered); err != nil {
+			s.logger.ErrorContext(ctx, "failed to advance hook subscription", "error", err, "hook_id", sub.ID)
+			return
+		}
+	}
+
+	if failure != nil {
+		s.fail(ctx, sub, failure)
+	}
+}
+
+// fail records a failed delivery and unsubscribes the hook once its target
+// is gone or has failed maxFailures times in a row
+func (s *HookService) fail(ctx context.Context, sub *domain.HookSubscription, cause error) {
+	if ctx.Err() != nil {
+		return
+	}
+	// The target's own failures opened its circuit, and they were counted as
+	// they happened. The delivery was not sent and is tried again next tick;
+	// the trial delivery after each cooldown counts if it fails too.
+	if errors.Is(cause, httpclient.ErrCircuitOpen) {
+		s.logger.DebugContext(ctx, "hook delivery deferred: circuit open", "hook_id", sub.ID)
+		return
+	}
+
+	reason := "gone"
+	if !errors.Is(cause, errHookGone) {
+		failures, err := s.hookRepo.RecordFailure(ctx, sub.ID)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to record hook failure", "error", err, "hook_id", sub.ID)
+			return
+		}
+		s.logger.WarnContext(ctx, "hook delivery failed", "error", cause, "hook_id", sub.ID, "failures", failures)
+		if failures < s.maxFailures {
+			return
+		}
+		reason = "repeated failures"
+	}
+
+	if err := s.hookRepo.Delete(ctx, sub.ID, sub.UserID); err != nil && !errors.Is(err, repository.ErrNoRowsAffected) {
+		s.logger.ErrorContext(ctx, "failed to unsubscribe hook", "error", err, "hook_id", sub.ID)
+		return
+	}
+	s.logger.InfoContext(ctx, "hook unsubscribed automatically", "hook_id", sub.ID, "user_id", sub.UserID, "reason", reason)
+}
+
+// post sends one change to a subscription's target in its payload version
+func (s *HookService) post(ctx context.Context, sub *domain.HookSubscription, change *domain.TodoChange) error {
+	delivery := &domain.HookDelivery{
+		Event:      domain.HookEventFor(change.Op),
+		Seq:        change.Seq,
+		TodoID:     change.TodoID,
+		Todo:       change.Todo,
+		OccurredAt: change.ChangedAt,
+	}
+	body, err := json.Marshal(delivery.Payload(sub.PayloadVersion))
+	if err != nil {
+		return fmt.Errorf("failed to encode hook delivery: %w", err)
+	}
+
+	return s.send(ctx, sub, &domain.HookDeliveryAttempt{
+		HookID:         sub.ID,
+		UserID:         sub.UserID,
+		Event:          delivery.Event,
+		Seq:            change.Seq,
+		PayloadVersion: sub.PayloadVersion,
+		Request:        body,
+	})
+}
+
+// send posts the request body of attempt to a subscription's target, fills in
+// the outcome and records the attempt. Attempts that were not sent, or were
+// cut short by ctx, are not recorded and keep a nil ID. The Idempotency-Key lets targets drop a
+// delivery they already received, and lets the client retry it.
+func (s *HookService) send(ctx context.Context, sub *domain.HookSubscription, attempt *domain.HookDeliveryAttempt) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.TargetURL, bytes.NewReader(attempt.Request))
+	if err != nil {
+		return fmt.Errorf("failed to build hook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("%s:%d", sub.ID, attempt.Seq))
+	req.Header.Set("X-Hook-ID", sub.ID.String())
+	req.Header.Set("X-Hook-Event", string(attempt.Event))
+	req.Header.Set("X-Hook-Version", strconv.Itoa(attempt.PayloadVersion))
+	if attempt.Redelivery {
+		req.Header.Set("X-Hook-Redelivery", "true")
+	}
+
+	start := time.Now()
+	status, err := s.do(req)
+	attempt.DurationMS = time.Since(start).Milliseconds()
+
+	if status != 0 {
+		attempt.StatusCode = &status
+	}
+	if err != nil {
+		message := hookAttemptError(err)
+		attempt.Error = &message
+	} else {
+		attempt.Succeeded = true
+	}
+
+	// Attempts cut short by shutdown or refused by an open circuit say
+	// nothing about the target
+	if ctx.Err() != nil || errors.Is(err, httpclient.ErrCircuitOpen) {
+		return err
+	}
+
+	attempt.ID = s.idGen.New()
+	if recordErr := s.hookRepo.CreateDelivery(ctx, attempt); recordErr != nil {
+		s.logger.ErrorContext(ctx, "failed to record hook delivery", "error", recordErr, "hook_id", sub.ID)
+	}
+
+	return err
+}
+
+// hookAttemptError describes a failed attempt to the user. Connection, DNS and
+// TLS errors are reported alike, so attempts cannot be used to probe which
+// addresses the server can reach.
+func hookAttemptError(err error) string {
+	var statusErr *hookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Error()
+	}
+	return "hook target could not be reached"
+}
+
+// hookStatusError is returned when a hook target answers with a status other than 2xx
+type hookStatusError struct {
+	code int
+}
+
+func (e *hookStatusError) Error() string {
+	return fmt.Sprintf("hook target responded with status %d", e.code)
+}
+
+// Is makes a 410 Gone response match errHookGone
+func (e *hookStatusError) Is(target error) bool {
+	return target == errHookGone && e.code == http.StatusGone
+}
+
+// do sends a hook request and returns the status of the response, or 0 if
+// there was none. The error is a *hookStatusError unless the target answers
+// with 2xx.
+func (s *HookService) do(req *http.Request) (int, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, hookMaxResponseBytes))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &hookStatusError{code: resp.StatusCode}
+	}
+	return resp.StatusCode, nil
+}
+
+// sampleHookTodo is the made-up todo of sample deliveries for users without todos
+func sampleHookTodo(userID uuid.UUID) *domain.Todo {
+	description := "Milk, eggs and bread"
+	now := time.Now().UTC().Truncate(time.Second)
+	return &domain.Todo{
+		ID:          uuid.MustParse("00000000-0000-4000-8000-000000000001"),
+		UserID:      userID,
+		Title:       "Buy groceries",
+		Description: &description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// normalizeHookURL checks that s is an absolute https URL without credentials
+// that does not name a loopback or private address
+func normalizeHookURL(s string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", errors.New("must be an https URL such as https://hooks.example.com/todo")
+	}
+	if u.User != nil {
+		return "", errors.New("must not contain credentials")
+	}
+	// Host names are checked again when delivering, after they are resolved
+	host := strings.ToLower(u.Hostname())
+	if addr, err := netip.ParseAddr(host); (err == nil && !httpclient.IsPublicAddr(addr)) ||
+		host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "", errors.New("must point at a public address")
+	}
+	u.Fragment = ""
+	return u.String(), nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// NotificationService manages the in-app notification inbox. It is separate
+// from email and push delivery: features call Notify to persist a notification
+// and users read it through the inbox endpoints.
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	idGen            *idgen.Generator
+	logger           *slog.Logger
+}
+
+// NewNotificationService creates a new NotificationService
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	idGen *idgen.Generator,
+	logger *slog.Logger,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		idGen:            idGen,
+		logger:           logger,
+	}
+}
+
+// Notify adds a notification to a user's inbox. todoID is the todo the
+// notification is about, if any.
+func (s *NotificationService) Notify(ctx context.Context, userID uuid.UUID, kind domain.NotificationKind, title string, body *string, todoID *uuid.UUID) (*domain.Notification, error) {
+	notification := &domain.Notification{
+		ID:     s.idGen.New(),
+		UserID: userID,
+		Kind:   kind,
+		Title:  title,
+		Body:   body,
+		TodoID: todoID,
+	}
+
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create notification", "user_id", userID, "kind", kind))
+	}
+
+	return notification, nil
+}
+
+// List retrieves up to limit of a user's notifications, newest first, with their unread count
+func (s *NotificationService) List(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) (*domain.NotificationInbox, error) {
+	if limit < 1 || limit > MaxPageLimit {
+		return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("limit: must be between 1 and %d", MaxPageLimit))
+	}
+
+	notifications, err := s.notificationRepo.ListByUserID(ctx, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list notifications", "user_id", userID))
+	}
+
+	unread, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "count unread notifications", "user_id", userID))
+	}
+
+	return &domain.NotificationInbox{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	}, nil
+}
+
+// MarkRead marks one of a user's notifications as read
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) (*domain.Notification, error) {
+	notification, err := s.notificationRepo.MarkRead(ctx, notificationID, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "mark notification read", "notification_id", notificationID))
+	}
+
+	// Other users' notifications are reported as missing rather than forbidden
+	if notification == nil {
+		return nil, apperror.NewAppError(
+			apperror.CodeNotFound,
+			"Notification not found",
+			http.StatusNotFound,
+			fmt.Errorf("notification with ID %s not found", notificationID),
+		)
+	}
+
+	return notification, nil
+}
+
+// MarkAllRead marks every notification of a user as read and returns how many changed
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.notificationRepo.MarkAllRead(ctx, userID)
+	if err != nil {
+		return 0, apperror.ErrInternal.WithCause(errctx.Wrap(err, "mark all notifications read", "user_id", userID))
+	}
+	return count, nil
+}
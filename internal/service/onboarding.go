@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// OnboardingService tracks each user's onboarding checklist. Steps the server
+// can observe are recorded by the services that observe them; the rest are
+// reported by clients.
+type OnboardingService struct {
+	onboardingRepo repository.OnboardingRepository
+	logger         *slog.Logger
+}
+
+// NewOnboardingService creates a new OnboardingService
+func NewOnboardingService(onboardingRepo repository.OnboardingRepository, logger *slog.Logger) *OnboardingService {
+	return &OnboardingService{
+		onboardingRepo: onboardingRepo,
+		logger:         logger,
+	}
+}
+
+// Get retrieves the user's checklist progress
+func (s *OnboardingService) Get(ctx context.Context, userID uuid.UUID) (*domain.Onboarding, error) {
+	completed, err := s.onboardingRepo.ListCompleted(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list onboarding steps", "user_id", userID))
+	}
+	return domain.NewOnboarding(completed), nil
+}
+
+// Update marks steps as completed or not completed and returns the new progress
+func (s *OnboardingService) Update(ctx context.Context, userID uuid.UUID, req *domain.UpdateOnboardingRequest) (*domain.Onboarding, error) {
+	steps := make([]domain.OnboardingStep, 0, len(req.Steps))
+	for step := range req.Steps {
+		steps = append(steps, step)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+
+	var details []string
+	for _, step := range steps {
+		if !step.IsValid() {
+			details = append(details, fmt.Sprintf("steps.%s: must be one of %s", step, validOnboardingSteps()))
+		}
+	}
+	if len(details) > 0 {
+		return nil, apperror.ErrValidation.WithDetails(details...)
+	}
+
+	for _, step := range steps {
+		var err error
+		if req.Steps[step] {
+			err = s.onboardingRepo.Complete(ctx, userID, step)
+		} else {
+			err = s.onboardingRepo.Reset(ctx, userID, step)
+		}
+		if err != nil {
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "update onboarding step", "user_id", userID, "step", step))
+		}
+	}
+
+	return s.Get(ctx, userID)
+}
+
+// Record marks a step as completed on behalf of another service. Failures are
+// logged rather than returned so they never fail the request that triggered them.
+func (s *OnboardingService) Record(ctx context.Context, userID uuid.UUID, step domain.OnboardingStep) {
+	if err := s.onboardingRepo.Complete(ctx, userID, step); err != nil {
+		s.logger.ErrorContext(ctx, "failed to record onboarding step", "error", err, "user_id", userID, "step", step)
+	}
+}
+
+// validOnboardingSteps lists the checklist steps for validation messages
+func validOnboardingSteps() string {
+	names := make([]string, 0, len(domain.OnboardingSteps))
+	for _, step := range domain.OnboardingSteps {
+		names = append(names, string(step))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// Usage reports cover defaultReportPeriods periods up to today unless a range
+// is given, and never more than maxReportPeriods
+const (
+	defaultReportPeriods = 30
+	maxReportPeriods     = 366
+)
+
+// ReportService produces usage and growth reports for administrators
+type ReportService struct {
+	reportRepo repository.ReportRepository
+	logger     *slog.Logger
+}
+
+// NewReportService creates a new ReportService
+func NewReportService(reportRepo repository.ReportRepository, logger *slog.Logger) *ReportService {
+	return &ReportService{
+		reportRepo: reportRepo,
+		logger:     logger,
+	}
+}
+
+// Usage builds a usage report with one entry per period in the requested range
+func (s *ReportService) Usage(ctx context.Context, req *domain.UsageReportRequest) (*domain.UsageReport, error) {
+	period := req.Period
+	if period == "" {
+		period = domain.ReportPeriodDaily
+	}
+
+	// Both ends are inclusive, so the range ends where the period after To starts
+	last := period.Start(time.Now().UTC())
+	if req.To != "" {
+		to, err := time.Parse(time.DateOnly, req.To)
+		if err != nil {
+			return nil, apperror.ErrValidation.WithDetails("to: must be a date in YYYY-MM-DD format")
+		}
+		last = period.Start(to)
+	}
+
+	first := last
+	if req.From != "" {
+		from, err := time.Parse(time.DateOnly, req.From)
+		if err != nil {
+			return nil, apperror.ErrValidation.WithDetails("from: must be a date in YYYY-MM-DD format")
+		}
+		first = period.Start(from)
+		if first.After(last) {
+			return nil, apperror.ErrValidation.WithDetails("from: must not be after to")
+		}
+	} else {
+		for i := 1; i < defaultReportPeriods; i++ {
+			first = period.Start(first.AddDate(0, 0, -1))
+		}
+	}
+
+	var starts []time.Time
+	for start := first; !start.After(last); start = period.Next(start) {
+		if len(starts) == maxReportPeriods {
+			return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("from: a report covers at most %d periods", maxReportPeriods))
+		}
+		starts = append(starts, start)
+	}
+	end := period.Next(last)
+
+	counted, err := s.reportRepo.Usage(ctx, period, first, end)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "usage report", "period", period, "from", first, "to", end))
+	}
+
+	// Periods without activity are reported with zero counts
+	byStart := make(map[time.Time]*domain.UsagePeriod, len(counted))
+	for _, p := range counted {
+		byStart[p.Start] = p
+	}
+
+	report := &domain.UsageReport{
+		Period:  period,
+		From:    first,
+		To:      end,
+		Periods: make([]*domain.UsagePeriod, len(starts)),
+	}
+	for i, start := range starts {
+		if p, ok := byStart[start]; ok {
+			report.Periods[i] = p
+		} else {
+			report.Periods[i] = &domain.UsagePeriod{Start: start}
+		}
+	}
+
+	return report, nil
+}
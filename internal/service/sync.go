@@ -0,0 +1,481 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+// Conflict reasons and resolutions reported to sync clients
+const (
+	conflictModifiedOnServer = "modified_on_server"
+	conflictDeletedOnServer  = "deleted_on_server"
+	conflictForbidden        = "forbidden"
+	conflictMissingTitle     = "missing_title"
+	conflictInvalidID        = "invalid_id"
+	conflictInvalidContent   = "invalid_content"
+	conflictContentTooLong   = "content_too_long"
+
+	resolutionServerWins = "server_wins"
+	resolutionClientWins = "client_wins"
+	resolutionMerged     = "merged"
+	resolutionRejected   = "rejected"
+)
+
+// SyncService handles delta synchronization for offline-capable clients
+type SyncService struct {
+	todoRepo     repository.TodoRepository
+	userRepo     repository.UserRepository
+	onboarding   *OnboardingService
+	kpis         *metrics.KPIs
+	limits       domain.TodoLimits
+	pollInterval time.Duration
+	logger       *slog.Logger
+}
+
+// NewSyncService creates a new SyncService. limits bound the content of
+// client changes, and pollInterval is how often a waiting Changes call looks
+// for new changes.
+func NewSyncService(
+	todoRepo repository.TodoRepository,
+	userRepo repository.UserRepository,
+	onboarding *OnboardingService,
+	kpis *metrics.KPIs,
+	limits domain.TodoLimits,
+	pollInterval time.Duration,
+	logger *slog.Logger,
+) *SyncService {
+	return &SyncService{
+		todoRepo:     todoRepo,
+		userRepo:     userRepo,
+		onboarding:   onboarding,
+		kpis:         kpis,
+		limits:       limits,
+		pollInterval: pollInterval,
+		logger:       logger,
+	}
+}
+
+// Sync applies the client's local changes, resolving conflicts with server-side
+// changes made since the client's sync token according to the requested policy,
+// and returns every server-side change since that token along with a new token.
+// The batch is applied in one transaction.
+func (s *SyncService) Sync(ctx context.Context, userID uuid.UUID, req *domain.SyncRequest) (*domain.SyncResponse, error) {
+	since, err := decodeSyncToken(req.SyncToken)
+	if err != nil {
+		return nil, err
+	}
+	// A client without a token has not seen any server state to conflict with
+	hasToken := req.SyncToken != ""
+
+	policy := req.Policy
+	if policy == "" {
+		policy = domain.SyncPolicyServerWins
+	}
+
+	requireEncrypted, err := e2eEnabled(ctx, s.userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		resp  *domain.SyncResponse
+		stats syncStats
+	)
+	err = s.todoRepo.InTx(ctx, func(todoRepo repository.TodoRepository) error {
+		// No other change of the user commits until the batch is applied, so
+		// conflicts are judged against the latest server state
+		if _, err := todoRepo.LockChanges(ctx, userID); err != nil {
+			return apperror.ErrInternal.WithCause(errctx.Wrap(err, "lock todo changes for sync", "user_id", userID))
+		}
+
+		// Todos changed or deleted on the server since the token conflict with client changes
+		serverOps := make(map[uuid.UUID]domain.SyncOp)
+		if hasToken {
+			feed, err := readFeed(ctx, todoRepo, userID, since)
+			if err != nil {
+				return err
+			}
+			for _, change := range feed {
+				serverOps[change.TodoID] = change.Op
+			}
+		}
+
+		conflicts := []domain.SyncConflict{}
+		for _, change := range req.Changes {
+			conflict, err := s.apply(ctx, todoRepo, userID, policy, requireEncrypted, change, serverOps[change.ID], &stats)
+			if err != nil {
+				return err
+			}
+			if conflict != nil {
+				conflicts = append(conflicts, *conflict)
+			}
+		}
+
+		// Read back everything that changed since the token, including the client's own writes
+		changes, err := changesSince(ctx, todoRepo, userID, since, hasToken)
+		if err != nil {
+			return err
+		}
+
+		resp = &domain.SyncResponse{
+			SyncToken: changes.SyncToken,
+			Changes:   changes.Changes,
+			Deleted:   changes.Deleted,
+			Conflicts: conflicts,
+		}
+		return nil
+	})
+	if err != nil {
+		var appErr *apperror.AppError
+		if errors.As(err, &appErr) {
+			return nil, appErr
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "sync transaction", "user_id", userID))
+	}
+
+	// Recorded once the changes are committed
+	for i := 0; i < stats.created; i++ {
+		s.kpis.RecordTodoCreated()
+	}
+	for i := 0; i < stats.completed; i++ {
+		s.kpis.RecordTodoCompleted()
+	}
+	if stats.created > 0 {
+		s.onboarding.Record(ctx, userID, domain.OnboardingStepCreatedFirstTodo)
+	}
+
+	s.logger.InfoContext(ctx, "sync completed",
+		"user_id", userID,
+		"client_changes", len(req.Changes),
+		"server_changes", len(resp.Changes),
+		"deleted", len(resp.Deleted),
+		"conflicts", len(resp.Conflicts),
+		"policy", policy,
+	)
+
+	return resp, nil
+}
+
+// syncStats counts the todos a sync created and completed
+type syncStats struct {
+	created   int
+	completed int
+}
+
+// Changes returns the server-side changes since a sync token without applying
+// any. When there are none, it waits up to wait for some to happen, so clients
+// that cannot keep a stream open still learn of changes promptly. An empty
+// result keeps the token the client sent. Without a token, every todo is a change.
+func (s *SyncService) Changes(ctx context.Context, userID uuid.UUID, token string, wait time.Duration) (*domain.ChangesResponse, error) {
+	since, err := decodeSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+	hasToken := token != ""
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		changes, err := changesSince(ctx, s.todoRepo, userID, since, hasToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes.Changes) > 0 || len(changes.Deleted) > 0 {
+			return changes, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			// The client went away; nobody reads the response
+			return changes, nil
+		case <-deadline.C:
+			return changes, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Feed returns up to limit entries of the user's change feed after the
+// sequence number since. Each todo appears once, with its latest change, so
+// a client that applies the entries in order and resumes from NextSeq sees
+// every change exactly once.
+func (s *SyncService) Feed(ctx context.Context, userID uuid.UUID, since int64, limit int) (*domain.ChangeFeed, error) {
+	// Fetch one extra entry to learn whether there is another page
+	changes, err := s.todoRepo.ListChangesSince(ctx, userID, since, limit+1)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todo changes", "user_id", userID, "since", since))
+	}
+
+	feed := &domain.ChangeFeed{
+		Changes: changes,
+		NextSeq: since,
+	}
+	if len(changes) > limit {
+		feed.Changes = changes[:limit]
+		feed.HasMore = true
+	}
+	if n := len(feed.Changes); n > 0 {
+		feed.NextSeq = feed.Changes[n-1].Seq
+	}
+
+	return feed, nil
+}
+
+// feedPageSize is how many change feed entries a sync reads per query
+const feedPageSize = 1000
+
+// changesSince lists the todos changed and deleted after the change feed
+// position since, and the token of the last change returned. Without a token
+// it lists every todo, as the feed does not reach back to todos that have not
+// changed since it was introduced.
+func changesSince(ctx context.Context, todoRepo repository.TodoRepository, userID uuid.UUID, since int64, hasToken bool) (*domain.ChangesResponse, error) {
+	if !hasToken {
+		// Take the position first; changes that commit while the todos are
+		// listed are sent again on the next sync rather than missed
+		seq, err := todoRepo.LastChangeSeq(ctx, userID)
+		if err != nil {
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get last todo change for sync", "user_id", userID))
+		}
+		todos, err := todoRepo.ListByUserID(ctx, userID)
+		if err != nil {
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todos for sync", "user_id", userID))
+		}
+		return &domain.ChangesResponse{
+			SyncToken: domain.EncodeSyncToken(seq),
+			Changes:   todos,
+			Deleted:   []uuid.UUID{},
+		}, nil
+	}
+
+	feed, err := readFeed(ctx, todoRepo, userID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &domain.ChangesResponse{
+		SyncToken: domain.EncodeSyncToken(since),
+		Changes:   []*domain.Todo{},
+		Deleted:   []uuid.UUID{},
+	}
+	for _, change := range feed {
+		switch {
+		case change.Op == domain.SyncOpDelete:
+			resp.Deleted = append(resp.Deleted, change.TodoID)
+		case change.Todo != nil:
+			resp.Changes = append(resp.Changes, change.Todo)
+		}
+	}
+	if n := len(feed); n > 0 {
+		resp.SyncToken = domain.EncodeSyncToken(feed[n-1].Seq)
+	}
+
+	return resp, nil
+}
+
+// readFeed returns every entry of the user's change feed after since
+func readFeed(ctx context.Context, todoRepo repository.TodoRepository, userID uuid.UUID, since int64) ([]*domain.TodoChange, error) {
+	var feed []*domain.TodoChange
+	for {
+		page, err := todoRepo.ListChangesSince(ctx, userID, since, feedPageSize)
+		if err != nil {
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todo changes for sync", "user_id", userID, "since", since))
+		}
+		feed = append(feed, page...)
+		if len(page) < feedPageSize {
+			return feed, nil
+		}
+		since = page[len(page)-1].Seq
+	}
+}
+
+// decodeSyncToken parses a client's sync token
+func decodeSyncToken(token string) (int64, error) {
+	since, err := domain.DecodeSyncToken(token)
+	if err != nil {
+		return 0, apperror.NewAppError(
+			apperror.CodeBadRequest,
+			"Invalid sync token",
+			http.StatusBadRequest,
+			err,
+		)
+	}
+	return since, nil
+}
+
+// apply applies a single client change and returns the conflict it caused, if any.
+// requireEncrypted rejects plaintext content for users in end-to-end encryption mode,
+// and serverOp is the todo's change on the server since the client's token, if any.
+func (s *SyncService) apply(
+	ctx context.Context,
+	todoRepo repository.TodoRepository,
+	userID uuid.UUID,
+	policy domain.SyncPolicy,
+	requireEncrypted bool,
+	change domain.SyncChange,
+	serverOp domain.SyncOp,
+	stats *syncStats,
+) (*domain.SyncConflict, error) {
+	current, err := todoRepo.GetByID(ctx, change.ID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get todo for sync", "todo_id", change.ID))
+	}
+
+	if current != nil && current.UserID != userID {
+		s.logger.WarnContext(ctx, "sync change targets todo owned by another user",
+			"user_id", userID, "todo_id", change.ID, "owner_id", current.UserID)
+		return &domain.SyncConflict{ID: change.ID, Reason: conflictForbidden, Resolution: resolutionRejected}, nil
+	}
+
+	modifiedOnServer := current != nil && serverOp != ""
+
+	if change.Op == domain.SyncOpDelete {
+		if current == nil {
+			return nil, nil
+		}
+		if modifiedOnServer && policy != domain.SyncPolicyClientWins {
+			return &domain.SyncConflict{
+				ID:         change.ID,
+				Reason:     conflictModifiedOnServer,
+				Resolution: resolutionServerWins,
+				Server:     current,
+			}, nil
+		}
+		if err := todoRepo.Delete(ctx, change.ID); err != nil {
+			return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "delete todo during sync", "todo_id", change.ID))
+		}
+		if modifiedOnServer {
+			return &domain.SyncConflict{ID: change.ID, Reason: conflictModifiedOnServer, Resolution: resolutionClientWins}, nil
+		}
+		return nil, nil
+	}
+
+	if current == nil {
+		return s.create(ctx, todoRepo, userID, policy, requireEncrypted, change, serverOp == domain.SyncOpDelete, stats)
+	}
+
+	var conflict *domain.SyncConflict
+	if modifiedOnServer {
+		switch policy {
+		case domain.SyncPolicyServerWins:
+			return &domain.SyncConflict{
+				ID:         change.ID,
+				Reason:     conflictModifiedOnServer,
+				Resolution: resolutionServerWins,
+				Server:     current,
+			}, nil
+		case domain.SyncPolicyMerge:
+			conflict = &domain.SyncConflict{ID: change.ID, Reason: conflictModifiedOnServer, Resolution: resolutionMerged}
+		default:
+			conflict = &domain.SyncConflict{ID: change.ID, Reason: conflictModifiedOnServer, Resolution: resolutionClientWins}
+		}
+	}
+
+	wasCompleted := current.Completed
+
+	if reason := s.contentConflict(current, change, requireEncrypted); reason != "" {
+		return &domain.SyncConflict{ID: change.ID, Reason: reason, Resolution: resolutionRejected}, nil
+	}
+	if change.Completed != nil {
+		// When merging, a completion made on either side is kept
+		if policy == domain.SyncPolicyMerge && modifiedOnServer {
+			current.Completed = current.Completed || *change.Completed
+		} else {
+			current.Completed = *change.Completed
+		}
+	}
+
+	if err := todoRepo.Update(ctx, current); err != nil {
+		// Deleted by another request after it was read
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return &domain.SyncConflict{ID: change.ID, Reason: conflictDeletedOnServer, Resolution: resolutionServerWins}, nil
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "update todo during sync", "todo_id", change.ID))
+	}
+
+	if current.Completed && !wasCompleted {
+		stats.completed++
+	}
+
+	return conflict, nil
+}
+
+// create creates a todo reported by the client that does not exist on the server
+func (s *SyncService) create(
+	ctx context.Context,
+	todoRepo repository.TodoRepository,
+	userID uuid.UUID,
+	policy domain.SyncPolicy,
+	requireEncrypted bool,
+	change domain.SyncChange,
+	deletedOnServer bool,
+	stats *syncStats,
+) (*domain.SyncConflict, error) {
+	var conflict *domain.SyncConflict
+	if deletedOnServer {
+		if policy == domain.SyncPolicyServerWins {
+			return &domain.SyncConflict{ID: change.ID, Reason: conflictDeletedOnServer, Resolution: resolutionServerWins}, nil
+		}
+		resolution := resolutionClientWins
+		if policy == domain.SyncPolicyMerge {
+			resolution = resolutionMerged
+		}
+		conflict = &domain.SyncConflict{ID: change.ID, Reason: conflictDeletedOnServer, Resolution: resolution}
+	}
+
+	if v := change.ID.Version(); v != 4 && v != 7 {
+		return &domain.SyncConflict{ID: change.ID, Reason: conflictInvalidID, Resolution: resolutionRejected}, nil
+	}
+
+	if change.Title == nil && len(change.TitleCiphertext) == 0 {
+		return &domain.SyncConflict{ID: change.ID, Reason: conflictMissingTitle, Resolution: resolutionRejected}, nil
+	}
+
+	todo := &domain.Todo{
+		ID:     change.ID,
+		UserID: userID,
+	}
+	if reason := s.contentConflict(todo, change, requireEncrypted); reason != "" {
+		return &domain.SyncConflict{ID: change.ID, Reason: reason, Resolution: resolutionRejected}, nil
+	}
+	if change.Completed != nil {
+		todo.Completed = *change.Completed
+	}
+
+	if err := todoRepo.Create(ctx, todo); err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create todo during sync", "todo_id", change.ID))
+	}
+	stats.created++
+
+	return conflict, nil
+}
+
+// contentConflict applies the content of change to todo and returns the reason
+// to reject the result, or "" if it is valid. Plaintext writes are invalid when
+// requireEncrypted is set, and changed content must be within the size limits.
+func (s *SyncService) contentConflict(todo *domain.Todo, change domain.SyncChange, requireEncrypted bool) string {
+	before := *todo
+	if details := todo.ApplyContent(change.Content()); len(details) > 0 {
+		return conflictInvalidContent
+	}
+	changed := !sameContent(&before, todo)
+	if len(todo.CheckContent(requireEncrypted && changed)) > 0 {
+		return conflictInvalidContent
+	}
+	if changed && len(s.limits.Check(todo)) > 0 {
+		return conflictContentTooLong
+	}
+	return ""
+}
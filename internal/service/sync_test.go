@@ -0,0 +1,190 @@
+//go:build integration
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/repository"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/service"
+	"github.com/whauzan/todo-api/internal/testutil"
+)
+
+func newSyncService(t *testing.T, db *testutil.Database, todoRepo repository.TodoRepository) *service.SyncService {
+	t.Helper()
+
+	logger := testutil.Logger()
+	onboarding := service.NewOnboardingService(postgres.NewOnboardingRepository(db.Pool), logger)
+	limits := domain.TodoLimits{MaxTitleLength: domain.MaxTitleLength, MaxDescriptionLength: domain.MaxDescriptionLength}
+	return service.NewSyncService(todoRepo, postgres.NewUserRepository(db.Pool), onboarding, nil, limits, time.Second, logger)
+}
+
+func completeChange(id uuid.UUID) domain.SyncChange {
+	completed := true
+	return domain.SyncChange{ID: id, Op: domain.SyncOpUpsert, Completed: &completed}
+}
+
+func TestSyncTokenFollowsCommitOrder(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	syncService := newSyncService(t, db, postgres.NewTodoRepository(db.Pool))
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "sync-order")
+	todos := testutil.SeedTodos(t, db, user.ID, "Slow writer", "Fast writer")
+
+	first, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{})
+	if err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+
+	// A transaction that started earlier commits after a later one: its
+	// updated_at is older than the other write, but it is still synced
+	slow, err := db.Pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	defer slow.Rollback(ctx)
+	if _, err := slow.Exec(ctx, "SELECT NOW()"); err != nil {
+		t.Fatalf("start slow transaction: %v", err)
+	}
+
+	second, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{
+		SyncToken: first.SyncToken,
+		Changes:   []domain.SyncChange{completeChange(todos[1].ID)},
+	})
+	if err != nil {
+		t.Fatalf("second sync: %v", err)
+	}
+
+	if _, err := slow.Exec(ctx, "UPDATE todos SET title = 'Slow writer, edited' WHERE id = $1", todos[0].ID); err != nil {
+		t.Fatalf("slow update: %v", err)
+	}
+	if err := slow.Commit(ctx); err != nil {
+		t.Fatalf("slow commit: %v", err)
+	}
+
+	third, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{SyncToken: second.SyncToken})
+	if err != nil {
+		t.Fatalf("third sync: %v", err)
+	}
+	if len(third.Changes) != 1 || third.Changes[0].ID != todos[0].ID || third.Changes[0].Title != "Slow writer, edited" {
+		t.Fatalf("third sync changes = %+v, want the slow writer's edit", third.Changes)
+	}
+
+	// Nothing changed since
+	fourth, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{SyncToken: third.SyncToken})
+	if err != nil {
+		t.Fatalf("fourth sync: %v", err)
+	}
+	if len(fourth.Changes) != 0 || len(fourth.Deleted) != 0 || fourth.SyncToken != third.SyncToken {
+		t.Fatalf("fourth sync = %+v, want no changes and the same token", fourth)
+	}
+}
+
+func TestSyncWithoutTokenHasNoConflicts(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	syncService := newSyncService(t, db, postgres.NewTodoRepository(db.Pool))
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "sync-first")
+	todos := testutil.SeedTodos(t, db, user.ID, "Existing", "Untouched")
+
+	resp, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{
+		Policy:  domain.SyncPolicyServerWins,
+		Changes: []domain.SyncChange{completeChange(todos[0].ID)},
+	})
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(resp.Conflicts) != 0 {
+		t.Fatalf("conflicts = %+v, want none without a token", resp.Conflicts)
+	}
+	if len(resp.Changes) != len(todos) {
+		t.Fatalf("got %d todos, want every todo on a first sync", len(resp.Changes))
+	}
+	for _, todo := range resp.Changes {
+		if todo.ID == todos[0].ID && !todo.Completed {
+			t.Fatalf("change was not applied: %+v", todo)
+		}
+	}
+
+	// With a token, a server change since then conflicts
+	title := "Edited on the server"
+	if _, err := db.Pool.Exec(ctx, "UPDATE todos SET title = $1 WHERE id = $2", title, todos[1].ID); err != nil {
+		t.Fatalf("server edit: %v", err)
+	}
+	resp, err = syncService.Sync(ctx, user.ID, &domain.SyncRequest{
+		SyncToken: resp.SyncToken,
+		Policy:    domain.SyncPolicyServerWins,
+		Changes:   []domain.SyncChange{completeChange(todos[1].ID)},
+	})
+	if err != nil {
+		t.Fatalf("Sync with token: %v", err)
+	}
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].ID != todos[1].ID || resp.Conflicts[0].Reason != "modified_on_server" {
+		t.Fatalf("conflicts = %+v, want the server edit", resp.Conflicts)
+	}
+}
+
+// failingUpdates fails every todo update after the first, inside transactions too
+type failingUpdates struct {
+	repository.TodoRepository
+	updates *int
+}
+
+func (r failingUpdates) InTx(ctx context.Context, fn func(repository.TodoRepository) error) error {
+	return r.TodoRepository.InTx(ctx, func(tx repository.TodoRepository) error {
+		return fn(failingUpdates{TodoRepository: tx, updates: r.updates})
+	})
+}
+
+func (r failingUpdates) Update(ctx context.Context, todo *domain.Todo) error {
+	*r.updates++
+	if *r.updates > 1 {
+		return errors.New("injected failure")
+	}
+	return r.TodoRepository.Update(ctx, todo)
+}
+
+func TestSyncAppliesBatchAtomically(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	var updates int
+	syncService := newSyncService(t, db, failingUpdates{TodoRepository: postgres.NewTodoRepository(db.Pool), updates: &updates})
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "sync-atomic")
+	todos := testutil.SeedTodos(t, db, user.ID, "First", "Second")
+	created := uuid.New()
+	title := "Created offline"
+
+	_, err := syncService.Sync(ctx, user.ID, &domain.SyncRequest{
+		Changes: []domain.SyncChange{
+			{ID: created, Op: domain.SyncOpUpsert, Title: &title},
+			completeChange(todos[0].ID),
+			completeChange(todos[1].ID),
+		},
+	})
+	wantStatus(t, err, http.StatusInternalServerError)
+
+	// Neither the create nor the first update survived the failed batch
+	repo := postgres.NewTodoRepository(db.Pool)
+	if todo, err := repo.GetByID(ctx, created); err != nil || todo != nil {
+		t.Fatalf("created todo = %+v, %v; want it rolled back", todo, err)
+	}
+	for _, seeded := range todos {
+		todo, err := repo.GetByID(ctx, seeded.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if todo.Completed {
+			t.Fatalf("todo %q was completed by a failed sync", todo.Title)
+		}
+	}
+}
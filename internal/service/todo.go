@@ -1,68 +1,236 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/whauzan/todo-api/internal/domain"
 	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/pkg/metrics"
 	"github.com/whauzan/todo-api/internal/repository"
 )
 
+const (
+	// DefaultPageLimit is the page size used when a paginated list request has no limit
+	DefaultPageLimit = 50
+	// MaxPageLimit is the largest page size a client may request
+	MaxPageLimit = 100
+
+	// UndoWindow is how long a bulk completion can be undone
+	UndoWindow = time.Hour
+)
+
 // TodoService handles todo business logic
 type TodoService struct {
-	todoRepo repository.TodoRepository
-	logger   *slog.Logger
+	todoRepo   repository.TodoRepository
+	userRepo   repository.UserRepository
+	idGen      *idgen.Generator
+	onboarding *OnboardingService
+	kpis       *metrics.KPIs
+	limits     domain.TodoLimits
+	logger     *slog.Logger
 }
 
-// NewTodoService creates a new TodoService
+// NewTodoService creates a new TodoService. limits bound the plaintext
+// content of created and changed todos.
 func NewTodoService(
 	todoRepo repository.TodoRepository,
+	userRepo repository.UserRepository,
+	idGen *idgen.Generator,
+	onboarding *OnboardingService,
+	kpis *metrics.KPIs,
+	limits domain.TodoLimits,
 	logger *slog.Logger,
 ) *TodoService {
 	return &TodoService{
-		todoRepo: todoRepo,
-		logger:   logger,
+		todoRepo:   todoRepo,
+		userRepo:   userRepo,
+		idGen:      idGen,
+		onboarding: onboarding,
+		kpis:       kpis,
+		limits:     limits,
+		logger:     logger,
 	}
 }
 
-// Create creates a new todo
-func (s *TodoService) Create(ctx context.Context, userID uuid.UUID, req *domain.CreateTodoRequest) (*domain.Todo, error) {
+// Create creates a new todo.
+// When the request carries a client-generated ID that already exists with
+// identical content, the existing todo is returned and created is false.
+func (s *TodoService) Create(ctx context.Context, userID uuid.UUID, req *domain.CreateTodoRequest) (*domain.Todo, bool, error) {
+	id := s.idGen.New()
+	if req.ID != nil {
+		if v := req.ID.Version(); v != 4 && v != 7 {
+			return nil, false, apperror.ErrValidation.WithDetails("id: must be a UUID version 4 or 7")
+		}
+		id = *req.ID
+	}
+
 	todo := &domain.Todo{
-		ID:          uuid.New(),
-		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
-		Completed:   false,
+		ID:        id,
+		UserID:    userID,
+		Completed: false,
+	}
+	if err := s.applyContent(ctx, userID, todo, req.Content()); err != nil {
+		return nil, false, err
+	}
+
+	if req.ID != nil {
+		// A retried create returns the todo stored by the first attempt
+		existing, err := s.findDuplicate(ctx, todo)
+		if err != nil || existing != nil {
+			return existing, false, err
+		}
 	}
 
 	if err := s.todoRepo.Create(ctx, todo); err != nil {
-		s.logger.ErrorContext(ctx, "failed to create todo", "error", err, "user_id", userID)
-		return nil, apperror.ErrInternal
+		// A concurrent retry may have inserted the same ID in the meantime
+		if req.ID != nil {
+			if existing, dupErr := s.findDuplicate(ctx, todo); dupErr != nil || existing != nil {
+				return existing, false, dupErr
+			}
+		}
+		return nil, false, apperror.ErrInternal.WithCause(errctx.Wrap(err, "create todo", "user_id", userID))
 	}
 
+	s.kpis.RecordTodoCreated()
+	s.onboarding.Record(ctx, userID, domain.OnboardingStepCreatedFirstTodo)
 	s.logger.InfoContext(ctx, "todo created successfully", "todo_id", todo.ID, "user_id", userID)
 
-	return todo, nil
+	return todo, true, nil
+}
+
+// Clone creates an open copy of a todo owned by the user, with the same
+// plaintext or encrypted content. created is false when a retried clone with
+// the same ID returns the copy made by the first attempt.
+func (s *TodoService) Clone(ctx context.Context, userID, todoID uuid.UUID, req *domain.CloneTodoRequest) (*domain.Todo, bool, error) {
+	source, err := s.GetByID(ctx, userID, todoID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return s.Create(ctx, userID, &domain.CreateTodoRequest{
+		ID:                    req.ID,
+		Title:                 source.Title,
+		Description:           source.Description,
+		TitleCiphertext:       source.TitleCiphertext,
+		DescriptionCiphertext: source.DescriptionCiphertext,
+	})
+}
+
+// findDuplicate looks up a stored todo with the client-supplied ID of todo.
+// It returns the stored todo if it was created by the same user with the same
+// content, nil if no such ID exists, and a conflict error otherwise.
+func (s *TodoService) findDuplicate(ctx context.Context, todo *domain.Todo) (*domain.Todo, error) {
+	existing, err := s.todoRepo.GetByID(ctx, todo.ID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "check for existing todo", "todo_id", todo.ID))
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.UserID != todo.UserID || !sameContent(existing, todo) {
+		return nil, apperror.ErrConflict.WithDetails("id: a different todo with this ID already exists")
+	}
+
+	s.logger.InfoContext(ctx, "duplicate todo create ignored", "todo_id", existing.ID, "user_id", todo.UserID)
+
+	return existing, nil
+}
+
+// sameContent reports whether two todos have the same plaintext or encrypted content
+func sameContent(a, b *domain.Todo) bool {
+	return a.Encrypted == b.Encrypted &&
+		a.Title == b.Title &&
+		equalStringPtr(a.Description, b.Description) &&
+		bytes.Equal(a.TitleCiphertext, b.TitleCiphertext) &&
+		bytes.Equal(a.DescriptionCiphertext, b.DescriptionCiphertext)
+}
+
+// applyContent applies a content change to todo and checks the result
+func (s *TodoService) applyContent(ctx context.Context, userID uuid.UUID, todo *domain.Todo, content domain.TodoContent) error {
+	before := *todo
+	if details := todo.ApplyContent(content); len(details) > 0 {
+		return apperror.ErrValidation.WithDetails(details...)
+	}
+	return s.checkContent(ctx, userID, &before, todo)
+}
+
+// checkContent validates the content of todo as changed from before. Plaintext
+// writes are rejected for owners in end-to-end encryption mode, but plaintext
+// todos stored before the mode was enabled can still be completed or reopened.
+// Likewise, only changed content must be within the size limits, so todos
+// stored before a limit was lowered can still be completed.
+func (s *TodoService) checkContent(ctx context.Context, userID uuid.UUID, before, todo *domain.Todo) error {
+	changed := !sameContent(before, todo)
+
+	requireEncrypted := false
+	if !todo.Encrypted && changed {
+		var err error
+		requireEncrypted, err = e2eEnabled(ctx, s.userRepo, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if details := todo.CheckContent(requireEncrypted); len(details) > 0 {
+		return apperror.ErrValidation.WithDetails(details...)
+	}
+	if changed {
+		if details := s.limits.Check(todo); len(details) > 0 {
+			return apperror.ErrValidation.WithDetails(details...)
+		}
+	}
+	return nil
+}
+
+// e2eEnabled reports whether the user is in end-to-end encryption mode
+func e2eEnabled(ctx context.Context, userRepo repository.UserRepository, userID uuid.UUID) (bool, error) {
+	user, err := userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user encryption mode", "user_id", userID))
+	}
+	if user == nil {
+		return false, apperror.ErrUnauthorized
+	}
+	return user.E2EEnabled, nil
+}
+
+// equalStringPtr reports whether two optional strings hold the same value
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// todoNotFound is returned when a todo does not exist or was deleted while being changed
+func todoNotFound(todoID uuid.UUID) *apperror.AppError {
+	return apperror.NewAppError(
+		apperror.CodeNotFound,
+		"Todo not found",
+		404,
+		fmt.Errorf("todo with ID %s not found", todoID),
+	)
 }
 
 // GetByID retrieves a todo by ID and verifies ownership
 func (s *TodoService) GetByID(ctx context.Context, userID, todoID uuid.UUID) (*domain.Todo, error) {
 	todo, err := s.todoRepo.GetByID(ctx, todoID)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to get todo by ID", "error", err, "todo_id", todoID)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get todo by ID", "todo_id", todoID))
 	}
 
 	if todo == nil {
-		return nil, apperror.NewAppError(
-			apperror.CodeNotFound,
-			"Todo not found",
-			404,
-			fmt.Errorf("todo with ID %s not found", todoID),
-		)
+		return nil, todoNotFound(todoID)
 	}
 
 	// Verify ownership
@@ -79,8 +247,7 @@ func (s *TodoService) GetByID(ctx context.Context, userID, todoID uuid.UUID) (*d
 func (s *TodoService) List(ctx context.Context, userID uuid.UUID) ([]*domain.Todo, error) {
 	todos, err := s.todoRepo.ListByUserID(ctx, userID)
 	if err != nil {
-		s.logger.ErrorContext(ctx, "failed to list todos", "error", err, "user_id", userID)
-		return nil, apperror.ErrInternal
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todos", "user_id", userID))
 	}
 
 	// Return empty slice instead of nil if no todos found
@@ -91,29 +258,149 @@ func (s *TodoService) List(ctx context.Context, userID uuid.UUID) ([]*domain.Tod
 	return todos, nil
 }
 
+// ListPage retrieves one page of a user's todos, newest first, starting after the cursor.
+// The returned page has a NextCursor when more todos follow.
+func (s *TodoService) ListPage(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) (*domain.TodoPage, error) {
+	if limit < 1 || limit > MaxPageLimit {
+		return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("limit: must be between 1 and %d", MaxPageLimit))
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	todos, err := s.todoRepo.ListPageByUserID(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todo page", "user_id", userID))
+	}
+
+	page := &domain.TodoPage{Todos: todos}
+	if len(todos) > limit {
+		page.Todos = todos[:limit]
+		last := page.Todos[limit-1]
+		page.NextCursor = &domain.TodoCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// Stream calls fn for each todo of a user as it is read from the database.
+// An error returned by fn stops the stream and is returned unchanged.
+func (s *TodoService) Stream(ctx context.Context, userID uuid.UUID, fn func(*domain.Todo) error) error {
+	var fnErr error
+	err := s.todoRepo.StreamByUserID(ctx, userID, func(todo *domain.Todo) error {
+		if err := fn(todo); err != nil {
+			fnErr = err
+			return err
+		}
+		return nil
+	})
+
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "stream todos", "user_id", userID))
+	}
+
+	return nil
+}
+
+// ListSummaries retrieves summaries of all todos for a user, without descriptions
+func (s *TodoService) ListSummaries(ctx context.Context, userID uuid.UUID) ([]*domain.TodoSummary, error) {
+	summaries, err := s.todoRepo.ListSummariesByUserID(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todo summaries", "user_id", userID))
+	}
+
+	// Return empty slice instead of nil if no todos found
+	if summaries == nil {
+		summaries = []*domain.TodoSummary{}
+	}
+
+	return summaries, nil
+}
+
+// ListSummaryPage is ListPage for todo summaries
+func (s *TodoService) ListSummaryPage(ctx context.Context, userID uuid.UUID, after *domain.TodoCursor, limit int) (*domain.TodoSummaryPage, error) {
+	if limit < 1 || limit > MaxPageLimit {
+		return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("limit: must be between 1 and %d", MaxPageLimit))
+	}
+
+	// Fetch one extra row to find out whether another page follows
+	summaries, err := s.todoRepo.ListSummaryPageByUserID(ctx, userID, after, limit+1)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list todo summary page", "user_id", userID))
+	}
+
+	page := &domain.TodoSummaryPage{Todos: summaries}
+	if len(summaries) > limit {
+		page.Todos = summaries[:limit]
+		last := page.Todos[limit-1]
+		page.NextCursor = &domain.TodoCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
+
+// StreamSummaries is Stream for todo summaries
+func (s *TodoService) StreamSummaries(ctx context.Context, userID uuid.UUID, fn func(*domain.TodoSummary) error) error {
+	var fnErr error
+	err := s.todoRepo.StreamSummariesByUserID(ctx, userID, func(summary *domain.TodoSummary) error {
+		if err := fn(summary); err != nil {
+			fnErr = err
+			return err
+		}
+		return nil
+	})
+
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "stream todo summaries", "user_id", userID))
+	}
+
+	return nil
+}
+
 // Update updates a todo
 func (s *TodoService) Update(ctx context.Context, userID, todoID uuid.UUID, req *domain.UpdateTodoRequest) (*domain.Todo, error) {
+	// Only the description can be cleared
+	var details []string
+	if req.Title.IsNull() {
+		details = append(details, "title: cannot be null")
+	}
+	if req.Completed.IsNull() {
+		details = append(details, "completed: cannot be null")
+	}
+	if len(details) > 0 {
+		return nil, apperror.ErrValidation.WithDetails(details...)
+	}
+
 	// First, get the todo and verify ownership
 	todo, err := s.GetByID(ctx, userID, todoID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
-	if req.Title != nil {
-		todo.Title = *req.Title
-	}
-	if req.Description != nil {
-		todo.Description = req.Description
+	wasCompleted := todo.Completed
+
+	// Update fields if provided; a null description clears it
+	if err := s.applyContent(ctx, userID, todo, req.Content()); err != nil {
+		return nil, err
 	}
-	if req.Completed != nil {
-		todo.Completed = *req.Completed
+	if req.Completed.Valid {
+		todo.Completed = req.Completed.Value
 	}
 
-	// Save the updated todo
+	// Save the updated todo; it may have been deleted since it was read
 	if err := s.todoRepo.Update(ctx, todo); err != nil {
-		s.logger.ErrorContext(ctx, "failed to update todo", "error", err, "todo_id", todoID)
-		return nil, apperror.ErrInternal
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, todoNotFound(todoID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "update todo", "todo_id", todoID))
+	}
+
+	if todo.Completed && !wasCompleted {
+		s.kpis.RecordTodoCompleted()
 	}
 
 	s.logger.InfoContext(ctx, "todo updated successfully", "todo_id", todoID, "user_id", userID)
@@ -121,6 +408,96 @@ func (s *TodoService) Update(ctx context.Context, userID, todoID uuid.UUID, req
 	return todo, nil
 }
 
+// Patch loads a todo owned by the user, lets apply modify it, and saves the result.
+// Errors returned by apply are passed through unchanged.
+func (s *TodoService) Patch(ctx context.Context, userID, todoID uuid.UUID, apply func(*domain.Todo) error) (*domain.Todo, error) {
+	todo, err := s.GetByID(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	wasCompleted := todo.Completed
+	before := *todo
+
+	if err := apply(todo); err != nil {
+		return nil, err
+	}
+	if err := s.checkContent(ctx, userID, &before, todo); err != nil {
+		return nil, err
+	}
+
+	if err := s.todoRepo.Update(ctx, todo); err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, todoNotFound(todoID)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "patch todo", "todo_id", todoID))
+	}
+
+	if todo.Completed && !wasCompleted {
+		s.kpis.RecordTodoCompleted()
+	}
+
+	s.logger.InfoContext(ctx, "todo patched successfully", "todo_id", todoID, "user_id", userID)
+
+	return todo, nil
+}
+
+// CompleteByFilter completes every open todo of the user matching the filter
+// and returns a token that reopens them within UndoWindow. Todos that are
+// already completed are not matched, so a replayed request changes nothing.
+func (s *TodoService) CompleteByFilter(ctx context.Context, userID uuid.UUID, req *domain.CompleteByFilterRequest) (*domain.CompleteByFilterResult, error) {
+	filter := req.Filter
+	if filter.IsEmpty() {
+		return nil, apperror.ErrValidation.WithDetails("filter: at least one criterion is required")
+	}
+	if filter.IDs != nil && len(filter.IDs) == 0 {
+		return nil, apperror.ErrValidation.WithDetails("filter.ids: must not be empty")
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return nil, apperror.ErrValidation.WithDetails("filter.created_before: must be after created_after")
+	}
+
+	// Undo tokens are random rather than time-ordered so they cannot be guessed
+	token := uuid.New()
+	expiresAt := time.Now().UTC().Add(UndoWindow)
+
+	count, err := s.todoRepo.CompleteByFilter(ctx, userID, filter, token, expiresAt)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "complete todos by filter", "user_id", userID))
+	}
+
+	s.kpis.RecordTodosCompleted(count)
+	s.logger.InfoContext(ctx, "todos completed by filter", "user_id", userID, "count", count)
+
+	result := &domain.CompleteByFilterResult{Completed: count}
+	if count > 0 {
+		result.UndoToken = &token
+		result.UndoExpiresAt = &expiresAt
+	}
+	return result, nil
+}
+
+// UndoComplete reopens the todos of a bulk completion that were not changed
+// since. Each undo token can be used once.
+func (s *TodoService) UndoComplete(ctx context.Context, userID uuid.UUID, req *domain.UndoCompleteRequest) (*domain.UndoCompleteResult, error) {
+	count, err := s.todoRepo.UndoComplete(ctx, userID, req.UndoToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRowsAffected) {
+			return nil, apperror.NewAppError(
+				apperror.CodeNotFound,
+				"Undo token not found or expired",
+				404,
+				fmt.Errorf("undo token %s not found", req.UndoToken),
+			)
+		}
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "undo todo completion", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "bulk completion undone", "user_id", userID, "count", count)
+
+	return &domain.UndoCompleteResult{Reopened: count}, nil
+}
+
 // Delete deletes a todo
 func (s *TodoService) Delete(ctx context.Context, userID, todoID uuid.UUID) error {
 	// First, verify the todo exists and the user owns it
@@ -131,8 +508,7 @@ func (s *TodoService) Delete(ctx context.Context, userID, todoID uuid.UUID) erro
 
 	// Delete the todo
 	if err := s.todoRepo.Delete(ctx, todoID); err != nil {
-		s.logger.ErrorContext(ctx, "failed to delete todo", "error", err, "todo_id", todoID)
-		return apperror.ErrInternal
+		return apperror.ErrInternal.WithCause(errctx.Wrap(err, "delete todo", "todo_id", todoID))
 	}
 
 	s.logger.InfoContext(ctx, "todo deleted successfully", "todo_id", todoID, "user_id", userID)
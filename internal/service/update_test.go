@@ -0,0 +1,156 @@
+//go:build integration
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/idgen"
+	"github.com/whauzan/todo-api/internal/pkg/password"
+	"github.com/whauzan/todo-api/internal/repository"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+	"github.com/whauzan/todo-api/internal/service"
+	"github.com/whauzan/todo-api/internal/testutil"
+)
+
+// todoDeletedAfterRead deletes each todo right after it is read, as if
+// another request deleted it between the service's read and write
+type todoDeletedAfterRead struct {
+	repository.TodoRepository
+}
+
+func (r todoDeletedAfterRead) GetByID(ctx context.Context, id uuid.UUID) (*domain.Todo, error) {
+	todo, err := r.TodoRepository.GetByID(ctx, id)
+	if err != nil || todo == nil {
+		return todo, err
+	}
+	return todo, r.TodoRepository.Delete(ctx, id)
+}
+
+// userDeletedAfterRead deletes each user right after it is read
+type userDeletedAfterRead struct {
+	repository.UserRepository
+}
+
+func (r userDeletedAfterRead) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err != nil || user == nil {
+		return user, err
+	}
+	return user, r.UserRepository.Delete(ctx, id)
+}
+
+func newTodoService(t *testing.T, db *testutil.Database, todoRepo repository.TodoRepository) *service.TodoService {
+	t.Helper()
+
+	idGen, err := idgen.NewGenerator(4)
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+	logger := testutil.Logger()
+	onboarding := service.NewOnboardingService(postgres.NewOnboardingRepository(db.Pool), logger)
+	limits := domain.TodoLimits{MaxTitleLength: domain.MaxTitleLength, MaxDescriptionLength: domain.MaxDescriptionLength}
+	return service.NewTodoService(todoRepo, postgres.NewUserRepository(db.Pool), idGen, onboarding, nil, limits, logger)
+}
+
+// wantStatus fails the test unless err is an AppError with the status
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	var appErr *apperror.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("error = %v, want AppError with status %d", err, status)
+	}
+	if appErr.Status != status {
+		t.Fatalf("status = %d (%s: %v), want %d", appErr.Status, appErr.Code, appErr.Err, status)
+	}
+}
+
+func TestTodoUpdateDeletedAfterRead(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	todoService := newTodoService(t, db, todoDeletedAfterRead{postgres.NewTodoRepository(db.Pool)})
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "update-race")
+	todos := testutil.SeedTodos(t, db, user.ID, "Updated", "Patched")
+
+	_, err := todoService.Update(ctx, user.ID, todos[0].ID, &domain.UpdateTodoRequest{Completed: domain.Some(true)})
+	wantStatus(t, err, http.StatusNotFound)
+
+	_, err = todoService.Patch(ctx, user.ID, todos[1].ID, func(todo *domain.Todo) error {
+		todo.Completed = true
+		return nil
+	})
+	wantStatus(t, err, http.StatusNotFound)
+}
+
+func TestTodoUpdateRacingDelete(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	todoService := newTodoService(t, db, postgres.NewTodoRepository(db.Pool))
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "update-delete-race")
+	todo := testutil.SeedTodos(t, db, user.ID, "Contended")[0]
+
+	// Every update either lands before the delete or is reported as not
+	// found; none may succeed on a todo that no longer exists
+	const updaters = 20
+	errs := make(chan error, updaters)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < updaters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := todoService.Update(ctx, user.ID, todo.ID, &domain.UpdateTodoRequest{Completed: domain.Some(true)})
+			errs <- err
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		if err := todoService.Delete(ctx, user.ID, todo.ID); err != nil {
+			t.Errorf("Delete: %v", err)
+		}
+	}()
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			wantStatus(t, err, http.StatusNotFound)
+		}
+	}
+
+	_, err := todoService.GetByID(ctx, user.ID, todo.ID)
+	wantStatus(t, err, http.StatusNotFound)
+}
+
+func TestUserUpdateDeletedAfterRead(t *testing.T) {
+	db := testutil.NewDatabase(t)
+	idGen, err := idgen.NewGenerator(4)
+	if err != nil {
+		t.Fatalf("NewGenerator: %v", err)
+	}
+	userRepo := userDeletedAfterRead{postgres.NewUserRepository(db.Pool)}
+	hasher := password.NewHasherWithCost(password.MinCost)
+	authService := service.NewAuthService(userRepo, nil, hasher, idGen, nil, testutil.Logger())
+	ctx := context.Background()
+
+	user := testutil.SeedUser(t, db, "encryption-race")
+	_, err = authService.SetEncryption(ctx, user.ID, true)
+	wantStatus(t, err, http.StatusNotFound)
+
+	user = testutil.SeedUser(t, db, "telemetry-race")
+	_, err = authService.SetTelemetry(ctx, user.ID, false)
+	wantStatus(t, err, http.StatusNotFound)
+}
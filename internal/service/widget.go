@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/apperror"
+	"github.com/whauzan/todo-api/internal/pkg/errctx"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/internal/repository"
+)
+
+const (
+	// DefaultWidgetLimit is the number of todos a widget shows when it sets no limit
+	DefaultWidgetLimit = 20
+	// MaxWidgetLimit is the largest number of todos a widget may request
+	MaxWidgetLimit = 100
+)
+
+// WidgetService handles tokens and data for todo lists embedded on other sites
+type WidgetService struct {
+	userRepo     repository.UserRepository
+	todoRepo     repository.TodoRepository
+	tokenManager *jwt.TokenManager
+	tokenTTL     time.Duration
+	logger       *slog.Logger
+}
+
+// NewWidgetService creates a new WidgetService issuing tokens valid for tokenTTL
+func NewWidgetService(
+	userRepo repository.UserRepository,
+	todoRepo repository.TodoRepository,
+	tokenManager *jwt.TokenManager,
+	tokenTTL time.Duration,
+	logger *slog.Logger,
+) *WidgetService {
+	return &WidgetService{
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		tokenManager: tokenManager,
+		tokenTTL:     tokenTTL,
+		logger:       logger,
+	}
+}
+
+// IssueToken issues a widget token bound to the origin in the request
+func (s *WidgetService) IssueToken(ctx context.Context, userID uuid.UUID, req *domain.CreateWidgetTokenRequest) (*domain.WidgetToken, error) {
+	origin, err := normalizeOrigin(req.Origin)
+	if err != nil {
+		return nil, apperror.ErrValidation.WithDetails("origin: " + err.Error())
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "get user by ID", "user_id", userID))
+	}
+	if user == nil {
+		return nil, userNotFound(userID)
+	}
+
+	// The widget runs on a page the user does not control, so it only gets the widget scope
+	scopes := []string{jwt.ScopeWidget}
+	tokenResp, err := s.tokenManager.GenerateOriginToken(user.ID, user.Email, user.TokenVersion, scopes, origin, s.tokenTTL)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "generate widget token", "user_id", userID))
+	}
+
+	s.logger.InfoContext(ctx, "widget token issued", "user_id", userID, "origin", origin)
+
+	return &domain.WidgetToken{
+		Token:     tokenResp.Token,
+		ExpiresAt: tokenResp.ExpiresAt,
+		Origin:    origin,
+		Scopes:    tokenResp.Scopes,
+	}, nil
+}
+
+// ListTodos retrieves up to limit open todos of the user, newest first.
+// Encrypted todos are left out because the server cannot show their content.
+func (s *WidgetService) ListTodos(ctx context.Context, userID uuid.UUID, limit int) ([]*domain.WidgetTodo, error) {
+	if limit < 1 || limit > MaxWidgetLimit {
+		return nil, apperror.ErrValidation.WithDetails(fmt.Sprintf("limit: must be between 1 and %d", MaxWidgetLimit))
+	}
+
+	todos, err := s.todoRepo.ListByUserIDAndStatus(ctx, userID, false)
+	if err != nil {
+		return nil, apperror.ErrInternal.WithCause(errctx.Wrap(err, "list widget todos", "user_id", userID))
+	}
+
+	items := make([]*domain.WidgetTodo, 0, min(len(todos), limit))
+	for _, todo := range todos {
+		if todo.Encrypted {
+			continue
+		}
+		items = append(items, &domain.WidgetTodo{
+			ID:        todo.ID,
+			Title:     todo.Title,
+			Completed: todo.Completed,
+			CreatedAt: todo.CreatedAt,
+		})
+		if len(items) == limit {
+			break
+		}
+	}
+
+	return items, nil
+}
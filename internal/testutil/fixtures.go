@@ -0,0 +1,59 @@
+//go:build integration
+
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/password"
+	"github.com/whauzan/todo-api/internal/repository/postgres"
+)
+
+// FixturePassword is the password of every seeded user
+const FixturePassword = "fixture-password"
+
+// SeedUser creates an active user that signs in with FixturePassword.
+// The email is unique to the call, so tests can seed several users.
+func SeedUser(t testing.TB, db *Database, name string) *domain.User {
+	t.Helper()
+
+	// Bcrypt's minimum cost keeps fixtures fast; Verify reads the cost from the hash
+	hash, err := password.NewHasherWithCost(password.MinCost).Hash(FixturePassword)
+	if err != nil {
+		t.Fatalf("failed to hash fixture password: %v", err)
+	}
+
+	user := &domain.User{
+		ID:           uuid.New(),
+		Email:        name + "-" + randomHex(t, 4) + "@example.com",
+		PasswordHash: hash,
+		Name:         name,
+	}
+	if err := postgres.NewUserRepository(db.Pool).Create(context.Background(), user); err != nil {
+		t.Fatalf("failed to seed user: %v", err)
+	}
+	return user
+}
+
+// SeedTodos creates an open todo with each title for the user
+func SeedTodos(t testing.TB, db *Database, userID uuid.UUID, titles ...string) []*domain.Todo {
+	t.Helper()
+
+	repo := postgres.NewTodoRepository(db.Pool)
+	todos := make([]*domain.Todo, 0, len(titles))
+	for _, title := range titles {
+		todo := &domain.Todo{
+			ID:     uuid.New(),
+			UserID: userID,
+			Title:  title,
+		}
+		if err := repo.Create(context.Background(), todo); err != nil {
+			t.Fatalf("failed to seed todo %q: %v", title, err)
+		}
+		todos = append(todos, todo)
+	}
+	return todos
+}
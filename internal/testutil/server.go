@@ -0,0 +1,79 @@
+//go:build integration
+
+package testutil
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/whauzan/todo-api/internal/config"
+	"github.com/whauzan/todo-api/internal/domain"
+	"github.com/whauzan/todo-api/internal/pkg/jwt"
+	"github.com/whauzan/todo-api/pkg/client"
+)
+
+// jwtSecret signs the tokens of the test servers
+const jwtSecret = "integration-test-secret-0123456789abcdef"
+
+// Config returns the configuration the tests run the server with: the struct
+// defaults, the test database and a fixed JWT secret, then the overrides.
+// The process environment, profiles and CONFIG_FILE are ignored so a
+// developer's .env cannot change the tests.
+func Config(t testing.TB, db *Database, overrides map[string]string) *config.Config {
+	t.Helper()
+
+	environment := map[string]string{
+		"DATABASE_URL": db.URL,
+		"JWT_SECRET":   jwtSecret,
+	}
+	for k, v := range overrides {
+		environment[k] = v
+	}
+
+	cfg := &config.Config{}
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: environment}); err != nil {
+		t.Fatalf("failed to parse test config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+	return cfg
+}
+
+// Logger returns a logger that discards records unless the tests run with -v.
+// Records go to stderr rather than the test log, because background work may
+// still log after a test ends.
+func Logger() *slog.Logger {
+	var out io.Writer = io.Discard
+	if testing.Verbose() {
+		out = os.Stderr
+	}
+	return slog.New(slog.NewTextHandler(out, nil))
+}
+
+// Serve starts the handler on an httptest server for the duration of the
+// test and returns an API client pointed at it
+func Serve(t testing.TB, h http.Handler) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return client.New(srv.URL, client.WithHTTPClient(srv.Client()))
+}
+
+// Token signs a token for user that grants only scopes, as the token
+// endpoints would for another client type
+func Token(t testing.TB, cfg *config.Config, user *domain.User, scopes ...string) string {
+	t.Helper()
+
+	token, err := jwt.NewTokenManager(cfg.JWTSecret, cfg.JWTExpiryHours).GenerateToken(user.ID, user.Email, user.TokenVersion, scopes)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token.Token
+}
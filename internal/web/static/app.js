@@ -0,0 +1,132 @@
+(function () {
+  "use strict";
+
+  var TOKEN_KEY = "todo.token";
+  var EMAIL_KEY = "todo.email";
+
+  var loginView = document.getElementById("login-view");
+  var todosView = document.getElementById("todos-view");
+  var todoList = document.getElementById("todo-list");
+  var emptyState = document.getElementById("empty-state");
+  var errorBox = document.getElementById("error");
+
+  function showError(message) {
+    errorBox.textContent = message;
+    errorBox.hidden = !message;
+  }
+
+  function api(method, path, body) {
+    var headers = { "Accept": "application/json" };
+    var token = localStorage.getItem(TOKEN_KEY);
+    if (token) {
+      headers["Authorization"] = "Bearer " + token;
+    }
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+
+    return fetch("/api/v1" + path, {
+      method: method,
+      headers: headers,
+      body: body === undefined ? undefined : JSON.stringify(body)
+    }).then(function (resp) {
+      if (resp.status === 204) {
+        return null;
+      }
+      return resp.json().then(function (envelope) {
+        if (resp.status === 401) {
+          logout();
+        }
+        if (!envelope.success) {
+          var err = envelope.error || {};
+          var details = err.details ? " (" + err.details.join(", ") + ")" : "";
+          throw new Error((err.message || "Request failed") + details);
+        }
+        return envelope.data;
+      });
+    });
+  }
+
+  function render(todos) {
+    todoList.innerHTML = "";
+    emptyState.hidden = todos.length > 0;
+
+    todos.forEach(function (todo) {
+      var item = document.createElement("li");
+      item.className = todo.completed ? "completed" : "";
+
+      var checkbox = document.createElement("input");
+      checkbox.type = "checkbox";
+      checkbox.checked = todo.completed;
+      checkbox.addEventListener("change", function () {
+        api("PATCH", "/todos/" + todo.id, { completed: checkbox.checked })
+          .then(load)
+          .catch(function (err) { showError(err.message); });
+      });
+
+      var title = document.createElement("span");
+      // The web UI holds no keys, so encrypted content stays hidden
+      title.textContent = todo.encrypted ? "(encrypted)" : todo.title;
+
+      item.appendChild(checkbox);
+      item.appendChild(title);
+      todoList.appendChild(item);
+    });
+  }
+
+  function load() {
+    return api("GET", "/todos")
+      .then(render)
+      .catch(function (err) { showError(err.message); });
+  }
+
+  function showView() {
+    var loggedIn = !!localStorage.getItem(TOKEN_KEY);
+    loginView.hidden = loggedIn;
+    todosView.hidden = !loggedIn;
+    if (loggedIn) {
+      document.getElementById("user-email").textContent = localStorage.getItem(EMAIL_KEY) || "";
+      load();
+    }
+  }
+
+  function logout() {
+    localStorage.removeItem(TOKEN_KEY);
+    localStorage.removeItem(EMAIL_KEY);
+    showView();
+  }
+
+  document.getElementById("login-form").addEventListener("submit", function (event) {
+    event.preventDefault();
+    var form = event.target;
+    showError("");
+
+    api("POST", "/auth/login", { email: form.email.value, password: form.password.value })
+      .then(function (data) {
+        localStorage.setItem(TOKEN_KEY, data.token);
+        localStorage.setItem(EMAIL_KEY, data.user.email);
+        form.reset();
+        showView();
+      })
+      .catch(function (err) { showError(err.message); });
+  });
+
+  document.getElementById("create-form").addEventListener("submit", function (event) {
+    event.preventDefault();
+    var form = event.target;
+    showError("");
+
+    api("POST", "/todos", { title: form.title.value })
+      .then(function () {
+        form.reset();
+        return load();
+      })
+      .catch(function (err) { showError(err.message); });
+  });
+
+  document.getElementById("logout-button").addEventListener("click", function () {
+    api("POST", "/auth/logout").finally(logout);
+  });
+
+  showView();
+})();
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is the default HTTP timeout used by the client
+const DefaultTimeout = 15 * time.Second
+
+// Client is a typed client for the todo API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      string
+	userAgent  string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken sets the bearer token used for authenticated requests
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a new Client for the API served at baseURL (e.g. http://localhost:8080)
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		userAgent:  "todo-api-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SetToken replaces the bearer token used for authenticated requests
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Token returns the bearer token currently used by the client
+func (c *Client) Token() string {
+	return c.token
+}
+
+// envelope is the standard API response envelope
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Error   *APIError       `json:"error,omitempty"`
+	Meta    *meta           `json:"meta,omitempty"`
+}
+
+// meta is the metadata of a response envelope
+type meta struct {
+	RequestID string `json:"request_id"`
+	Cursor    *struct {
+		Limit      int    `json:"limit"`
+		NextCursor string `json:"next_cursor"`
+		HasMore    bool   `json:"has_more"`
+	} `json:"cursor,omitempty"`
+}
+
+// APIError is returned when the API responds with an error envelope
+type APIError struct {
+	StatusCode int      `json:"-"`
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Details    []string `json:"details,omitempty"`
+	// RequestID identifies the failed request in the server logs
+	RequestID string `json:"-"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if len(e.Details) > 0 {
+		return fmt.Sprintf("%s (%d): %s: %s", e.Code, e.StatusCode, e.Message, strings.Join(e.Details, "; "))
+	}
+	return fmt.Sprintf("%s (%d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// do performs a request and decodes the envelope data into out (if non-nil)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	_, err := c.doMeta(ctx, method, path, body, out)
+	return err
+}
+
+// doMeta is do that also returns the envelope metadata, if any
+func (c *Client) doMeta(ctx context.Context, method, path string, body, out interface{}) (*meta, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Actions with nothing to return respond without a body
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		if resp.StatusCode >= 400 {
+			return nil, &APIError{
+				StatusCode: resp.StatusCode,
+				Code:       http.StatusText(resp.StatusCode),
+				Message:    "unexpected non-JSON error response",
+			}
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !env.Success || resp.StatusCode >= 400 {
+		apiErr := env.Error
+		if apiErr == nil {
+			apiErr = &APIError{Code: http.StatusText(resp.StatusCode)}
+		}
+		apiErr.StatusCode = resp.StatusCode
+		if env.Meta != nil {
+			apiErr.RequestID = env.Meta.RequestID
+		}
+		return nil, apiErr
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, fmt.Errorf("failed to decode response data: %w", err)
+		}
+	}
+
+	return env.Meta, nil
+}
@@ -0,0 +1,251 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/whauzan/todo-api/pkg/client"
+)
+
+// writeEnvelope writes a success envelope the way the API does
+func writeEnvelope(t *testing.T, w http.ResponseWriter, status int, data, meta any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	env := map[string]any{"success": true, "data": data}
+	if meta != nil {
+		env["meta"] = meta
+	}
+	if err := json.NewEncoder(w).Encode(env); err != nil {
+		t.Errorf("failed to write envelope: %v", err)
+	}
+}
+
+func newServer(t *testing.T, mux *http.ServeMux) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return client.New(srv.URL + "/")
+}
+
+func TestLoginStoresTokenForLaterRequests(t *testing.T) {
+	const token = "issued-token"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		var req client.LoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode login body: %v", err)
+		}
+		if req.Email != "user@example.com" || req.Client != "api-key" {
+			t.Errorf("login body = %+v", req)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("login sent Authorization %q", got)
+		}
+		writeEnvelope(t, w, http.StatusOK, map[string]any{
+			"token":  token,
+			"scopes": []string{"todos:read"},
+			"user":   map[string]any{"email": req.Email},
+		}, nil)
+	})
+	mux.HandleFunc("GET /api/v1/todos", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+token {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		writeEnvelope(t, w, http.StatusOK, []any{}, nil)
+	})
+	mux.HandleFunc("POST /api/v1/auth/logout", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newServer(t, mux)
+	ctx := context.Background()
+
+	resp, err := c.Login(ctx, &client.LoginRequest{Email: "user@example.com", Password: "secret", Client: "api-key"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if resp.Token != token || c.Token() != token {
+		t.Fatalf("token = %q, client token = %q, want %q", resp.Token, c.Token(), token)
+	}
+	if len(resp.Scopes) != 1 || resp.Scopes[0] != "todos:read" {
+		t.Errorf("scopes = %v", resp.Scopes)
+	}
+
+	if _, err := c.ListTodos(ctx, nil); err != nil {
+		t.Fatalf("ListTodos: %v", err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if c.Token() != "" {
+		t.Errorf("token after logout = %q, want empty", c.Token())
+	}
+}
+
+func TestListTodosPageFollowsCursor(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/todos", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has("cursor") {
+			t.Errorf("request without cursor would list every todo: %s", r.URL.RawQuery)
+		}
+		if got := query.Get("limit"); got != "2" {
+			t.Errorf("limit = %q, want 2", got)
+		}
+
+		switch query.Get("cursor") {
+		case "":
+			writeEnvelope(t, w, http.StatusOK, []map[string]any{{"id": ids[0]}, {"id": ids[1]}},
+				map[string]any{"cursor": map[string]any{"limit": 2, "next_cursor": "page-2", "has_more": true}})
+		case "page-2":
+			writeEnvelope(t, w, http.StatusOK, []map[string]any{{"id": ids[2]}},
+				map[string]any{"cursor": map[string]any{"limit": 2, "has_more": false}})
+		default:
+			t.Errorf("unexpected cursor %q", query.Get("cursor"))
+		}
+	})
+
+	c := newServer(t, mux)
+
+	var got []uuid.UUID
+	opts := &client.ListTodosOptions{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages == 3 {
+			t.Fatal("pagination did not stop")
+		}
+		page, err := c.ListTodosPage(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("ListTodosPage: %v", err)
+		}
+		for _, todo := range page.Todos {
+			got = append(got, todo.ID)
+		}
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("last page has next cursor %q", page.NextCursor)
+			}
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if len(got) != len(ids) {
+		t.Fatalf("got %d todos, want %d", len(got), len(ids))
+	}
+	for i := range ids {
+		if got[i] != ids[i] {
+			t.Errorf("todo %d = %s, want %s", i, got[i], ids[i])
+		}
+	}
+}
+
+func TestDeleteTodoNoContent(t *testing.T) {
+	id := uuid.New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v1/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != id.String() {
+			t.Errorf("deleted %s, want %s", r.PathValue("id"), id)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	c := newServer(t, mux)
+	if err := c.DeleteTodo(context.Background(), id); err != nil {
+		t.Fatalf("DeleteTodo: %v", err)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    client.APIError
+		wantMsg string
+	}{
+		{
+			name:   "error envelope",
+			status: http.StatusBadRequest,
+			body: `{"success":false,"error":{"code":"VALIDATION_ERROR","message":"Validation failed",` +
+				`"details":["title: is required"]},"meta":{"request_id":"req-1"}}`,
+			want: client.APIError{
+				StatusCode: http.StatusBadRequest,
+				Code:       "VALIDATION_ERROR",
+				Message:    "Validation failed",
+				Details:    []string{"title: is required"},
+				RequestID:  "req-1",
+			},
+			wantMsg: "VALIDATION_ERROR (400): Validation failed: title: is required",
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+			body:   `{"success":false,"error":{"code":"NOT_FOUND","message":"Todo not found"}}`,
+			want: client.APIError{
+				StatusCode: http.StatusNotFound,
+				Code:       "NOT_FOUND",
+				Message:    "Todo not found",
+			},
+			wantMsg: "NOT_FOUND (404): Todo not found",
+		},
+		{
+			name:   "non-JSON body",
+			status: http.StatusBadGateway,
+			body:   "<html>bad gateway</html>",
+			want: client.APIError{
+				StatusCode: http.StatusBadGateway,
+				Code:       "Bad Gateway",
+				Message:    "unexpected non-JSON error response",
+			},
+		},
+		{
+			name:   "envelope without error",
+			status: http.StatusInternalServerError,
+			body:   `{"success":false}`,
+			want: client.APIError{
+				StatusCode: http.StatusInternalServerError,
+				Code:       "Internal Server Error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/v1/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			})
+
+			c := newServer(t, mux)
+			_, err := c.GetTodo(context.Background(), uuid.New())
+
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *client.APIError", err)
+			}
+			if apiErr.StatusCode != tt.want.StatusCode || apiErr.Code != tt.want.Code ||
+				apiErr.Message != tt.want.Message || apiErr.RequestID != tt.want.RequestID {
+				t.Errorf("error = %+v, want %+v", *apiErr, tt.want)
+			}
+			if len(apiErr.Details) != len(tt.want.Details) {
+				t.Errorf("details = %v, want %v", apiErr.Details, tt.want.Details)
+			}
+			if tt.wantMsg != "" && apiErr.Error() != tt.wantMsg {
+				t.Errorf("Error() = %q, want %q", apiErr.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// ListTodosOptions selects a page of todos. Limit is 1-100, or 0 for the
+// server default; Cursor is the NextCursor of the previous page, or empty for
+// the first page.
+type ListTodosOptions struct {
+	Limit  int
+	Cursor string
+}
+
+// TodoPage is one page of todos
+type TodoPage struct {
+	Todos      []*Todo
+	NextCursor string
+	HasMore    bool
+}
+
+// ListTodosPage retrieves one page of todos for the authenticated user.
+// Unlike ListTodos, it returns the cursor of the next page.
+func (c *Client) ListTodosPage(ctx context.Context, opts *ListTodosOptions) (*TodoPage, error) {
+	// An empty cursor still asks for the first page rather than every todo
+	params := &ListTodosParams{Cursor: new(string)}
+	if opts != nil {
+		params.Cursor = &opts.Cursor
+		if opts.Limit > 0 {
+			params.Limit = &opts.Limit
+		}
+	}
+
+	page := &TodoPage{}
+	m, err := c.doMeta(ctx, http.MethodGet, "/api/v1/todos"+params.encode(), nil, &page.Todos)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Cursor != nil {
+		page.NextCursor = m.Cursor.NextCursor
+		page.HasMore = m.Cursor.HasMore
+	}
+	return page, nil
+}
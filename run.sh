@@ -10,4 +10,4 @@ if [ ! -f .env ]; then
 fi
 
 # Load environment variables and run the app
-env $(cat .env | grep -v '^#' | grep -v '^$' | xargs) go run cmd/api/main.go
+env $(cat .env | grep -v '^#' | grep -v '^$' | xargs) go run ./cmd/api
@@ -76,6 +76,223 @@ CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
 -- Trigger to automatically update updated_at on todos table
 CREATE TRIGGER update_todos_updated_at BEFORE UPDATE ON todos
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Support keyset (cursor) pagination ordered by creation time
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_created_at_id ON todos(user_id, created_at DESC, id DESC);
+
+-- Record deleted todos so sync clients can learn about deletions
+CREATE TABLE IF NOT EXISTS todo_tombstones (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_todo_tombstones_user_id_deleted_at ON todo_tombstones(user_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_todos_user_id_updated_at ON todos(user_id, updated_at);
+
+-- End-to-end encryption mode
+ALTER TABLE users ADD COLUMN IF NOT EXISTS e2e_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS encrypted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS title_ciphertext BYTEA;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS description_ciphertext BYTEA;
+
+-- Account status
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'suspended', 'pending_deletion'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status_reason TEXT;
+
+-- Token version for revoking issued access tokens
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+-- Announcement banners and per-user dismissals
+CREATE TABLE IF NOT EXISTS announcements (
+    id UUID PRIMARY KEY,
+    message TEXT NOT NULL,
+    severity VARCHAR(16) NOT NULL DEFAULT 'info'
+        CHECK (severity IN ('info', 'warning', 'critical')),
+    starts_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_announcements_starts_at_ends_at ON announcements(starts_at, ends_at);
+CREATE TABLE IF NOT EXISTS announcement_dismissals (
+    announcement_id UUID NOT NULL REFERENCES announcements(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    dismissed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (announcement_id, user_id)
+);
+
+-- Onboarding checklist
+CREATE TABLE IF NOT EXISTS onboarding_steps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    step VARCHAR(64) NOT NULL,
+    completed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, step)
+);
+
+-- In-app notification inbox
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT,
+    todo_id UUID REFERENCES todos(id) ON DELETE SET NULL,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_created_at_id ON notifications(user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- Case-insensitive email uniqueness
+UPDATE users SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
+
+-- Product analytics opt-out
+ALTER TABLE users ADD COLUMN IF NOT EXISTS telemetry_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Grace period for requested account deletions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purge_after TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_users_purge_after ON users(purge_after) WHERE purge_after IS NOT NULL;
+
+-- Undo tokens for bulk completions
+CREATE TABLE IF NOT EXISTS todo_undo_tokens (
+    token UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_ids UUID[] NOT NULL,
+    completed_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_todo_undo_tokens_user_id_expires_at ON todo_undo_tokens(user_id, expires_at);
+
+-- CORS origin allowlist for embedded widgets
+CREATE TABLE IF NOT EXISTS cors_origins (
+    origin VARCHAR(255) PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Last change sequence number handed out per user
+CREATE TABLE IF NOT EXISTS user_change_seqs (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+-- Change feed: the latest change of each todo, numbered per user in commit order
+CREATE TABLE IF NOT EXISTS todo_changes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seq BIGINT NOT NULL,
+    todo_id UUID NOT NULL,
+    op VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, seq)
+);
+
+-- Create index on user_id and todo_id for replacing a todo's earlier change
+CREATE INDEX IF NOT EXISTS idx_todo_changes_user_id_todo_id ON todo_changes(user_id, todo_id);
+
+-- Function to record every todo mutation in the change feed. The sequence row
+-- stays locked until the transaction commits, so a user's changes become
+-- visible in sequence order and readers never skip one.
+CREATE OR REPLACE FUNCTION record_todo_change()
+RETURNS TRIGGER AS \$\$
+DECLARE
+    change_user_id UUID;
+    change_todo_id UUID;
+    change_op VARCHAR(10);
+    next_seq BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        change_user_id := OLD.user_id;
+        change_todo_id := OLD.id;
+        change_op := 'delete';
+        -- The todos of a deleted user go with it
+        IF NOT EXISTS (SELECT 1 FROM users WHERE id = change_user_id) THEN
+            RETURN NULL;
+        END IF;
+    ELSE
+        change_user_id := NEW.user_id;
+        change_todo_id := NEW.id;
+        change_op := 'upsert';
+    END IF;
+
+    INSERT INTO user_change_seqs (user_id, last_seq) VALUES (change_user_id, 1)
+    ON CONFLICT (user_id) DO UPDATE SET last_seq = user_change_seqs.last_seq + 1
+    RETURNING last_seq INTO next_seq;
+
+    DELETE FROM todo_changes WHERE user_id = change_user_id AND todo_id = change_todo_id;
+    INSERT INTO todo_changes (user_id, seq, todo_id, op)
+    VALUES (change_user_id, next_seq, change_todo_id, change_op);
+
+    RETURN NULL;
+END;
+\$\$ language 'plpgsql';
+
+-- Trigger to record changes on todos table
+DROP TRIGGER IF EXISTS record_todos_changes ON todos;
+CREATE TRIGGER record_todos_changes AFTER INSERT OR UPDATE OR DELETE ON todos
+    FOR EACH ROW EXECUTE FUNCTION record_todo_change();
+
+-- Plaintext descriptions are at most 2000 characters
+ALTER TABLE todos DROP CONSTRAINT IF EXISTS todos_description_length;
+ALTER TABLE todos ADD CONSTRAINT todos_description_length CHECK (char_length(description) <= 2000);
+
+-- REST hook subscriptions
+CREATE TABLE IF NOT EXISTS hook_subscriptions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    target_url TEXT NOT NULL,
+    last_seq BIGINT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_hook_subscriptions_user_id ON hook_subscriptions(user_id);
+
+-- Audit log of agent tool calls
+CREATE TABLE IF NOT EXISTS agent_actions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tool VARCHAR(100) NOT NULL,
+    arguments JSONB,
+    outcome VARCHAR(10) NOT NULL,
+    error_code VARCHAR(50),
+    todo_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_agent_actions_user_id_created_at_id ON agent_actions(user_id, created_at DESC, id DESC);
+
+-- Client IP address of agent tool calls
+ALTER TABLE agent_actions ADD COLUMN IF NOT EXISTS client_ip VARCHAR(45);
+
+-- Hook subscriptions select several events and keep their payload version
+DO \$\$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'hook_subscriptions' AND column_name = 'event') THEN
+        ALTER TABLE hook_subscriptions ADD COLUMN IF NOT EXISTS events TEXT[];
+        UPDATE hook_subscriptions SET events = ARRAY[event] WHERE events IS NULL;
+        ALTER TABLE hook_subscriptions ALTER COLUMN events SET NOT NULL, DROP COLUMN event;
+    END IF;
+END;
+\$\$;
+ALTER TABLE hook_subscriptions ADD COLUMN IF NOT EXISTS payload_version INTEGER NOT NULL DEFAULT 1;
+
+-- Delivery attempts of REST hooks
+CREATE TABLE IF NOT EXISTS hook_deliveries (
+    id UUID PRIMARY KEY,
+    hook_id UUID NOT NULL REFERENCES hook_subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event VARCHAR(50) NOT NULL,
+    seq BIGINT NOT NULL,
+    payload_version INTEGER NOT NULL,
+    request_body JSONB NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    succeeded BOOLEAN NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    redelivery BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_hook_deliveries_hook_id_created_at_id ON hook_deliveries(hook_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_hook_deliveries_created_at ON hook_deliveries(created_at);
 EOF
 
 echo "✅ Database setup complete!"